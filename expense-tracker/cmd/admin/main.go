@@ -0,0 +1,320 @@
+// Command admin runs operator tasks against the database configured by the
+// same configuration file and environment variables as the API.
+//
+//	go run ./cmd/admin migrate up
+//	go run ./cmd/admin migrate down -steps 1
+//	go run ./cmd/admin migrate status
+//	go run ./cmd/admin version
+//	go run ./cmd/admin user create -email jane@example.com -name Jane
+//	go run ./cmd/admin user disable -email jane@example.com
+//	go run ./cmd/admin user enable -email jane@example.com
+//	go run ./cmd/admin user reset-password -email jane@example.com
+//	go run ./cmd/admin seed -email demo@example.com -months 3
+//	go run ./cmd/admin vacuum
+//
+// Without -password, user create, reset-password and seed generate a
+// password and print it once. Commands that change data refuse to run
+// until pending migrations are applied.
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/events"
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/infrastructure/database"
+	"expense-tracker/internal/infrastructure/repositories"
+	"expense-tracker/internal/pkg/validation"
+	"expense-tracker/migrations"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type admin struct {
+	db       *sqlx.DB
+	migrator *database.Migrator
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(a *admin, ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"migrate", "up, down [-steps N] or status", (*admin).migrate},
+	{"version", "print the schema version", (*admin).version},
+	{"user", "create, disable, enable or reset-password", (*admin).user},
+	{"seed", "create a demo account with generated expenses", (*admin).seed},
+	{"vacuum", "reclaim space and refresh planner statistics", (*admin).vacuum},
+}
+
+func main() {
+	log.SetFlags(0)
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration `file`")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	var run func(a *admin, ctx context.Context, args []string) error
+	for _, cmd := range commands {
+		if cmd.name == flag.Arg(0) {
+			run = cmd.run
+		}
+	}
+	if run == nil {
+		log.Printf("admin: unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	settings, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatalf("admin: %v", err)
+	}
+	db, err := database.Connect(settings.Database)
+	if err != nil {
+		log.Fatalf("Could not connect to database: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		log.Fatalf("Could not load migrations: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	a := &admin{db: db, migrator: migrator}
+	if err := run(a, ctx, flag.Args()[1:]); err != nil {
+		db.Close()
+		log.Fatalf("admin %s: %v", flag.Arg(0), err)
+	}
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: admin [-config file] <command> [arguments]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out, "\nThe database is configured like the API's, with -config or CONFIG_FILE and the DB_* environment variables.")
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("admin "+name, flag.ExitOnError)
+}
+
+func (a *admin) migrate(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing subcommand: up, down or status")
+	}
+
+	fs := newFlagSet("migrate " + args[0])
+	steps := 1
+	if args[0] == "down" {
+		fs.IntVar(&steps, "steps", 1, "number of migrations to roll back")
+	}
+	fs.Parse(args[1:])
+
+	switch args[0] {
+	case "up":
+		applied, err := a.migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migrations\n", applied)
+	case "down":
+		rolledBack, err := a.migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %d migrations\n", rolledBack)
+	case "status":
+		statuses, err := a.migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(tw, "%04d\t%s\t%s\n", status.Version, status.Name, applied)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown subcommand %q", args[0])
+	}
+	return a.version(ctx, nil)
+}
+
+func (a *admin) version(ctx context.Context, args []string) error {
+	newFlagSet("version").Parse(args)
+	version, err := a.migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Schema version %d (latest %d, %s)\n", version, a.migrator.Latest(), a.db.DriverName())
+	return nil
+}
+
+// requireCurrentSchema keeps commands that write data from running against
+// a schema this build does not expect.
+func (a *admin) requireCurrentSchema(ctx context.Context) error {
+	version, err := a.migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version != a.migrator.Latest() {
+		return fmt.Errorf("schema is at version %d, expected %d; run: admin migrate up", version, a.migrator.Latest())
+	}
+	return nil
+}
+
+func (a *admin) service() *services.AdminService {
+	tx := database.NewTxManager(a.db)
+	bus := events.NewBus(repositories.NewOutboxRepository(a.db))
+	expenseService := services.NewExpenseService(tx, repositories.NewExpenseRepository(a.db), repositories.NewExpenseRevisionRepository(a.db), bus)
+	return services.NewAdminService(tx, repositories.NewUserRepository(a.db), repositories.NewLoginAttemptRepository(a.db), expenseService, bus)
+}
+
+func (a *admin) user(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing subcommand: create, disable, enable or reset-password")
+	}
+
+	fs := newFlagSet("user " + args[0])
+	email := fs.String("email", "", "account email")
+	var name, password *string
+	switch args[0] {
+	case "create":
+		name = fs.String("name", "", "display name")
+		password = fs.String("password", "", "password (default generated)")
+	case "reset-password":
+		password = fs.String("password", "", "new password (default generated)")
+	case "disable", "enable":
+	default:
+		return fmt.Errorf("unknown subcommand %q", args[0])
+	}
+	fs.Parse(args[1:])
+	if *email == "" {
+		return fmt.Errorf("-email is required")
+	}
+
+	generated := password != nil && *password == ""
+	if generated {
+		*password = generatePassword()
+	}
+
+	if err := a.requireCurrentSchema(ctx); err != nil {
+		return err
+	}
+	svc := a.service()
+
+	switch args[0] {
+	case "create":
+		req := dto.RegisterRequest{Email: *email, Password: *password, Name: *name}
+		if err := validation.NewValidator().Validate(req); err != nil {
+			return err
+		}
+		user, err := svc.CreateUser(ctx, req)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created user %s (%s)\n", user.Email, user.ID)
+	case "reset-password":
+		// The same minimum length as dto.RegisterRequest
+		if len(*password) < 6 {
+			return fmt.Errorf("password must be at least 6 characters")
+		}
+		if err := svc.ResetPassword(ctx, *email, *password); err != nil {
+			return err
+		}
+		fmt.Printf("Reset the password of %s\n", *email)
+	case "disable", "enable":
+		user, err := svc.SetDisabled(ctx, *email, args[0] == "disable")
+		if err != nil {
+			return err
+		}
+		if user.DisabledAt != nil {
+			fmt.Printf("Disabled %s\n", user.Email)
+		} else {
+			fmt.Printf("Enabled %s\n", user.Email)
+		}
+	}
+
+	if generated {
+		fmt.Printf("Password: %s\n", *password)
+	}
+	return nil
+}
+
+func (a *admin) seed(ctx context.Context, args []string) error {
+	fs := newFlagSet("seed")
+	req := dto.RegisterRequest{}
+	fs.StringVar(&req.Email, "email", "demo@example.com", "email of the demo account")
+	fs.StringVar(&req.Name, "name", "Demo User", "name of the demo account")
+	fs.StringVar(&req.Password, "password", "", "password (default generated)")
+	months := fs.Int("months", 3, "months of expenses to generate, up to today")
+	fs.Parse(args)
+
+	generated := req.Password == ""
+	if generated {
+		req.Password = generatePassword()
+	}
+	if err := validation.NewValidator().Validate(req); err != nil {
+		return err
+	}
+	if *months < 1 {
+		return fmt.Errorf("-months must be at least 1")
+	}
+
+	if err := a.requireCurrentSchema(ctx); err != nil {
+		return err
+	}
+	user, created, err := a.service().SeedDemoData(ctx, req, *months)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created user %s (%s) with %d expenses\n", user.Email, user.ID, created)
+	if generated {
+		fmt.Printf("Password: %s\n", req.Password)
+	}
+	return nil
+}
+
+func (a *admin) vacuum(ctx context.Context, args []string) error {
+	newFlagSet("vacuum").Parse(args)
+	if err := database.Vacuum(ctx, a.db); err != nil {
+		return err
+	}
+	fmt.Println("Vacuumed and analyzed the database")
+	return nil
+}
+
+func generatePassword() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("Could not generate a password: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
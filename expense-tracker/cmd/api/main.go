@@ -1,342 +1,487 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"expense-tracker/internal/application/events"
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/config"
+	domain "expense-tracker/internal/domain/repositories"
+	"expense-tracker/internal/infrastructure/database"
+	"expense-tracker/internal/infrastructure/grpcserver"
+	"expense-tracker/internal/infrastructure/health"
+	"expense-tracker/internal/infrastructure/http/handlers"
+	"expense-tracker/internal/infrastructure/http/middleware"
+	"expense-tracker/internal/infrastructure/idempotency"
+	"expense-tracker/internal/infrastructure/jwt"
+	"expense-tracker/internal/infrastructure/mailer"
+	"expense-tracker/internal/infrastructure/metrics"
+	"expense-tracker/internal/infrastructure/ratelimit"
+	"expense-tracker/internal/infrastructure/repositories"
+	"expense-tracker/internal/infrastructure/repositories/memory"
+	"expense-tracker/internal/infrastructure/storage"
+	"expense-tracker/internal/infrastructure/tracing"
+	"expense-tracker/internal/infrastructure/webhook"
+	"expense-tracker/internal/pkg/logger"
+	"expense-tracker/internal/pkg/validation"
+	"expense-tracker/migrations"
+
 	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/mattn/go-sqlite3"
+	"google.golang.org/grpc"
 )
 
-// Config ساده
-type Config struct {
-	ServerPort string
-	JWTSecret  string
-}
-
-func loadConfig() *Config {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8081" // پورت پیش‌فرض
-	}
-	
-	return &Config{
-		ServerPort: port,
-		JWTSecret:  "your-secret-key-change-in-production",
+// randomSecret signs tokens of this process only, for development without
+// a configured JWT secret.
+func randomSecret() string {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatalf("Failed to generate a JWT secret: %v", err)
 	}
+	return hex.EncodeToString(key)
 }
 
 // Database connection
-func connectDB() (*sqlx.DB, error) {
-	db, err := sqlx.Connect("sqlite3", "expense_tracker.db")
+func connectDB(cfg config.DatabaseConfig) (*sqlx.DB, error) {
+	db, err := database.Connect(cfg)
 	if err != nil {
 		return nil, err
 	}
-	
-	// Create tables if they don't exist
-	schema := `
-	CREATE TABLE IF NOT EXISTS users (
-		id TEXT PRIMARY KEY,
-		email TEXT UNIQUE NOT NULL,
-		password TEXT NOT NULL,
-		name TEXT NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-	
-	CREATE TABLE IF NOT EXISTS expenses (
-		id TEXT PRIMARY KEY,
-		user_id TEXT NOT NULL,
-		amount REAL NOT NULL,
-		category TEXT NOT NULL CHECK(category IN ('groceries', 'leisure', 'electronics', 'utilities', 'clothing', 'health', 'others')),
-		description TEXT,
-		date DATE NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
-	);
-	
-	CREATE INDEX IF NOT EXISTS idx_expenses_user_id ON expenses(user_id);
-	CREATE INDEX IF NOT EXISTS idx_expenses_date ON expenses(date);
-	`
-	
-	_, err = db.Exec(schema)
-	if err != nil {
-		return nil, err
+
+	if cfg.AutoMigrate {
+		if err := migrateDB(db); err != nil {
+			db.Close()
+			log.Fatalf("Database migration failed: %v", err)
+		}
 	}
-	
-	log.Println("Database initialized successfully")
+
+	slog.Info("database initialized")
 	return db, nil
 }
 
-// Handlers
-func registerHandler(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-		Name     string `json:"name"`
+func migrateDB(db *sqlx.DB) error {
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		return err
 	}
-	
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
 	}
-	
-	response := map[string]interface{}{
-		"message": "User registered successfully",
-		"user": map[string]string{
-			"email": req.Email,
-			"name":  req.Name,
-		},
-		"token": "demo-jwt-token",
+
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
 	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	slog.Info("database schema migrated", "version", version, "applied", applied)
+	return nil
 }
 
-func loginHandler(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-	
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-	
-	response := map[string]interface{}{
-		"message": "Login successful",
-		"token":   "demo-jwt-token",
-		"user": map[string]string{
-			"email": req.Email,
-			"id":    "user-123",
-		},
-	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+// staleAfter is how long a worker running every interval may go without a
+// run before it counts as stuck: two missed runs and a minute for a slow
+// one.
+func staleAfter(interval time.Duration) time.Duration {
+	return 2*interval + time.Minute
 }
 
-func createExpenseHandler(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Amount      float64 `json:"amount"`
-		Category    string  `json:"category"`
-		Description string  `json:"description"`
-		Date        string  `json:"date"`
-	}
-	
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-	
-	response := map[string]interface{}{
-		"message": "Expense created successfully",
-		"expense": map[string]interface{}{
-			"id":          "exp-123",
-			"amount":      req.Amount,
-			"category":    req.Category,
-			"description": req.Description,
-			"date":        req.Date,
-			"created_at":  time.Now().Format(time.RFC3339),
-		},
-	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+// workers runs the background jobs of the API until shutdown. Each job is
+// a readiness component that fails when the job gets stuck.
+type workers struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	checks *health.Checker
 }
 
-func getExpensesHandler(w http.ResponseWriter, r *http.Request) {
-	// Get query parameters for filtering
-	period := r.URL.Query().Get("period")
-	category := r.URL.Query().Get("category")
-	
-	// Mock expenses data
-	expenses := []map[string]interface{}{
-		{
-			"id":          "exp-1",
-			"amount":      25.50,
-			"category":    "groceries",
-			"description": "Weekly groceries",
-			"date":        time.Now().AddDate(0, 0, -1).Format("2006-01-02"),
-		},
-		{
-			"id":          "exp-2",
-			"amount":      50.00,
-			"category":    "leisure",
-			"description": "Movie tickets",
-			"date":        time.Now().AddDate(0, 0, -3).Format("2006-01-02"),
-		},
-		{
-			"id":          "exp-3",
-			"amount":      120.00,
-			"category":    "electronics",
-			"description": "Phone charger",
-			"date":        time.Now().AddDate(0, 0, -10).Format("2006-01-02"),
-		},
-	}
-	
-	// Apply period filter
-	if period != "" {
-		var filtered []map[string]interface{}
-		now := time.Now()
-		
-		for _, expense := range expenses {
-			expenseDate, err := time.Parse("2006-01-02", expense["date"].(string))
-			if err != nil {
-				continue
-			}
-			
-			switch period {
-			case "week":
-				if expenseDate.After(now.AddDate(0, 0, -7)) {
-					filtered = append(filtered, expense)
-				}
-			case "month":
-				if expenseDate.After(now.AddDate(0, -1, 0)) {
-					filtered = append(filtered, expense)
-				}
-			case "3months":
-				if expenseDate.After(now.AddDate(0, -3, 0)) {
-					filtered = append(filtered, expense)
-				}
-			default:
-				filtered = append(filtered, expense)
-			}
-		}
-		expenses = filtered
-	}
-	
-	// Filter by category if provided
-	if category != "" {
-		filtered := []map[string]interface{}{}
-		for _, expense := range expenses {
-			if expense["category"] == category {
-				filtered = append(filtered, expense)
+func newWorkers(checks *health.Checker) *workers {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &workers{ctx: ctx, cancel: cancel, checks: checks}
+}
+
+// run starts fn, which must return once ctx ends.
+func (w *workers) run(fn func(ctx context.Context)) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		fn(w.ctx)
+	}()
+}
+
+// every runs job each interval, reporting it as worker.<name>.
+func (w *workers) every(name string, interval time.Duration, job func(ctx context.Context)) {
+	heartbeat := health.NewHeartbeat()
+	w.checks.Add("worker."+name, health.Recent(heartbeat.Last, staleAfter(interval)))
+	w.run(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
 			}
+			job(ctx)
+			heartbeat.Beat()
 		}
-		expenses = filtered
-	}
-	
-	// Calculate total
-	var total float64
-	for _, expense := range expenses {
-		total += expense["amount"].(float64)
-	}
-	
-	response := map[string]interface{}{
-		"expenses": expenses,
-		"count":    len(expenses),
-		"total":    total,
-		"filters": map[string]string{
-			"period":   period,
-			"category": category,
-		},
-	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	})
 }
 
-func updateExpenseHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	expenseID := vars["id"]
-	
-	var req struct {
-		Amount      *float64 `json:"amount"`
-		Category    *string  `json:"category"`
-		Description *string  `json:"description"`
-		Date        *string  `json:"date"`
-	}
-	
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
+// stop cancels the jobs and waits for them to return, or for ctx to end.
+func (w *workers) stop(ctx context.Context) error {
+	w.cancel()
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	
-	response := map[string]interface{}{
-		"message":    "Expense updated successfully",
-		"expense_id": expenseID,
-		"updates":    req,
+}
+
+// apiComponents holds what main needs to shut the API down.
+type apiComponents struct {
+	bus        *events.Bus
+	grpcServer *grpc.Server
+	feed       *services.FeedService
+	exports    *services.ExportService
+}
+
+// Repository implementations used by the API
+type repositorySet struct {
+	tx                 domain.TxManager
+	users              domain.UserRepository
+	expenses           domain.ExpenseRepository
+	expenseRevisions   domain.ExpenseRevisionRepository
+	loginAttempts      domain.LoginAttemptRepository
+	emailVerifications domain.EmailVerificationRepository
+	dataExports        domain.DataExportRepository
+	webhookEndpoints   domain.WebhookEndpointRepository
+	webhookDeliveries  domain.WebhookDeliveryRepository
+	outbox             domain.OutboxRepository
+	leases             domain.LeaseRepository
+}
+
+func sqlRepositories(db *sqlx.DB) repositorySet {
+	return repositorySet{
+		tx:                 database.NewTxManager(db),
+		users:              repositories.NewUserRepository(db),
+		expenses:           repositories.NewExpenseRepository(db),
+		expenseRevisions:   repositories.NewExpenseRevisionRepository(db),
+		loginAttempts:      repositories.NewLoginAttemptRepository(db),
+		emailVerifications: repositories.NewEmailVerificationRepository(db),
+		dataExports:        repositories.NewDataExportRepository(db),
+		webhookEndpoints:   repositories.NewWebhookEndpointRepository(db),
+		webhookDeliveries:  repositories.NewWebhookDeliveryRepository(db),
+		outbox:             repositories.NewOutboxRepository(db),
+		leases:             repositories.NewLeaseRepository(db),
 	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
 }
 
-func deleteExpenseHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	expenseID := vars["id"]
-	
-	response := map[string]interface{}{
-		"message":    "Expense deleted successfully",
-		"expense_id": expenseID,
+// Demo mode keeps everything in memory; data is lost on restart
+func memoryRepositories() repositorySet {
+	store := memory.NewStore()
+	return repositorySet{
+		tx:                 memory.NewTxManager(store),
+		users:              memory.NewUserRepository(store),
+		expenses:           memory.NewExpenseRepository(store),
+		expenseRevisions:   memory.NewExpenseRevisionRepository(store),
+		loginAttempts:      memory.NewLoginAttemptRepository(store),
+		emailVerifications: memory.NewEmailVerificationRepository(store),
+		dataExports:        memory.NewDataExportRepository(store),
+		webhookEndpoints:   memory.NewWebhookEndpointRepository(store),
+		webhookDeliveries:  memory.NewWebhookDeliveryRepository(store),
+		outbox:             memory.NewOutboxRepository(store),
+		leases:             memory.NewLeaseRepository(store),
 	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
 }
 
-// Auth middleware
-func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
-			return
+// API routes; the background jobs of the services are started on jobs
+func registerAPIRoutes(router *mux.Router, repos repositorySet, settings *config.Config, collector *metrics.Metrics, jobs *workers) *apiComponents {
+	jwtManager := jwt.NewJWTManager(settings.JWT.SecretKey, settings.JWT.TokenDuration)
+	validator := validation.NewValidator()
+
+	// Services publish domain events; other components subscribe to them.
+	// Events for asynchronous subscribers go through the outbox.
+	bus := events.NewBus(repos.outbox)
+	tx := bus.Transactional(repos.tx)
+	ob := settings.Outbox
+	relay := events.NewRelay(bus, repos.outbox, repos.leases, events.RelayPolicy{
+		BatchSize:    ob.BatchSize,
+		PollInterval: ob.PollInterval,
+		MaxAttempts:  ob.MaxAttempts,
+		BackoffBase:  ob.BackoffBase,
+		BackoffMax:   ob.BackoffMax,
+	})
+
+	rl := settings.RateLimit
+	authService := services.NewAuthService(repos.users, repos.loginAttempts, jwtManager, services.LoginPolicy{
+		MaxFailedAttempts: rl.MaxFailedAttempts,
+		FailureWindow:     rl.FailureWindow,
+		LockoutDuration:   rl.LockoutDuration,
+		DelayBase:         rl.DelayBase,
+		DelayMax:          rl.DelayMax,
+	}, bus)
+	wh := settings.Webhook
+	webhookService := services.NewWebhookService(repos.tx, repos.webhookEndpoints, repos.webhookDeliveries,
+		webhook.NewHTTPSender(wh.Timeout, wh.AllowPrivateURLs), services.WebhookPolicy{
+			MaxAttempts:      wh.MaxAttempts,
+			BackoffBase:      wh.BackoffBase,
+			BackoffMax:       wh.BackoffMax,
+			DisableAfter:     wh.DisableAfter,
+			BatchSize:        50,
+			ClaimFor:         50*wh.Timeout + time.Minute, // the batch is attempted one at a time
+			AllowPrivateURLs: wh.AllowPrivateURLs,
+		})
+	webhookService.Subscribe(bus)
+	feedService := services.NewFeedService(settings.Feed.HistorySize, settings.Feed.BufferSize, settings.Feed.MaxConnections, settings.Feed.ResumeWindow)
+	feedService.Subscribe(bus)
+	if collector != nil {
+		collector.Subscribe(bus)
+	}
+	expenseService := services.NewExpenseService(tx, repos.expenses, repos.expenseRevisions, bus)
+	accountService := services.NewAccountService(repos.tx, repos.users, repos.emailVerifications, mailer.NewLogMailer(),
+		settings.Account.EmailVerificationTTL)
+
+	exportStorage, err := storage.NewFileStorage(settings.Export.Dir)
+	if err != nil {
+		log.Fatalf("Failed to prepare export directory: %v", err)
+	}
+	exportService := services.NewExportService(repos.users, repos.expenses, repos.expenseRevisions, repos.webhookEndpoints, repos.webhookDeliveries, repos.outbox,
+		repos.loginAttempts, repos.emailVerifications, repos.dataExports, exportStorage, settings.JWT.SecretKey,
+		settings.Export.LinkTTL, settings.Export.AsyncThreshold)
+
+	// Expired export archives are removed periodically
+	jobs.every("export_purge", settings.Export.PurgeInterval, func(ctx context.Context) {
+		if err := exportService.PurgeExpired(ctx); err != nil {
+			slog.Error("failed to purge expired exports", "error", err)
 		}
-		
-		// Simple auth check (in production, validate JWT)
-		if authHeader != "Bearer demo-jwt-token" {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+	})
+
+	// Trashed expenses are permanently removed after the retention period
+	jobs.every("trash_purge", settings.Trash.PurgeInterval, func(ctx context.Context) {
+		purged, err := expenseService.PurgeTrash(ctx, settings.Trash.Retention)
+		if err != nil {
+			slog.Error("failed to purge trashed expenses", "error", err)
 			return
 		}
-		
-		next(w, r)
+		if purged > 0 {
+			slog.Info("purged trashed expenses", "count", purged)
+		}
+	})
+
+	jobs.checks.Add("worker.outbox_relay", health.Recent(relay.LastRun, staleAfter(ob.PollInterval)))
+	jobs.run(relay.Run)
+
+	// Delivered outbox events are kept for a while for troubleshooting
+	jobs.every("outbox_purge", ob.PurgeInterval, func(ctx context.Context) {
+		if _, err := relay.PurgeProcessed(ctx, time.Now().Add(-ob.Retention)); err != nil {
+			slog.Error("failed to purge processed outbox events", "error", err)
+		}
+	})
+
+	// Pending webhook deliveries are sent, and failed ones retried with backoff
+	jobs.every("webhook_delivery", wh.PollInterval, func(ctx context.Context) {
+		if _, err := webhookService.ProcessDue(ctx); err != nil {
+			slog.Error("failed to process webhook deliveries", "error", err)
+		}
+	})
+
+	authHandler := handlers.NewAuthHandler(authService, validator, rl.TrustProxyHeaders)
+	expenseHandler := handlers.NewExpenseHandler(expenseService, validator, settings.Expense.RequireIfMatch)
+	accountHandler := handlers.NewAccountHandler(accountService, validator)
+	exportHandler := handlers.NewExportHandler(exportService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService, validator)
+	feedHandler := handlers.NewFeedHandler(feedService, authService, settings.Feed.Heartbeat)
+
+	// Login is limited per client IP and per account before reaching the service
+	rateLimitStore := ratelimit.NewMemoryStore()
+	var login http.Handler = http.HandlerFunc(authHandler.Login)
+	login = middleware.RateLimitMiddleware(rateLimitStore, ratelimit.PerMinute(rl.LoginAccountPerMinute, rl.LoginAccountBurst), middleware.LoginAccountKey)(login)
+	login = middleware.RateLimitMiddleware(rateLimitStore, ratelimit.PerMinute(rl.LoginIPPerMinute, rl.LoginIPBurst), middleware.ClientIPKey(rl.TrustProxyHeaders))(login)
+	loginLimits := ratelimit.LoginLimits{
+		Store:      rateLimitStore,
+		PerIP:      ratelimit.PerMinute(rl.LoginIPPerMinute, rl.LoginIPBurst),
+		PerAccount: ratelimit.PerMinute(rl.LoginAccountPerMinute, rl.LoginAccountBurst),
 	}
+
+	graphqlHandler := handlers.NewGraphQLHandler(expenseService, accountService, authService, validator, loginLimits, rl.TrustProxyHeaders, handlers.GraphQLLimits{
+		MaxDepth:      settings.GraphQL.MaxDepth,
+		MaxComplexity: settings.GraphQL.MaxComplexity,
+	}, settings.Expense.RequireIfMatch)
+
+	// Retried creates with the same Idempotency-Key replay the first response
+	idempotent := middleware.IdempotencyMiddleware(idempotency.NewMemoryStore(),
+		settings.Expense.IdempotencyTTL)
+
+	router.HandleFunc("/api/auth/register", authHandler.Register).Methods("POST")
+	router.Handle("/api/auth/login", login).Methods("POST")
+	router.HandleFunc("/api/auth/verify-email", accountHandler.VerifyEmail).Methods("POST")
+	router.HandleFunc("/api/exports/{id}/download", exportHandler.Download).Methods("GET")
+
+	// EventSource and browser WebSockets cannot send an Authorization header
+	streamAuth := middleware.QueryTokenAuthMiddleware(authService)
+	router.Handle("/api/expenses/stream", streamAuth(http.HandlerFunc(feedHandler.Stream))).Methods("GET")
+	router.Handle("/api/expenses/stream/ws", streamAuth(http.HandlerFunc(feedHandler.WebSocket))).Methods("GET")
+
+	// GraphQL serves register and login too, so a token is optional there
+	graphqlAuth := middleware.OptionalAuthMiddleware(authService)
+	router.Handle("/api/graphql", graphqlAuth(http.HandlerFunc(graphqlHandler.Query))).Methods("GET", "POST")
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.Use(middleware.AuthMiddleware(authService))
+	api.Handle("/expenses", idempotent(http.HandlerFunc(expenseHandler.CreateExpense))).Methods("POST")
+	api.HandleFunc("/expenses", expenseHandler.GetExpenses).Methods("GET")
+	api.Handle("/expenses/batch", idempotent(http.HandlerFunc(expenseHandler.Batch))).Methods("POST")
+	api.HandleFunc("/expenses/trash", expenseHandler.GetTrash).Methods("GET")
+	api.HandleFunc("/expenses/{id}", expenseHandler.GetExpense).Methods("GET")
+	api.HandleFunc("/expenses/{id}", expenseHandler.UpdateExpense).Methods("PUT", "PATCH")
+	api.HandleFunc("/expenses/{id}", expenseHandler.DeleteExpense).Methods("DELETE")
+	api.HandleFunc("/expenses/{id}/restore", expenseHandler.RestoreExpense).Methods("POST")
+	api.HandleFunc("/expenses/{id}/history", expenseHandler.GetHistory).Methods("GET")
+	api.HandleFunc("/expenses/{id}/history/{revision}/revert", expenseHandler.RevertExpense).Methods("POST")
+	api.HandleFunc("/expenses/{id}/permanent", expenseHandler.PermanentlyDeleteExpense).Methods("DELETE")
+
+	api.HandleFunc("/me", accountHandler.GetProfile).Methods("GET")
+	api.HandleFunc("/me", accountHandler.UpdateProfile).Methods("PATCH")
+	api.HandleFunc("/me", accountHandler.DeleteAccount).Methods("DELETE")
+	api.HandleFunc("/me/password", accountHandler.ChangePassword).Methods("POST")
+	api.HandleFunc("/me/exports", exportHandler.RequestExport).Methods("POST")
+	api.HandleFunc("/me/exports", exportHandler.ListExports).Methods("GET")
+	api.HandleFunc("/me/exports/{id}", exportHandler.GetExport).Methods("GET")
+
+	api.HandleFunc("/webhooks", webhookHandler.CreateWebhook).Methods("POST")
+	api.HandleFunc("/webhooks", webhookHandler.ListWebhooks).Methods("GET")
+	api.HandleFunc("/webhooks/{id}", webhookHandler.GetWebhook).Methods("GET")
+	api.HandleFunc("/webhooks/{id}", webhookHandler.UpdateWebhook).Methods("PATCH")
+	api.HandleFunc("/webhooks/{id}", webhookHandler.DeleteWebhook).Methods("DELETE")
+	api.HandleFunc("/webhooks/{id}/deliveries", webhookHandler.ListDeliveries).Methods("GET")
+	api.HandleFunc("/webhooks/{id}/deliveries/{deliveryId}/redeliver", webhookHandler.Redeliver).Methods("POST")
+
+	// gRPC clients get the same services; login shares the rate limits
+	grpcServer, grpcHealth := grpcserver.NewServer(expenseService, authService, validator, grpcserver.Options{
+		LoginLimits:    loginLimits,
+		TrustProxy:     rl.TrustProxyHeaders,
+		RequireVersion: settings.Expense.RequireIfMatch,
+	})
+	// gRPC load balancers see the drain through the health service
+	jobs.checks.OnDrain(grpcHealth.Shutdown)
+
+	return &apiComponents{bus: bus, grpcServer: grpcServer, feed: feedService, exports: exportService}
 }
 
 func main() {
-	cfg := loadConfig()
-	
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration `file`; environment variables override it")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted, then exit")
+	flag.Parse()
+
+	settings, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *printConfig {
+		if err := settings.WriteYAML(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := logger.Setup(os.Stderr, settings.Logging.Format, settings.Logging.Level); err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+	slog.Info("configuration loaded", "environment", settings.Environment, "file", *configFile)
+	for _, warning := range settings.Warnings() {
+		slog.Warn("configuration: " + warning)
+	}
+	// Outside production a missing secret only costs the tokens on restart
+	if settings.JWT.SecretKey == "" {
+		settings.JWT.SecretKey = randomSecret()
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), settings.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	// Connect to database
-	db, err := connectDB()
+	db, err := connectDB(settings.Database)
 	if err != nil {
-		log.Printf("Warning: Could not connect to database: %v", err)
-		log.Println("Running in demo mode without database...")
+		if settings.IsProduction() {
+			log.Fatalf("Could not connect to database: %v", err)
+		}
+		slog.Warn("could not connect to database; running in demo mode with in-memory storage, data is lost on restart", "error", err)
 	} else {
 		defer db.Close()
-		log.Println("Connected to database successfully")
+		slog.Info("connected to database")
 	}
-	
+
 	// Initialize router
 	router := mux.NewRouter()
-	
+
+	// Every request is measured, unmatched ones too; /metrics serves the
+	// results
+	var collector *metrics.Metrics
+	if settings.Metrics.Enabled {
+		collector = metrics.New()
+		if db != nil {
+			collector.RegisterDB(db)
+		}
+		router.Handle("/metrics", collector.Handler(settings.Metrics.Token)).Methods("GET")
+	}
+
+	// The log level can be changed without a restart by holders of the token
+	if token := settings.Logging.LevelToken; token != "" {
+		router.Handle("/log-level", logger.LevelHandler(token)).Methods("GET", "PUT")
+	}
+
 	// Public routes
 	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Expense Tracker API v1.0"))
 	})
-	
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		if db != nil {
-			if err := db.Ping(); err != nil {
-				http.Error(w, "Database connection error", http.StatusInternalServerError)
-				return
-			}
+
+	// Liveness runs no checks; readiness checks every component, and /health
+	// stays as an alias of it for existing monitors
+	checks := health.New(health.ReadBuildInfo(), settings.Health.CheckTimeout)
+	if db != nil {
+		checks.Add("database", health.Database(db))
+		migrator, err := database.NewMigrator(db, migrations.FS)
+		if err != nil {
+			log.Fatalf("Failed to load migrations: %v", err)
 		}
-		w.Write([]byte("OK"))
-	})
-	
-	// Auth routes
-	router.HandleFunc("/api/auth/register", registerHandler).Methods("POST")
-	router.HandleFunc("/api/auth/login", loginHandler).Methods("POST")
-	
+		checks.Add("migrations", health.Migrations(migrator))
+		if settings.Database.Type != "postgres" {
+			checks.Add("disk", health.DiskSpace(filepath.Dir(settings.Database.DBName), uint64(settings.Health.MinFreeDiskMB)<<20))
+		}
+	}
+	router.Handle("/health/live", checks.LiveHandler()).Methods("GET")
+	ready := checks.ReadyHandler(settings.Health.DetailsToken)
+	router.Handle("/health/ready", ready).Methods("GET")
+	router.Handle("/health", ready).Methods("GET")
+
 	// Test endpoint
 	router.HandleFunc("/api/test", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -345,27 +490,146 @@ func main() {
 			"status":  "success",
 		})
 	})
-	
-	// Protected routes
-	router.HandleFunc("/api/expenses", authMiddleware(createExpenseHandler)).Methods("POST")
-	router.HandleFunc("/api/expenses", authMiddleware(getExpensesHandler)).Methods("GET")
-	router.HandleFunc("/api/expenses/{id}", authMiddleware(updateExpenseHandler)).Methods("PUT")
-	router.HandleFunc("/api/expenses/{id}", authMiddleware(deleteExpenseHandler)).Methods("DELETE")
-	
+
+	// Auth and protected routes
+	repos := memoryRepositories()
+	if db != nil {
+		repos = sqlRepositories(db)
+	}
+	jobs := newWorkers(checks)
+	app := registerAPIRoutes(router, repos, settings, collector, jobs)
+
+	// Postgres wakes the relay when another process, such as a CLI, commits
+	// outbox events
+	if db != nil {
+		listener, err := database.Listen(settings.Database, "outbox_events", app.bus.Notify)
+		if err != nil {
+			slog.Warn("could not listen for outbox notifications, polling instead", "error", err)
+		}
+		defer listener.Close()
+	}
+
+	if port := settings.Server.GRPCPort; port != "" {
+		listener, err := net.Listen("tcp", ":"+port)
+		if err != nil {
+			log.Fatalf("Failed to listen for gRPC on port %s: %v", port, err)
+		}
+		go func() {
+			if err := app.grpcServer.Serve(listener); err != nil {
+				slog.Error("gRPC server stopped", "error", err)
+			}
+		}()
+		slog.Info("gRPC server starting", "port", port)
+	}
+
+	// The request middleware wraps the router rather than being added with
+	// Router.Use, so requests no route matches go through it too
+	var handler http.Handler = router
+	if collector != nil {
+		handler = middleware.MetricsMiddleware(collector)(handler)
+	}
+	handler = middleware.AccessLogMiddleware()(handler)
+	handler = middleware.RequestIDMiddleware()(handler)
+	handler = middleware.TracingMiddleware()(handler)
+	handler = middleware.RouteMiddleware(router)(handler)
+
 	// Start server
-	log.Printf("Server starting on port %s", cfg.ServerPort)
-	log.Println("Available endpoints:")
-	log.Println("  GET  /                     - Welcome message")
-	log.Println("  GET  /health               - Health check")
-	log.Println("  GET  /api/test             - Test endpoint")
-	log.Println("  POST /api/auth/register    - Register new user")
-	log.Println("  POST /api/auth/login       - Login user")
-	log.Println("  POST /api/expenses         - Create expense (protected)")
-	log.Println("  GET  /api/expenses         - Get expenses (protected)")
-	log.Println("  PUT  /api/expenses/{id}    - Update expense (protected)")
-	log.Println("  DELETE /api/expenses/{id}  - Delete expense (protected)")
-	
-	if err := http.ListenAndServe(":"+cfg.ServerPort, router); err != nil {
-		log.Fatal("Server failed to start:", err)
+	srv := settings.Server
+	server := &http.Server{
+		Addr:              ":" + srv.Port,
+		Handler:           handler,
+		ReadTimeout:       srv.ReadTimeout,
+		ReadHeaderTimeout: srv.ReadHeaderTimeout,
+		WriteTimeout:      srv.WriteTimeout,
+		IdleTimeout:       srv.IdleTimeout,
+		MaxHeaderBytes:    srv.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+	// Live feeds would otherwise keep their connections open until the
+	// shutdown deadline
+	server.RegisterOnShutdown(app.feed.Close)
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		log.Fatalf("Server failed to start: %v", err)
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	slog.Info("server starting", "port", srv.Port)
+	logRoutes(router)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-ctx.Done():
+	case err := <-served:
+		slog.Error("server stopped", "error", err)
+	}
+	// A second signal kills the process at once
+	stop()
+
+	shutdown(server, app, jobs, checks, srv)
+}
+
+// logRoutes lists the routes at debug level, walked from the router so the
+// list cannot fall behind it.
+func logRoutes(router *mux.Router) {
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, pathErr := route.GetPathTemplate()
+		methods, methodsErr := route.GetMethods()
+		// Subrouters have a path prefix but no methods
+		if pathErr == nil && methodsErr == nil {
+			slog.Debug("route", "methods", strings.Join(methods, ","), "path", path)
+		}
+		return nil
+	})
+}
+
+// shutdown drains the servers, then stops the background jobs, all within
+// the shutdown timeout. The database and the tracer are closed by main's
+// deferred calls afterwards.
+func shutdown(server *http.Server, app *apiComponents, jobs *workers, checks *health.Checker, srv config.ServerConfig) {
+	slog.Info("shutting down", "timeout", srv.ShutdownTimeout)
+
+	// Readiness fails first, so load balancers stop sending new requests
+	checks.Drain()
+	time.Sleep(srv.DrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), srv.ShutdownTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Warn("closing HTTP connections still open at the deadline", "error", err)
+			server.Close()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		stopped := make(chan struct{})
+		go func() {
+			app.grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			slog.Warn("closing gRPC connections still open at the deadline")
+			app.grpcServer.Stop()
+		}
+	}()
+	wg.Wait()
+
+	if err := jobs.stop(ctx); err != nil {
+		slog.Warn("background jobs still running at the deadline", "error", err)
 	}
-}
\ No newline at end of file
+	if err := app.exports.Wait(ctx); err != nil {
+		slog.Warn("exports still generating at the deadline", "error", err)
+	}
+	slog.Info("server stopped")
+}
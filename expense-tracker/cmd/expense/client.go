@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/application/dto"
+)
+
+var errNotLoggedIn = errors.New("not logged in, run: expense login")
+
+// apiError is a response the API rejected. The API answers errors with a
+// plain-text message.
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.Status)
+	}
+	return fmt.Sprintf("%s (%d)", e.Message, e.Status)
+}
+
+type client struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+func newClient(server, token string) *client {
+	return &client{
+		server: strings.TrimRight(server, "/"),
+		token:  token,
+		http:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends a JSON request and decodes the JSON response into out. Statuses
+// in accept are decoded like successes; any other non-2xx status is an
+// apiError.
+func (c *client) do(method, path string, header http.Header, body, out interface{}, accept ...int) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.server+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	ok := resp.StatusCode >= 200 && resp.StatusCode < 300
+	for _, status := range accept {
+		ok = ok || resp.StatusCode == status
+	}
+	if !ok {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return resp.StatusCode, &apiError{Status: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("decoding response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// ifMatch returns the If-Match header for an expected version, or no
+// header when version is zero.
+func ifMatch(version int) http.Header {
+	if version == 0 {
+		return nil
+	}
+	return http.Header{"If-Match": {`"` + strconv.Itoa(version) + `"`}}
+}
+
+func (c *client) login(email, password string) (*dto.AuthResponse, error) {
+	var response dto.AuthResponse
+	req := dto.LoginRequest{Email: email, Password: password}
+	if _, err := c.do(http.MethodPost, "/api/auth/login", nil, req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *client) listExpenses(filter dto.FilterParams) ([]*dto.ExpenseResponse, error) {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"period":     filter.Period,
+		"start_date": filter.StartDate,
+		"end_date":   filter.EndDate,
+		"category":   filter.Category,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	path := "/api/expenses"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var expenses []*dto.ExpenseResponse
+	if _, err := c.do(http.MethodGet, path, nil, nil, &expenses); err != nil {
+		return nil, err
+	}
+	return expenses, nil
+}
+
+func (c *client) createExpense(req dto.CreateExpenseRequest) (*dto.ExpenseResponse, error) {
+	var expense dto.ExpenseResponse
+	if _, err := c.do(http.MethodPost, "/api/expenses", nil, req, &expense); err != nil {
+		return nil, err
+	}
+	return &expense, nil
+}
+
+func (c *client) updateExpense(id string, req dto.UpdateExpenseRequest, version int) (*dto.ExpenseResponse, error) {
+	var expense dto.ExpenseResponse
+	if _, err := c.do(http.MethodPatch, "/api/expenses/"+url.PathEscape(id), ifMatch(version), req, &expense); err != nil {
+		return nil, err
+	}
+	return &expense, nil
+}
+
+func (c *client) deleteExpense(id string, version int) error {
+	_, err := c.do(http.MethodDelete, "/api/expenses/"+url.PathEscape(id), ifMatch(version), nil, nil)
+	return err
+}
+
+// batch sends the operations in one request. A failed atomic batch is
+// answered with 422 and still carries the per-operation results.
+func (c *client) batch(req dto.BatchRequest) (*dto.BatchResponse, error) {
+	var response dto.BatchResponse
+	if _, err := c.do(http.MethodPost, "/api/expenses/batch", nil, req, &response, http.StatusUnprocessableEntity); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// config is what the client remembers between runs. It holds a bearer
+// token, so the file is only readable by its owner.
+type config struct {
+	Server string `json:"server"`
+	Email  string `json:"email,omitempty"`
+	Token  string `json:"token,omitempty"`
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".expense.json"
+	}
+	return filepath.Join(dir, "expense-tracker", "config.json")
+}
+
+// loadConfig reads the config file. A missing file is an empty config.
+func loadConfig(path string) (*config, error) {
+	cfg := &config{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *config) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(path, 0o600)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/application/dto"
+
+	"golang.org/x/term"
+)
+
+const dateLayout = "2006-01-02"
+
+// prompt asks for one line of input.
+func prompt(in *bufio.Reader, label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	line, err := in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// readPassword asks for the password without echoing it. When stdin is not
+// a terminal the password is the next line, so scripts can pipe it in.
+func readPassword(in *bufio.Reader) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return prompt(in, "")
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
+
+// parseBatch reads a batch from stdin. It accepts the body of
+// POST /api/expenses/batch, just its operations array, or CSV rows of
+// amount,category,date,description that each create an expense.
+func parseBatch(data []byte) (dto.BatchRequest, error) {
+	var req dto.BatchRequest
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return req, errors.New("no operations on stdin")
+	}
+
+	switch data[0] {
+	case '{':
+		if err := json.Unmarshal(data, &req); err != nil {
+			return req, fmt.Errorf("invalid batch request: %w", err)
+		}
+	case '[':
+		if err := json.Unmarshal(data, &req.Operations); err != nil {
+			return req, fmt.Errorf("invalid operations: %w", err)
+		}
+	default:
+		ops, err := parseCSVBatch(bytes.NewReader(data))
+		if err != nil {
+			return req, err
+		}
+		req.Operations = ops
+	}
+	return req, nil
+}
+
+func parseCSVBatch(r io.Reader) ([]dto.BatchOperationRequest, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var ops []dto.BatchOperationRequest
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return ops, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(ops) == 0 && strings.EqualFold(record[0], "amount") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 4 {
+			return nil, fmt.Errorf("line %d: want amount,category,date,description", line)
+		}
+
+		amount, err := strconv.ParseFloat(record[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount %q", line, record[0])
+		}
+		expense := dto.CreateExpenseRequest{
+			Amount:   amount,
+			Category: record[1],
+			Date:     time.Now().Format(dateLayout),
+		}
+		if len(record) > 2 && record[2] != "" {
+			expense.Date = record[2]
+		}
+		if len(record) > 3 {
+			expense.Description = record[3]
+		}
+
+		data, err := json.Marshal(expense)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, dto.BatchOperationRequest{Op: "create", Data: data})
+	}
+}
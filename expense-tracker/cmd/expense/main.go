@@ -0,0 +1,371 @@
+// Command expense is a terminal client for the expense tracker API.
+//
+//	expense login -email you@example.com
+//	expense add -amount 12.50 -category groceries -description Milk
+//	expense list -period month -category groceries
+//	expense edit <id> -amount 14 -version 1
+//	expense delete <id>
+//	expense batch -mode best_effort < expenses.csv
+//	expense logout
+//
+// login stores the server and token in a config file, by default under the
+// user config directory. -server or EXPENSE_SERVER selects another API, and
+// -json prints responses as JSON instead of tables.
+//
+// batch reads the body of POST /api/expenses/batch, a JSON array of
+// operations, or CSV rows of amount,category,date,description to create.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"expense-tracker/internal/application/dto"
+)
+
+const defaultServer = "http://localhost:8081"
+
+type app struct {
+	cfg        *config
+	configPath string
+	server     string
+	json       bool
+	stdin      *bufio.Reader
+	stdout     io.Writer
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(a *app, args []string) error
+}
+
+var commands = []command{
+	{"login", "log in and store the token", (*app).login},
+	{"logout", "forget the stored token", (*app).logout},
+	{"add", "create an expense", (*app).add},
+	{"list", "list expenses, with the filters of GET /api/expenses", (*app).list},
+	{"edit", "change fields of an expense", (*app).edit},
+	{"delete", "move expenses to the trash", (*app).delete},
+	{"batch", "apply create, update and delete operations from stdin", (*app).batch},
+}
+
+func main() {
+	flag.Usage = usage
+	configPath := flag.String("config", defaultConfigPath(), "config file holding the server and token")
+	server := flag.String("server", os.Getenv("EXPENSE_SERVER"), "API base URL (default from the config file, then "+defaultServer+")")
+	asJSON := flag.Bool("json", false, "print JSON instead of tables")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fatal(fmt.Errorf("reading %s: %w", *configPath, err))
+	}
+
+	a := &app{
+		cfg:        cfg,
+		configPath: *configPath,
+		server:     *server,
+		json:       *asJSON,
+		stdin:      bufio.NewReader(os.Stdin),
+		stdout:     os.Stdout,
+	}
+	if a.server == "" {
+		a.server = cfg.Server
+	}
+	if a.server == "" {
+		a.server = defaultServer
+	}
+
+	name := flag.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(a, flag.Args()[1:]); err != nil {
+				fatal(err)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "expense: unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: expense [flags] <command> [command flags]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+	fmt.Fprintln(out, "\nRun expense <command> -h for the flags of a command.")
+}
+
+func fatal(err error) {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusUnauthorized {
+		err = fmt.Errorf("%w; run: expense login", err)
+	}
+	fmt.Fprintln(os.Stderr, "expense:", err)
+	os.Exit(1)
+}
+
+func newFlagSet(name, arguments string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: expense %s [flags] %s\n", name, arguments)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses flags that come before or after the positional
+// arguments, so both "edit -amount 5 <id>" and "edit <id> -amount 5" work.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func (a *app) client() (*client, error) {
+	if a.cfg.Token == "" {
+		return nil, errNotLoggedIn
+	}
+	return newClient(a.server, a.cfg.Token), nil
+}
+
+func (a *app) print(v interface{}, table func(io.Writer) error) error {
+	if a.json {
+		return writeJSON(a.stdout, v)
+	}
+	return table(a.stdout)
+}
+
+func (a *app) login(args []string) error {
+	fs := newFlagSet("login", "")
+	email := fs.String("email", a.cfg.Email, "account email")
+	parseArgs(fs, args)
+
+	var err error
+	if *email == "" {
+		if *email, err = prompt(a.stdin, "Email: "); err != nil {
+			return err
+		}
+	}
+	password, err := readPassword(a.stdin)
+	if err != nil {
+		return err
+	}
+
+	auth, err := newClient(a.server, "").login(*email, password)
+	if err != nil {
+		return err
+	}
+
+	a.cfg.Server = a.server
+	a.cfg.Email = auth.User.Email
+	a.cfg.Token = auth.Token
+	if err := a.cfg.save(a.configPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Logged in to %s as %s\n", a.server, auth.User.Email)
+	return nil
+}
+
+func (a *app) logout(args []string) error {
+	parseArgs(newFlagSet("logout", ""), args)
+	if a.cfg.Token == "" {
+		return nil
+	}
+	a.cfg.Token = ""
+	return a.cfg.save(a.configPath)
+}
+
+func (a *app) add(args []string) error {
+	fs := newFlagSet("add", "")
+	var req dto.CreateExpenseRequest
+	fs.Float64Var(&req.Amount, "amount", 0, "amount, greater than zero")
+	fs.StringVar(&req.Category, "category", "", "category, e.g. groceries")
+	fs.StringVar(&req.Date, "date", time.Now().Format(dateLayout), "date in YYYY-MM-DD format")
+	fs.StringVar(&req.Description, "description", "", "description")
+	if len(parseArgs(fs, args)) > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if req.Amount == 0 || req.Category == "" {
+		return errors.New("-amount and -category are required")
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	expense, err := c.createExpense(req)
+	if err != nil {
+		return err
+	}
+	return a.print(expense, func(w io.Writer) error {
+		return writeExpenses(w, []*dto.ExpenseResponse{expense})
+	})
+}
+
+func (a *app) list(args []string) error {
+	fs := newFlagSet("list", "")
+	var filter dto.FilterParams
+	fs.StringVar(&filter.Period, "period", "", "week, month, 3months or custom")
+	fs.StringVar(&filter.StartDate, "start", "", "first date, YYYY-MM-DD")
+	fs.StringVar(&filter.EndDate, "end", "", "last date, YYYY-MM-DD")
+	fs.StringVar(&filter.Category, "category", "", "only this category")
+	if len(parseArgs(fs, args)) > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if (filter.StartDate == "") != (filter.EndDate == "") {
+		return errors.New("-start and -end must be given together")
+	}
+	if filter.Period == "" && filter.StartDate != "" {
+		filter.Period = "custom"
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	expenses, err := c.listExpenses(filter)
+	if err != nil {
+		return err
+	}
+	return a.print(expenses, func(w io.Writer) error {
+		return writeExpenses(w, expenses)
+	})
+}
+
+func (a *app) edit(args []string) error {
+	fs := newFlagSet("edit", "<id>")
+	amount := fs.Float64("amount", 0, "new amount")
+	category := fs.String("category", "", "new category")
+	date := fs.String("date", "", "new date, YYYY-MM-DD")
+	description := fs.String("description", "", "new description")
+	version := fs.Int("version", 0, "only change the expense while it is at this version")
+	ids := parseArgs(fs, args)
+	if len(ids) != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	// Only flags given on the command line are sent, so an empty
+	// description can still be set explicitly
+	var req dto.UpdateExpenseRequest
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "amount":
+			req.Amount = amount
+		case "category":
+			req.Category = category
+		case "date":
+			req.Date = date
+		case "description":
+			req.Description = description
+		}
+	})
+	if req.Amount == nil && req.Category == nil && req.Date == nil && req.Description == nil {
+		return errors.New("nothing to change, use -amount, -category, -date or -description")
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	expense, err := c.updateExpense(ids[0], req, *version)
+	if err != nil {
+		return err
+	}
+	return a.print(expense, func(w io.Writer) error {
+		return writeExpenses(w, []*dto.ExpenseResponse{expense})
+	})
+}
+
+func (a *app) delete(args []string) error {
+	fs := newFlagSet("delete", "<id>...")
+	version := fs.Int("version", 0, "only delete the expense while it is at this version")
+	ids := parseArgs(fs, args)
+	if len(ids) == 0 || (*version != 0 && len(ids) > 1) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := c.deleteExpense(id, *version); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		if !a.json {
+			fmt.Fprintf(a.stdout, "Moved %s to the trash\n", id)
+		}
+	}
+	return nil
+}
+
+func (a *app) batch(args []string) error {
+	fs := newFlagSet("batch", "< operations")
+	mode := fs.String("mode", "", "atomic or best_effort (default atomic, or the mode of a JSON request)")
+	if len(parseArgs(fs, args)) > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	data, err := io.ReadAll(a.stdin)
+	if err != nil {
+		return err
+	}
+	req, err := parseBatch(data)
+	if err != nil {
+		return err
+	}
+	if *mode != "" {
+		req.Mode = *mode
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	response, err := c.batch(req)
+	if err != nil {
+		return err
+	}
+	if err := a.print(response, func(w io.Writer) error { return writeBatch(w, response) }); err != nil {
+		return err
+	}
+
+	if response.Failed > 0 {
+		if response.Mode == "atomic" {
+			return fmt.Errorf("%d of %d operations failed, nothing was applied", response.Failed, len(response.Results))
+		}
+		return fmt.Errorf("%d of %d operations failed", response.Failed, len(response.Results))
+	}
+	return nil
+}
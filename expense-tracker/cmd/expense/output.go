@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"expense-tracker/internal/application/dto"
+)
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeExpenses(w io.Writer, expenses []*dto.ExpenseResponse) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDATE\tCATEGORY\tAMOUNT\tDESCRIPTION\tVERSION")
+
+	var total float64
+	for _, e := range expenses {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.2f\t%s\t%d\n",
+			e.ID, e.Date.Format("2006-01-02"), e.Category, e.Amount, oneLine(e.Description), e.Version)
+		total += e.Amount
+	}
+	if len(expenses) > 1 {
+		fmt.Fprintf(tw, "\t\tTOTAL\t%.2f\t\t\n", total)
+	}
+	return tw.Flush()
+}
+
+func writeBatch(w io.Writer, response *dto.BatchResponse) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tOP\tID\tSTATUS\tERROR")
+	for _, result := range response.Results {
+		id := result.ID
+		if id == "" && result.Expense != nil {
+			id = result.Expense.ID
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\n", result.Index, result.Op, id, result.Status, result.Error)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s: %d succeeded, %d failed\n", response.Mode, response.Succeeded, response.Failed)
+	return err
+}
+
+// oneLine keeps a multi-line description from breaking the table.
+func oneLine(s string) string {
+	if !strings.ContainsAny(s, "\r\n\t") {
+		return s
+	}
+	quoted := strconv.Quote(s)
+	return quoted[1 : len(quoted)-1]
+}
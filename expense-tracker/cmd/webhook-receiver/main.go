@@ -0,0 +1,65 @@
+// Command webhook-receiver is a local endpoint for trying out webhooks. It
+// verifies signatures and prints every event it receives.
+//
+//	go run ./cmd/webhook-receiver -addr :9000 -secret whsec_...
+//
+// Register http://localhost:9000/ as a webhook URL. With -fail N the first N
+// requests are answered with 500 to exercise retries.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"expense-tracker/internal/infrastructure/webhook"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	secret := flag.String("secret", "", "endpoint secret; signatures are not checked when empty")
+	fail := flag.Int64("fail", 0, "answer the first N requests with 500")
+	flag.Parse()
+
+	var received int64
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		n := atomic.AddInt64(&received, 1)
+		event := r.Header.Get("X-Webhook-Event")
+		delivery := r.Header.Get("X-Webhook-Id")
+
+		if *secret != "" {
+			if err := webhook.VerifySignature(*secret, r.Header.Get("X-Webhook-Signature"), body, 5*time.Minute); err != nil {
+				log.Printf("#%d %s %s: %v", n, event, delivery, err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		if n <= *fail {
+			log.Printf("#%d %s %s: failing on purpose", n, event, delivery)
+			http.Error(w, "failing on purpose", http.StatusInternalServerError)
+			return
+		}
+
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, body, "", "  "); err != nil {
+			pretty.Write(body)
+		}
+		log.Printf("#%d %s %s\n%s", n, event, delivery, pretty.String())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Printf("Webhook receiver listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
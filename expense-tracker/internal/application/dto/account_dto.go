@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"time"
+)
+
+type UpdateProfileRequest struct {
+	Name  *string `json:"name" validate:"omitempty,max=255"`
+	Email *string `json:"email" validate:"omitempty,email"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type UserResponse struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	PendingEmail string    `json:"pending_email,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
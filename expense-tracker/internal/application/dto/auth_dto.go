@@ -9,6 +9,7 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	ClientIP string `json:"-"`
 }
 
 type AuthResponse struct {
@@ -18,4 +19,4 @@ type AuthResponse struct {
 		Email string `json:"email"`
 		Name  string `json:"name"`
 	} `json:"user"`
-}
\ No newline at end of file
+}
@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -19,18 +20,73 @@ type UpdateExpenseRequest struct {
 }
 
 type ExpenseResponse struct {
-	ID          string    `json:"id"`
-	Amount      float64   `json:"amount"`
-	Category    string    `json:"category"`
-	Description string    `json:"description"`
-	Date        time.Time `json:"date"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string     `json:"id"`
+	Amount      float64    `json:"amount"`
+	Category    string     `json:"category"`
+	Description string     `json:"description"`
+	Date        time.Time  `json:"date"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	Version     int        `json:"version"`
 }
 
 type FilterParams struct {
-	Period     string `query:"period"` // week, month, 3months, custom
-	StartDate  string `query:"start_date"`
-	EndDate    string `query:"end_date"`
-	Category   string `query:"category"`
-}
\ No newline at end of file
+	Period    string `query:"period"` // week, month, 3months, custom
+	StartDate string `query:"start_date"`
+	EndDate   string `query:"end_date"`
+	Category  string `query:"category"`
+}
+
+type CategoryTotalResponse struct {
+	Category string  `json:"category"`
+	Total    float64 `json:"total"`
+}
+
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+type ExpenseRevisionResponse struct {
+	Revision   int           `json:"revision"`
+	Action     string        `json:"action"`
+	ActorID    string        `json:"actor_id"`
+	Changes    []FieldChange `json:"changes"`
+	RevertedTo *int          `json:"reverted_to,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// BatchRequest carries up to 100 operations. Mode is "atomic" (the default)
+// or "best_effort".
+type BatchRequest struct {
+	Mode       string                  `json:"mode"`
+	Operations []BatchOperationRequest `json:"operations"`
+}
+
+// BatchOperationRequest is a create, update or delete. Data holds a
+// CreateExpenseRequest or UpdateExpenseRequest; Version, when set, works
+// like If-Match.
+type BatchOperationRequest struct {
+	Op      string          `json:"op"`
+	ID      string          `json:"id"`
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type BatchResult struct {
+	Index   int              `json:"index"`
+	Op      string           `json:"op"`
+	ID      string           `json:"id,omitempty"`
+	Status  int              `json:"status"`
+	Expense *ExpenseResponse `json:"expense,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Mode      string        `json:"mode"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
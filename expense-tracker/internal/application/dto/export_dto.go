@@ -0,0 +1,15 @@
+package dto
+
+import (
+	"time"
+)
+
+type ExportResponse struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	DownloadURL string     `json:"download_url,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+import (
+	"time"
+)
+
+// FeedReset tells a resuming client that the events it missed are no longer
+// available and it should reload its expenses.
+const FeedReset = "reset"
+
+type FeedEvent struct {
+	ID         string           `json:"id"`
+	Type       string           `json:"type"`
+	Expense    *ExpenseResponse `json:"expense,omitempty"`
+	Permanent  bool             `json:"permanent,omitempty"`
+	OccurredAt time.Time        `json:"occurred_at"`
+}
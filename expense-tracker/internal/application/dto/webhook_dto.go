@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+)
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,max=2048"`
+	Events []string `json:"events" validate:"required"`
+}
+
+type UpdateWebhookRequest struct {
+	URL    *string   `json:"url" validate:"omitempty,max=2048"`
+	Events *[]string `json:"events"`
+	Active *bool     `json:"active"`
+}
+
+// WebhookResponse includes the signing secret only when the endpoint is
+// created.
+type WebhookResponse struct {
+	ID                  string     `json:"id"`
+	URL                 string     `json:"url"`
+	Events              []string   `json:"events"`
+	Active              bool       `json:"active"`
+	Secret              string     `json:"secret,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID            string     `json:"id"`
+	Event         string     `json:"event"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	ResponseCode  int        `json:"response_code,omitempty"`
+	ResponseBody  string     `json:"response_body,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	Payload       string     `json:"payload"`
+}
@@ -0,0 +1,210 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/domain/repositories"
+)
+
+// Bus delivers events to subscribers in process. Synchronous subscribers
+// run in the publishing goroutine, inside the publisher's transaction, and
+// an error from one is returned by Publish. Events for asynchronous
+// subscribers are written to the outbox in that same transaction, and the
+// Relay hands them over once it has committed, at least once each.
+//
+// A nil *Bus is valid and drops every event.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[reflect.Type][]subscription
+	outbox   repositories.OutboxRepository
+	wake     chan struct{}
+}
+
+type subscription struct {
+	handle func(ctx context.Context, event Event) error
+	async  bool
+}
+
+var anyEvent = reflect.TypeOf((*Event)(nil)).Elem()
+
+func NewBus(outbox repositories.OutboxRepository) *Bus {
+	return &Bus{
+		handlers: make(map[reflect.Type][]subscription),
+		outbox:   outbox,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Subscribe registers a synchronous handler for events of type E. With E
+// set to Event the handler receives every event.
+func Subscribe[E Event](b *Bus, handler func(ctx context.Context, event E) error) {
+	subscribe(b, handler, false)
+}
+
+// SubscribeAsync registers a handler for events of type E that runs in the
+// background after the publishing transaction has committed. A handler may
+// see an event more than once, so it must be idempotent.
+func SubscribeAsync[E Event](b *Bus, handler func(ctx context.Context, event E) error) {
+	subscribe(b, handler, true)
+}
+
+func subscribe[E Event](b *Bus, handler func(ctx context.Context, event E) error, async bool) {
+	eventType := reflect.TypeOf((*E)(nil)).Elem()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], subscription{
+		handle: func(ctx context.Context, event Event) error { return handler(ctx, event.(E)) },
+		async:  async,
+	})
+}
+
+// Publish runs the synchronous subscribers of the event, stopping at the
+// first error, and stores it in the outbox when it has asynchronous ones.
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	if b == nil {
+		return nil
+	}
+
+	async := false
+	for _, sub := range b.subscriptions(event) {
+		if sub.async {
+			async = true
+			continue
+		}
+		if err := sub.handle(ctx, event); err != nil {
+			return fmt.Errorf("%s subscriber: %w", event.EventName(), err)
+		}
+	}
+	if !async {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	err = b.outbox.Append(ctx, &entities.OutboxEvent{
+		UserID:    event.OwnerID(),
+		EventType: event.EventName(),
+		Payload:   string(payload),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Inside a transaction the relay is woken once it has committed
+	if p, ok := ctx.Value(pendingKey{}).(*pending); ok {
+		p.appended = true
+	} else {
+		b.Notify()
+	}
+	return nil
+}
+
+// Notify wakes the relay, for example when another process has written to
+// the outbox.
+func (b *Bus) Notify() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliver runs the asynchronous subscribers of an event from the outbox.
+// All of them run even when one fails; a panic counts as a failure.
+func (b *Bus) deliver(ctx context.Context, event Event) error {
+	var errs []error
+	for _, sub := range b.subscriptions(event) {
+		if sub.async {
+			errs = append(errs, runAsync(ctx, sub, event))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func runAsync(ctx context.Context, sub subscription, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("subscriber panicked: %v", r)
+		}
+	}()
+	return sub.handle(ctx, event)
+}
+
+func (b *Bus) subscriptions(event Event) []subscription {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var subscriptions []subscription
+	subscriptions = append(subscriptions, b.handlers[reflect.TypeOf(event)]...)
+	subscriptions = append(subscriptions, b.handlers[anyEvent]...)
+	return subscriptions
+}
+
+type pendingKey struct{}
+
+// pending records whether a transaction wrote to the outbox, and what to
+// run once it has committed.
+type pending struct {
+	appended    bool
+	afterCommit []func()
+}
+
+// AfterCommit runs fn once the transaction in ctx, started through a
+// Transactional TxManager, has committed, and at once outside of one. fn is
+// dropped when the transaction or the savepoint it was registered in rolls
+// back. Unlike an asynchronous subscriber it runs at most once, for effects
+// such as counters that must not repeat.
+func AfterCommit(ctx context.Context, fn func()) {
+	if p, ok := ctx.Value(pendingKey{}).(*pending); ok {
+		p.afterCommit = append(p.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+// Transactional wraps tx so that the relay is woken as soon as a
+// transaction that published events commits, instead of at its next poll.
+func (b *Bus) Transactional(tx repositories.TxManager) repositories.TxManager {
+	return &txManager{bus: b, tx: tx}
+}
+
+type txManager struct {
+	bus *Bus
+	tx  repositories.TxManager
+}
+
+func (m *txManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if p, nested := ctx.Value(pendingKey{}).(*pending); nested {
+		registered := len(p.afterCommit)
+		err := m.tx.WithinTransaction(ctx, fn)
+		if err != nil {
+			// The savepoint rolled back what fn did
+			p.afterCommit = p.afterCommit[:registered]
+		}
+		return err
+	}
+
+	var p *pending
+	err := m.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		p = &pending{}
+		return fn(context.WithValue(ctx, pendingKey{}, p))
+	})
+	if err != nil {
+		return err
+	}
+	if p.appended && m.bus != nil {
+		m.bus.Notify()
+	}
+	for _, fn := range p.afterCommit {
+		fn()
+	}
+	return nil
+}
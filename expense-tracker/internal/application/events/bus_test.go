@@ -0,0 +1,114 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"expense-tracker/internal/domain/repositories"
+	"expense-tracker/internal/infrastructure/repositories/memory"
+)
+
+func TestAfterCommit(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name string
+		// run registers hooks named after what they belong to
+		run  func(ctx context.Context, tx repositories.TxManager, hook func(ctx context.Context, name string))
+		want []string
+	}{
+		{
+			name: "outside a transaction",
+			run: func(ctx context.Context, tx repositories.TxManager, hook func(ctx context.Context, name string)) {
+				hook(ctx, "now")
+			},
+			want: []string{"now"},
+		},
+		{
+			name: "committed",
+			run: func(ctx context.Context, tx repositories.TxManager, hook func(ctx context.Context, name string)) {
+				tx.WithinTransaction(ctx, func(ctx context.Context) error {
+					hook(ctx, "first")
+					hook(ctx, "second")
+					return nil
+				})
+			},
+			want: []string{"first", "second"},
+		},
+		{
+			name: "rolled back",
+			run: func(ctx context.Context, tx repositories.TxManager, hook func(ctx context.Context, name string)) {
+				tx.WithinTransaction(ctx, func(ctx context.Context) error {
+					hook(ctx, "rolled back")
+					return errFailed
+				})
+			},
+			want: nil,
+		},
+		{
+			name: "failed savepoint",
+			run: func(ctx context.Context, tx repositories.TxManager, hook func(ctx context.Context, name string)) {
+				tx.WithinTransaction(ctx, func(ctx context.Context) error {
+					hook(ctx, "before")
+					tx.WithinTransaction(ctx, func(ctx context.Context) error {
+						hook(ctx, "savepoint")
+						return errFailed
+					})
+					tx.WithinTransaction(ctx, func(ctx context.Context) error {
+						hook(ctx, "released savepoint")
+						return nil
+					})
+					hook(ctx, "after")
+					return nil
+				})
+			},
+			want: []string{"before", "released savepoint", "after"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.NewStore()
+			bus := NewBus(memory.NewOutboxRepository(store))
+			tx := bus.Transactional(memory.NewTxManager(store))
+
+			var ran []string
+			inTransaction := false
+			hook := func(ctx context.Context, name string) {
+				AfterCommit(ctx, func() {
+					if inTransaction {
+						t.Errorf("%s ran before the commit", name)
+					}
+					ran = append(ran, name)
+				})
+			}
+			tt.run(context.Background(), &trackingTx{tx: tx, inTransaction: &inTransaction}, hook)
+
+			if !slices.Equal(ran, tt.want) {
+				t.Fatalf("ran %q, want %q", ran, tt.want)
+			}
+		})
+	}
+}
+
+// trackingTx records whether a transaction function is still running, so
+// hooks running too early are caught.
+type trackingTx struct {
+	tx            repositories.TxManager
+	inTransaction *bool
+	depth         int
+}
+
+func (m *trackingTx) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		m.depth++
+		*m.inTransaction = true
+		defer func() {
+			m.depth--
+			*m.inTransaction = m.depth > 0
+		}()
+		return fn(ctx)
+	})
+}
@@ -0,0 +1,106 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+)
+
+// Event is anything published on the Bus. Events are passed by value so
+// asynchronous subscribers see the state at the time of publishing, and are
+// stored as JSON in the outbox until they are delivered.
+type Event interface {
+	EventName() string
+	// OwnerID is the user the event belongs to. Asynchronous subscribers
+	// receive the events of one user in the order they were published.
+	OwnerID() string
+}
+
+type ExpenseCreated struct {
+	ActorID    string           `json:"actor_id"`
+	Expense    entities.Expense `json:"expense"`
+	OccurredAt time.Time        `json:"occurred_at"`
+}
+
+// ExpenseUpdated is published for edits, restores from the trash and
+// reverts; Action tells them apart.
+type ExpenseUpdated struct {
+	ActorID    string                         `json:"actor_id"`
+	Expense    entities.Expense               `json:"expense"`
+	Action     entities.ExpenseRevisionAction `json:"action"`
+	Changes    []entities.FieldChange         `json:"changes"`
+	OccurredAt time.Time                      `json:"occurred_at"`
+}
+
+// ExpenseDeleted is published when an expense is moved to the trash, and
+// with Permanent set when it is removed for good. Expense.DeletedAt is set
+// on a permanent delete when the expense was in the trash before.
+type ExpenseDeleted struct {
+	ActorID    string           `json:"actor_id"`
+	Expense    entities.Expense `json:"expense"`
+	Permanent  bool             `json:"permanent"`
+	OccurredAt time.Time        `json:"occurred_at"`
+}
+
+type UserRegistered struct {
+	UserID     string    `json:"user_id"`
+	Email      string    `json:"email"`
+	Name       string    `json:"name"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type UserLoggedIn struct {
+	UserID     string    `json:"user_id"`
+	Email      string    `json:"email"`
+	ClientIP   string    `json:"client_ip"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// LoginFailed is published when a login is refused. Reason is one of the
+// LoginFailure constants.
+type LoginFailed struct {
+	Email      string    `json:"email"`
+	ClientIP   string    `json:"client_ip"`
+	Reason     string    `json:"reason"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+const (
+	LoginFailureInvalidCredentials = "invalid_credentials"
+	LoginFailureLocked             = "locked"
+	LoginFailureDisabled           = "disabled"
+)
+
+func (ExpenseCreated) EventName() string { return "expense.created" }
+func (ExpenseUpdated) EventName() string { return "expense.updated" }
+func (ExpenseDeleted) EventName() string { return "expense.deleted" }
+func (UserRegistered) EventName() string { return "user.registered" }
+func (UserLoggedIn) EventName() string   { return "user.logged_in" }
+func (LoginFailed) EventName() string    { return "user.login_failed" }
+
+func (e ExpenseCreated) OwnerID() string { return e.Expense.UserID }
+func (e ExpenseUpdated) OwnerID() string { return e.Expense.UserID }
+func (e ExpenseDeleted) OwnerID() string { return e.Expense.UserID }
+func (e UserRegistered) OwnerID() string { return e.UserID }
+func (e UserLoggedIn) OwnerID() string   { return e.UserID }
+
+// OwnerID of a failed login is empty, as it may not match any user.
+func (e LoginFailed) OwnerID() string { return "" }
+
+// decoders turn outbox payloads back into events. New event types must be
+// added here to reach asynchronous subscribers.
+var decoders = map[string]func(payload []byte) (Event, error){
+	ExpenseCreated{}.EventName(): decode[ExpenseCreated],
+	ExpenseUpdated{}.EventName(): decode[ExpenseUpdated],
+	ExpenseDeleted{}.EventName(): decode[ExpenseDeleted],
+	UserRegistered{}.EventName(): decode[UserRegistered],
+	UserLoggedIn{}.EventName():   decode[UserLoggedIn],
+	LoginFailed{}.EventName():    decode[LoginFailed],
+}
+
+func decode[E Event](payload []byte) (Event, error) {
+	var event E
+	err := json.Unmarshal(payload, &event)
+	return event, err
+}
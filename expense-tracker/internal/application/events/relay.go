@@ -0,0 +1,189 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/domain/repositories"
+
+	"github.com/google/uuid"
+)
+
+// RelayPolicy controls how the relay reads the outbox and retries events
+// whose subscribers fail.
+type RelayPolicy struct {
+	BatchSize    int
+	PollInterval time.Duration
+	MaxAttempts  int
+	BackoffBase  time.Duration
+	BackoffMax   time.Duration
+}
+
+// Relay hands outbox events to the asynchronous subscribers of the bus.
+// Events are delivered at least once, in outbox order per user: while an
+// event of a user waits for a retry, the user's later events wait too. An
+// event that still fails after MaxAttempts is marked processed with its
+// last error and skipped.
+//
+// Every API process runs a relay, but only the one holding the relay lease
+// reads the outbox; the others stand by and take over when the lease
+// expires, relayLeaseTTL after its holder stopped renewing it.
+type Relay struct {
+	bus     *Bus
+	outbox  repositories.OutboxRepository
+	leases  repositories.LeaseRepository
+	holder  string
+	policy  RelayPolicy
+	lastRun atomic.Int64 // Unix nanoseconds
+}
+
+const relayLease = "outbox_relay"
+
+// relayLeaseTTL is how long the relay lease lasts without being renewed.
+// The holder renews it before every batch and at least every PollInterval.
+const relayLeaseTTL = 30 * time.Second
+
+func NewRelay(bus *Bus, outbox repositories.OutboxRepository, leases repositories.LeaseRepository, policy RelayPolicy) *Relay {
+	r := &Relay{bus: bus, outbox: outbox, leases: leases, holder: uuid.NewString(), policy: policy}
+	r.lastRun.Store(time.Now().UnixNano())
+	return r
+}
+
+// LastRun returns when Run last checked the outbox or found another relay
+// holding the lease, or when the relay was created if it has not yet.
+// Health checks use it to spot a stuck relay.
+func (r *Relay) LastRun() time.Time {
+	return time.Unix(0, r.lastRun.Load())
+}
+
+// Run delivers events until ctx ends, while it holds the relay lease. It
+// checks the outbox when the bus is notified and at least every
+// PollInterval, and gives the lease up when it returns.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.policy.PollInterval)
+	defer ticker.Stop()
+	defer r.leases.Release(context.Background(), relayLease, r.holder)
+
+	for {
+		if err := r.drain(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("failed to relay outbox events", "error", err)
+		}
+		r.lastRun.Store(time.Now().UnixNano())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.bus.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain processes batches until the outbox has nothing more that is due.
+// Every batch starts with an event that is attempted, and a failed attempt
+// takes its user out of the next batch, so each round makes progress.
+func (r *Relay) drain(ctx context.Context) error {
+	for ctx.Err() == nil {
+		leading, err := r.leases.Acquire(ctx, relayLease, r.holder, time.Now(), relayLeaseTTL)
+		if err != nil || !leading {
+			return err
+		}
+		_, full, err := r.ProcessBatch(ctx)
+		if err != nil || !full {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProcessBatch makes one attempt for each event in the oldest batch of due
+// events. It returns how many were handled and whether the batch was full.
+func (r *Relay) ProcessBatch(ctx context.Context) (int, bool, error) {
+	pending, err := r.outbox.FindDue(ctx, time.Now(), r.policy.BatchSize)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// A user whose event fails in this batch waits with the rest of theirs
+	blocked := make(map[string]bool)
+	handled := 0
+	for _, row := range pending {
+		if ctx.Err() != nil {
+			break
+		}
+		if blocked[row.UserID] {
+			continue
+		}
+
+		done, err := r.process(ctx, row)
+		if err != nil {
+			return handled, false, err
+		}
+		if !done {
+			blocked[row.UserID] = true
+			continue
+		}
+		handled++
+	}
+	return handled, len(pending) == r.policy.BatchSize, nil
+}
+
+// process delivers one event and reports whether it is finished with,
+// either delivered or given up on.
+func (r *Relay) process(ctx context.Context, row *entities.OutboxEvent) (bool, error) {
+	deliverErr := r.deliver(ctx, row)
+
+	now := time.Now()
+	row.Attempts++
+	row.LastError = ""
+	row.NextAttemptAt = nil
+	switch {
+	case deliverErr == nil:
+		row.ProcessedAt = &now
+	case row.Attempts >= r.policy.MaxAttempts:
+		row.LastError = deliverErr.Error()
+		row.ProcessedAt = &now
+		slog.Error("giving up on outbox event", "id", row.ID, "type", row.EventType, "attempts", row.Attempts, "error", deliverErr)
+	default:
+		row.LastError = deliverErr.Error()
+		next := now.Add(r.backoff(row.Attempts))
+		row.NextAttemptAt = &next
+	}
+
+	if err := r.outbox.Update(ctx, row); err != nil {
+		return false, err
+	}
+	return row.ProcessedAt != nil, nil
+}
+
+func (r *Relay) deliver(ctx context.Context, row *entities.OutboxEvent) error {
+	decode, ok := decoders[row.EventType]
+	if !ok {
+		return fmt.Errorf("unknown event type %q", row.EventType)
+	}
+	event, err := decode([]byte(row.Payload))
+	if err != nil {
+		return err
+	}
+	return r.bus.deliver(ctx, event)
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.policy.BackoffBase
+	for i := 1; i < attempts && delay < r.policy.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > r.policy.BackoffMax {
+		delay = r.policy.BackoffMax
+	}
+	return delay
+}
+
+// PurgeProcessed removes events processed before the cutoff.
+func (r *Relay) PurgeProcessed(ctx context.Context, before time.Time) (int64, error) {
+	return r.outbox.DeleteProcessedBefore(ctx, before)
+}
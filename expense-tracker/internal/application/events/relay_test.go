@@ -0,0 +1,269 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/domain/repositories"
+	"expense-tracker/internal/infrastructure/repositories/memory"
+)
+
+func expenseCreated(userID, expenseID string) ExpenseCreated {
+	return ExpenseCreated{Expense: entities.Expense{ID: expenseID, UserID: userID}}
+}
+
+func newTestRelay(t *testing.T, policy RelayPolicy) (*Bus, *Relay, repositories.OutboxRepository) {
+	t.Helper()
+	store := memory.NewStore()
+	outbox := memory.NewOutboxRepository(store)
+	bus := NewBus(outbox)
+	return bus, NewRelay(bus, outbox, memory.NewLeaseRepository(store), policy), outbox
+}
+
+func TestRelayOrdering(t *testing.T) {
+	tests := []struct {
+		name      string
+		published []ExpenseCreated
+		failures  map[string]int // failed deliveries per expense ID before succeeding
+		batchSize int
+		// deliveries per ProcessBatch call; "!" marks a failed attempt
+		wantBatches [][]string
+	}{
+		{
+			name:        "outbox order",
+			published:   []ExpenseCreated{expenseCreated("a", "a1"), expenseCreated("b", "b1"), expenseCreated("a", "a2")},
+			batchSize:   10,
+			wantBatches: [][]string{{"a1", "b1", "a2"}},
+		},
+		{
+			name:      "a failing event holds back its user's later events only",
+			published: []ExpenseCreated{expenseCreated("a", "a1"), expenseCreated("b", "b1"), expenseCreated("a", "a2"), expenseCreated("b", "b2")},
+			failures:  map[string]int{"a1": 2},
+			batchSize: 10,
+			wantBatches: [][]string{
+				{"!a1", "b1", "b2"},
+				{"!a1"},
+				{"a1", "a2"},
+			},
+		},
+		{
+			name:      "gives up after the maximum attempts and moves on",
+			published: []ExpenseCreated{expenseCreated("a", "a1"), expenseCreated("a", "a2")},
+			failures:  map[string]int{"a1": 10},
+			batchSize: 10,
+			wantBatches: [][]string{
+				{"!a1"},
+				{"!a1"},
+				{"!a1", "a2"},
+			},
+		},
+		{
+			name:        "batches are read in order",
+			published:   []ExpenseCreated{expenseCreated("a", "a1"), expenseCreated("b", "b1"), expenseCreated("a", "a2")},
+			batchSize:   2,
+			wantBatches: [][]string{{"a1", "b1"}, {"a2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			// Retries are due at once, so each ProcessBatch call retries
+			bus, relay, outbox := newTestRelay(t, RelayPolicy{BatchSize: tt.batchSize, MaxAttempts: 3, BackoffBase: time.Nanosecond, BackoffMax: time.Nanosecond})
+
+			var delivered []string
+			failed := make(map[string]int)
+			SubscribeAsync(bus, func(ctx context.Context, e ExpenseCreated) error {
+				if failed[e.Expense.ID] < tt.failures[e.Expense.ID] {
+					failed[e.Expense.ID]++
+					delivered = append(delivered, "!"+e.Expense.ID)
+					return errors.New("subscriber failed")
+				}
+				delivered = append(delivered, e.Expense.ID)
+				return nil
+			})
+			for _, event := range tt.published {
+				if err := bus.Publish(ctx, event); err != nil {
+					t.Fatalf("Publish: %v", err)
+				}
+			}
+
+			for i, want := range tt.wantBatches {
+				delivered = nil
+				if _, _, err := relay.ProcessBatch(ctx); err != nil {
+					t.Fatalf("batch %d: %v", i+1, err)
+				}
+				if !slices.Equal(delivered, want) {
+					t.Fatalf("batch %d delivered %q, want %q", i+1, delivered, want)
+				}
+			}
+
+			if left, _ := outbox.FindUnprocessed(ctx, 100); len(left) != 0 {
+				t.Fatalf("%d events left unprocessed", len(left))
+			}
+		})
+	}
+}
+
+// Events of a user waiting for a retry do not hold back other users, even
+// when they fill whole batches.
+func TestRelaySkipsWaitingUsers(t *testing.T) {
+	ctx := context.Background()
+	bus, relay, _ := newTestRelay(t, RelayPolicy{BatchSize: 2, MaxAttempts: 5, BackoffBase: time.Minute, BackoffMax: time.Hour})
+
+	var delivered []string
+	SubscribeAsync(bus, func(ctx context.Context, e ExpenseCreated) error {
+		if e.Expense.UserID == "a" {
+			return errors.New("subscriber failed")
+		}
+		delivered = append(delivered, e.Expense.ID)
+		return nil
+	})
+	for _, event := range []ExpenseCreated{expenseCreated("a", "a1"), expenseCreated("a", "a2"), expenseCreated("a", "a3"), expenseCreated("b", "b1")} {
+		bus.Publish(ctx, event)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := relay.drain(ctx); err != nil {
+			t.Fatalf("drain: %v", err)
+		}
+		if !slices.Equal(delivered, []string{"b1"}) {
+			t.Fatalf("run %d delivered %q, want b1", i+1, delivered)
+		}
+	}
+}
+
+// Relays of several processes share the outbox; only the lease holder
+// delivers, and another takes over once the lease is given up.
+func TestOnlyTheLeaseHolderRelays(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	outbox := memory.NewOutboxRepository(store)
+	leases := memory.NewLeaseRepository(store)
+	policy := RelayPolicy{BatchSize: 10, MaxAttempts: 3, BackoffBase: time.Second, BackoffMax: time.Second}
+
+	var delivered []string
+	newProcess := func(name string) (*Bus, *Relay) {
+		bus := NewBus(outbox)
+		SubscribeAsync(bus, func(ctx context.Context, e ExpenseCreated) error {
+			delivered = append(delivered, name+":"+e.Expense.ID)
+			return nil
+		})
+		return bus, NewRelay(bus, outbox, leases, policy)
+	}
+	busA, relayA := newProcess("a")
+	busB, relayB := newProcess("b")
+
+	steps := []struct {
+		publish *Bus
+		id      string
+		relays  []*Relay
+		want    []string
+	}{
+		{busA, "e1", []*Relay{relayA, relayB}, []string{"a:e1"}},
+		{busB, "e2", []*Relay{relayB, relayA}, []string{"a:e2"}},
+		{busB, "e3", []*Relay{relayB}, nil},
+	}
+	for i, step := range steps {
+		delivered = nil
+		step.publish.Publish(ctx, expenseCreated("u", step.id))
+		for _, relay := range step.relays {
+			if err := relay.drain(ctx); err != nil {
+				t.Fatalf("step %d: drain: %v", i+1, err)
+			}
+		}
+		if !slices.Equal(delivered, step.want) {
+			t.Fatalf("step %d delivered %q, want %q", i+1, delivered, step.want)
+		}
+	}
+
+	delivered = nil
+	leases.Release(ctx, relayLease, relayA.holder)
+	relayB.drain(ctx)
+	if !slices.Equal(delivered, []string{"b:e3"}) {
+		t.Fatalf("after the lease was released delivered %q, want b:e3", delivered)
+	}
+}
+
+func TestRelayRecordsFailures(t *testing.T) {
+	ctx := context.Background()
+	bus, relay, outbox := newTestRelay(t, RelayPolicy{BatchSize: 10, MaxAttempts: 2, BackoffBase: time.Minute, BackoffMax: time.Hour})
+	SubscribeAsync(bus, func(ctx context.Context, e ExpenseCreated) error {
+		panic("subscriber bug")
+	})
+	bus.Publish(ctx, expenseCreated("a", "a1"))
+
+	before := time.Now()
+	if handled, _, err := relay.ProcessBatch(ctx); err != nil || handled != 0 {
+		t.Fatalf("ProcessBatch = %d, %v; want nothing handled", handled, err)
+	}
+
+	rows, _ := outbox.FindUnprocessed(ctx, 10)
+	if len(rows) != 1 {
+		t.Fatalf("%d unprocessed events, want 1", len(rows))
+	}
+	row := rows[0]
+	if row.Attempts != 1 || row.LastError == "" || row.NextAttemptAt == nil {
+		t.Fatalf("after a panic: attempts %d, error %q, next attempt %v", row.Attempts, row.LastError, row.NextAttemptAt)
+	}
+	if wait := row.NextAttemptAt.Sub(before); wait < time.Minute || wait > time.Minute+time.Second {
+		t.Fatalf("retry in %v, want a minute", wait)
+	}
+
+	// Not due yet
+	if handled, _, _ := relay.ProcessBatch(ctx); handled != 0 {
+		t.Fatal("event was retried before its backoff")
+	}
+}
+
+func TestRelayBackoff(t *testing.T) {
+	relay := NewRelay(nil, nil, nil, RelayPolicy{BackoffBase: time.Second, BackoffMax: time.Minute})
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{6, 32 * time.Second},
+		{7, time.Minute},
+		{100, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := relay.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// Events published in a transaction that rolls back never reach the outbox.
+func TestOutboxFollowsTheTransaction(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	outbox := memory.NewOutboxRepository(store)
+	bus := NewBus(outbox)
+	SubscribeAsync(bus, func(ctx context.Context, e ExpenseCreated) error { return nil })
+	tx := bus.Transactional(memory.NewTxManager(store))
+
+	tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		bus.Publish(ctx, expenseCreated("a", "rolled back"))
+		return errors.New("failed")
+	})
+	tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return bus.Publish(ctx, expenseCreated("a", "committed"))
+	})
+
+	rows, _ := outbox.FindUnprocessed(ctx, 10)
+	if len(rows) != 1 || !strings.Contains(rows[0].Payload, `"committed"`) {
+		t.Fatalf("outbox holds %d events, want only the committed one", len(rows))
+	}
+	select {
+	case <-bus.wake:
+	default:
+		t.Fatal("the relay was not woken after the commit")
+	}
+}
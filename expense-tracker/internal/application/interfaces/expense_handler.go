@@ -5,6 +5,13 @@ import "net/http"
 type ExpenseHandler interface {
 	CreateExpense(w http.ResponseWriter, r *http.Request)
 	GetExpenses(w http.ResponseWriter, r *http.Request)
+	GetExpense(w http.ResponseWriter, r *http.Request)
 	UpdateExpense(w http.ResponseWriter, r *http.Request)
 	DeleteExpense(w http.ResponseWriter, r *http.Request)
-}
\ No newline at end of file
+	Batch(w http.ResponseWriter, r *http.Request)
+	GetTrash(w http.ResponseWriter, r *http.Request)
+	RestoreExpense(w http.ResponseWriter, r *http.Request)
+	PermanentlyDeleteExpense(w http.ResponseWriter, r *http.Request)
+	GetHistory(w http.ResponseWriter, r *http.Request)
+	RevertExpense(w http.ResponseWriter, r *http.Request)
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "net/http"
+
+type ExportHandler interface {
+	RequestExport(w http.ResponseWriter, r *http.Request)
+	GetExport(w http.ResponseWriter, r *http.Request)
+	ListExports(w http.ResponseWriter, r *http.Request)
+	Download(w http.ResponseWriter, r *http.Request)
+}
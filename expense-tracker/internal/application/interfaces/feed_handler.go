@@ -0,0 +1,8 @@
+package interfaces
+
+import "net/http"
+
+type FeedHandler interface {
+	Stream(w http.ResponseWriter, r *http.Request)
+	WebSocket(w http.ResponseWriter, r *http.Request)
+}
@@ -0,0 +1,7 @@
+package interfaces
+
+import "net/http"
+
+type GraphQLHandler interface {
+	Query(w http.ResponseWriter, r *http.Request)
+}
@@ -0,0 +1,13 @@
+package interfaces
+
+import "net/http"
+
+type WebhookHandler interface {
+	CreateWebhook(w http.ResponseWriter, r *http.Request)
+	ListWebhooks(w http.ResponseWriter, r *http.Request)
+	GetWebhook(w http.ResponseWriter, r *http.Request)
+	UpdateWebhook(w http.ResponseWriter, r *http.Request)
+	DeleteWebhook(w http.ResponseWriter, r *http.Request)
+	ListDeliveries(w http.ResponseWriter, r *http.Request)
+	Redeliver(w http.ResponseWriter, r *http.Request)
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/domain/repositories"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrIncorrectPassword  = errors.New("current password is incorrect")
+	ErrEmailExists        = errors.New("email already exists")
+	ErrInvalidVerifyToken = errors.New("invalid or expired verification token")
+	ErrEmptyName          = errors.New("name cannot be empty")
+)
+
+// EmailVerificationSender delivers the token that confirms a new email
+// address to that address.
+type EmailVerificationSender interface {
+	SendEmailVerification(ctx context.Context, user *entities.User, email, token string) error
+}
+
+type AccountService struct {
+	txManager        repositories.TxManager
+	userRepo         repositories.UserRepository
+	verificationRepo repositories.EmailVerificationRepository
+	sender           EmailVerificationSender
+	verificationTTL  time.Duration
+}
+
+func NewAccountService(txManager repositories.TxManager, userRepo repositories.UserRepository, verificationRepo repositories.EmailVerificationRepository, sender EmailVerificationSender, verificationTTL time.Duration) *AccountService {
+	return &AccountService{
+		txManager:        txManager,
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		sender:           sender,
+		verificationTTL:  verificationTTL,
+	}
+}
+
+func (s *AccountService) GetProfile(ctx context.Context, userID string) (*dto.UserResponse, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, user)
+}
+
+// UpdateProfile changes the name right away. A new email is only stored as
+// pending until it is confirmed through VerifyEmail.
+func (s *AccountService) UpdateProfile(ctx context.Context, userID string, req dto.UpdateProfileRequest) (*dto.UserResponse, error) {
+	var user *entities.User
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.findUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if name == "" {
+				return ErrEmptyName
+			}
+			user.Name = name
+			if err := s.userRepo.Update(ctx, user); err != nil {
+				return err
+			}
+		}
+
+		if req.Email != nil && !strings.EqualFold(*req.Email, user.Email) {
+			return s.requestEmailChange(ctx, user, normalizeEmail(*req.Email))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(ctx, user)
+}
+
+func (s *AccountService) VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) (*dto.UserResponse, error) {
+	verification, err := s.verificationRepo.FindByTokenHash(ctx, hashToken(req.Token))
+	if err != nil {
+		return nil, err
+	}
+	if verification == nil || time.Now().After(verification.ExpiresAt) {
+		return nil, ErrInvalidVerifyToken
+	}
+
+	var user *entities.User
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.findUser(ctx, verification.UserID)
+		if err != nil {
+			return err
+		}
+
+		// The address may have been taken since the change was requested
+		exists, err := s.userRepo.ExistsByEmail(ctx, verification.Email)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrEmailExists
+		}
+
+		user.Email = verification.Email
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.verificationRepo.DeleteByUserID(ctx, user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(ctx, user)
+}
+
+func (s *AccountService) ChangePassword(ctx context.Context, userID string, req dto.ChangePasswordRequest) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		return ErrIncorrectPassword
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	user.Password = string(hashedPassword)
+	return s.userRepo.Update(ctx, user)
+}
+
+// DeleteAccount removes the user and all of their data. The password is
+// required again so a stolen token alone cannot erase an account.
+func (s *AccountService) DeleteAccount(ctx context.Context, userID string, req dto.DeleteAccountRequest) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return ErrIncorrectPassword
+	}
+
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.userRepo.Delete(ctx, user.ID)
+	})
+}
+
+func (s *AccountService) requestEmailChange(ctx context.Context, user *entities.User, email string) error {
+	exists, err := s.userRepo.ExistsByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrEmailExists
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	verification := &entities.EmailVerification{
+		UserID:    user.ID,
+		Email:     email,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.verificationTTL),
+	}
+	if err := s.verificationRepo.Save(ctx, verification); err != nil {
+		return err
+	}
+
+	return s.sender.SendEmailVerification(ctx, user, email, token)
+}
+
+func (s *AccountService) findUser(ctx context.Context, userID string) (*entities.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *AccountService) toResponse(ctx context.Context, user *entities.User) (*dto.UserResponse, error) {
+	response := &dto.UserResponse{
+		ID:        user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+
+	verification, err := s.verificationRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if verification != nil && time.Now().Before(verification.ExpiresAt) {
+		response.PendingEmail = verification.Email
+	}
+
+	return response, nil
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
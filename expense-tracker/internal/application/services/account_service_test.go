@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/infrastructure/repositories/memory"
+)
+
+// mailbox is an EmailVerificationSender keeping the last token sent to each
+// address.
+type mailbox struct {
+	tokens map[string]string
+}
+
+func (m *mailbox) SendEmailVerification(ctx context.Context, user *entities.User, email, token string) error {
+	m.tokens[email] = token
+	return nil
+}
+
+func newTestAccountService(t *testing.T, verificationTTL time.Duration) (*AccountService, *AuthService, *mailbox) {
+	t.Helper()
+	auth, store := newTestAuthService(t, LoginPolicy{})
+	sender := &mailbox{tokens: map[string]string{}}
+	service := NewAccountService(memory.NewTxManager(store), memory.NewUserRepository(store), memory.NewEmailVerificationRepository(store), sender, verificationTTL)
+	return service, auth, sender
+}
+
+func TestUpdateProfile(t *testing.T) {
+	ctx := context.Background()
+	service, auth, _ := newTestAccountService(t, time.Hour)
+	user := register(t, auth, "jane@example.com").User
+	register(t, auth, "taken@example.com")
+
+	name := "Jane Doe"
+	profile, err := service.UpdateProfile(ctx, user.ID, dto.UpdateProfileRequest{Name: &name})
+	if err != nil || profile.Name != name {
+		t.Fatalf("UpdateProfile = %+v, %v; want the new name", profile, err)
+	}
+
+	blank := "  "
+	if _, err := service.UpdateProfile(ctx, user.ID, dto.UpdateProfileRequest{Name: &blank}); !errors.Is(err, ErrEmptyName) {
+		t.Fatalf("UpdateProfile with a blank name = %v, want ErrEmptyName", err)
+	}
+	taken := "Taken@example.com"
+	if _, err := service.UpdateProfile(ctx, user.ID, dto.UpdateProfileRequest{Email: &taken}); !errors.Is(err, ErrEmailExists) {
+		t.Fatalf("UpdateProfile to a taken email = %v, want ErrEmailExists", err)
+	}
+	if _, err := service.GetProfile(ctx, "missing"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GetProfile of a missing user = %v, want ErrUserNotFound", err)
+	}
+
+	profile, err = service.GetProfile(ctx, user.ID)
+	if err != nil || profile.Name != name || profile.Email != "jane@example.com" {
+		t.Fatalf("GetProfile = %+v, %v; want the name changed and the email kept", profile, err)
+	}
+}
+
+func TestEmailChange(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("confirmed with the token", func(t *testing.T) {
+		service, auth, sender := newTestAccountService(t, time.Hour)
+		user := register(t, auth, "jane@example.com").User
+
+		email := "Jane.Doe@example.com"
+		profile, err := service.UpdateProfile(ctx, user.ID, dto.UpdateProfileRequest{Email: &email})
+		if err != nil || profile.Email != "jane@example.com" || profile.PendingEmail != "jane.doe@example.com" {
+			t.Fatalf("UpdateProfile = %+v, %v; want the new email pending", profile, err)
+		}
+
+		token := sender.tokens["jane.doe@example.com"]
+		profile, err = service.VerifyEmail(ctx, dto.VerifyEmailRequest{Token: token})
+		if err != nil || profile.Email != "jane.doe@example.com" || profile.PendingEmail != "" {
+			t.Fatalf("VerifyEmail = %+v, %v; want the email changed", profile, err)
+		}
+		if _, err := service.VerifyEmail(ctx, dto.VerifyEmailRequest{Token: token}); !errors.Is(err, ErrInvalidVerifyToken) {
+			t.Fatalf("VerifyEmail twice = %v, want ErrInvalidVerifyToken", err)
+		}
+	})
+
+	t.Run("a new request replaces the pending one", func(t *testing.T) {
+		service, auth, sender := newTestAccountService(t, time.Hour)
+		user := register(t, auth, "jane@example.com").User
+
+		first, second := "first@example.com", "second@example.com"
+		for _, email := range []*string{&first, &second} {
+			if _, err := service.UpdateProfile(ctx, user.ID, dto.UpdateProfileRequest{Email: email}); err != nil {
+				t.Fatalf("UpdateProfile: %v", err)
+			}
+		}
+		if _, err := service.VerifyEmail(ctx, dto.VerifyEmailRequest{Token: sender.tokens[first]}); !errors.Is(err, ErrInvalidVerifyToken) {
+			t.Fatalf("VerifyEmail with the replaced token = %v, want ErrInvalidVerifyToken", err)
+		}
+		if profile, err := service.VerifyEmail(ctx, dto.VerifyEmailRequest{Token: sender.tokens[second]}); err != nil || profile.Email != second {
+			t.Fatalf("VerifyEmail = %+v, %v; want %s", profile, err, second)
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		service, auth, sender := newTestAccountService(t, -time.Minute)
+		user := register(t, auth, "jane@example.com").User
+
+		email := "new@example.com"
+		profile, err := service.UpdateProfile(ctx, user.ID, dto.UpdateProfileRequest{Email: &email})
+		if err != nil || profile.PendingEmail != "" {
+			t.Fatalf("UpdateProfile = %+v, %v; want no pending email once expired", profile, err)
+		}
+		if _, err := service.VerifyEmail(ctx, dto.VerifyEmailRequest{Token: sender.tokens[email]}); !errors.Is(err, ErrInvalidVerifyToken) {
+			t.Fatalf("VerifyEmail = %v, want ErrInvalidVerifyToken", err)
+		}
+	})
+
+	t.Run("address taken before confirmation", func(t *testing.T) {
+		service, auth, sender := newTestAccountService(t, time.Hour)
+		user := register(t, auth, "jane@example.com").User
+
+		email := "new@example.com"
+		if _, err := service.UpdateProfile(ctx, user.ID, dto.UpdateProfileRequest{Email: &email}); err != nil {
+			t.Fatalf("UpdateProfile: %v", err)
+		}
+		register(t, auth, email)
+		if _, err := service.VerifyEmail(ctx, dto.VerifyEmailRequest{Token: sender.tokens[email]}); !errors.Is(err, ErrEmailExists) {
+			t.Fatalf("VerifyEmail = %v, want ErrEmailExists", err)
+		}
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+	service, auth, _ := newTestAccountService(t, time.Hour)
+	user := register(t, auth, "jane@example.com").User
+
+	err := service.ChangePassword(ctx, user.ID, dto.ChangePasswordRequest{CurrentPassword: "wrong-password", NewPassword: "changed12"})
+	if !errors.Is(err, ErrIncorrectPassword) {
+		t.Fatalf("ChangePassword with a wrong password = %v, want ErrIncorrectPassword", err)
+	}
+	if err := service.ChangePassword(ctx, user.ID, dto.ChangePasswordRequest{CurrentPassword: testPassword, NewPassword: "changed12"}); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+
+	if _, err := auth.Login(ctx, dto.LoginRequest{Email: user.Email, Password: testPassword}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login with the old password = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := auth.Login(ctx, dto.LoginRequest{Email: user.Email, Password: "changed12"}); err != nil {
+		t.Fatalf("Login with the new password: %v", err)
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	service, auth, _ := newTestAccountService(t, time.Hour)
+	user := register(t, auth, "jane@example.com").User
+	other := register(t, auth, "john@example.com").User
+
+	if err := service.DeleteAccount(ctx, user.ID, dto.DeleteAccountRequest{Password: "wrong-password"}); !errors.Is(err, ErrIncorrectPassword) {
+		t.Fatalf("DeleteAccount with a wrong password = %v, want ErrIncorrectPassword", err)
+	}
+	if _, err := service.GetProfile(ctx, user.ID); err != nil {
+		t.Fatalf("account removed without the password: %v", err)
+	}
+
+	if err := service.DeleteAccount(ctx, user.ID, dto.DeleteAccountRequest{Password: testPassword}); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+	if _, err := service.GetProfile(ctx, user.ID); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GetProfile after deletion = %v, want ErrUserNotFound", err)
+	}
+	if _, err := service.GetProfile(ctx, other.ID); err != nil {
+		t.Fatalf("another account was removed: %v", err)
+	}
+	// The email can be used again
+	register(t, auth, "jane@example.com")
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// accountStatusTTL is how long a looked-up account status is trusted.
+// Disabling or deleting an account, also from cmd/admin in another process,
+// shuts its tokens out within that time.
+const accountStatusTTL = 30 * time.Second
+
+type accountStatus struct {
+	err     error
+	checked time.Time
+}
+
+// accountStatusCache remembers whether accounts may use their tokens, so
+// authenticating a request does not cost a query every time.
+type accountStatusCache struct {
+	mu        sync.Mutex
+	entries   map[string]accountStatus
+	lastSweep time.Time
+}
+
+func newAccountStatusCache() *accountStatusCache {
+	return &accountStatusCache{entries: make(map[string]accountStatus), lastSweep: time.Now()}
+}
+
+func (c *accountStatusCache) get(userID string, now time.Time) (accountStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status, ok := c.entries[userID]
+	if !ok || now.Sub(status.checked) >= accountStatusTTL {
+		return accountStatus{}, false
+	}
+	return status, true
+}
+
+func (c *accountStatusCache) put(userID string, err error, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[userID] = accountStatus{err: err, checked: now}
+	if now.Sub(c.lastSweep) < accountStatusTTL {
+		return
+	}
+	c.lastSweep = now
+	for id, status := range c.entries {
+		if now.Sub(status.checked) >= accountStatusTTL {
+			delete(c.entries, id)
+		}
+	}
+}
+
+// Authenticate returns the user a token was issued to. It fails with
+// ErrInvalidToken for a bad or expired token or a deleted account, and
+// with ErrAccountDisabled for a disabled one.
+func (s *AuthService) Authenticate(ctx context.Context, token string) (string, error) {
+	userID, err := s.jwtMgr.ValidateToken(token)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	if err := s.CheckAccount(ctx, userID); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+// CheckAccount reports whether the user may still use tokens issued
+// earlier, for connections that outlive the request that authenticated
+// them. The answer may be up to accountStatusTTL old.
+func (s *AuthService) CheckAccount(ctx context.Context, userID string) error {
+	now := time.Now()
+	if status, ok := s.statuses.get(userID, now); ok {
+		return status.err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	var status error
+	switch {
+	case user == nil:
+		status = ErrInvalidToken
+	case user.DisabledAt != nil:
+		status = ErrAccountDisabled
+	}
+	s.statuses.put(userID, status, now)
+	return status
+}
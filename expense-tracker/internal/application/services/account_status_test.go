@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   func(registered string) string
+		change  func(ctx context.Context, service *AuthService, userID string)
+		wantErr error
+	}{
+		{
+			name:  "valid token",
+			token: func(registered string) string { return registered },
+		},
+		{
+			name:    "malformed token",
+			token:   func(registered string) string { return "not-a-token" },
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "tampered token",
+			token:   func(registered string) string { return registered + "x" },
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:  "disabled account",
+			token: func(registered string) string { return registered },
+			change: func(ctx context.Context, service *AuthService, userID string) {
+				user, _ := service.userRepo.FindByID(ctx, userID)
+				now := time.Now()
+				user.DisabledAt = &now
+				service.userRepo.Update(ctx, user)
+			},
+			wantErr: ErrAccountDisabled,
+		},
+		{
+			name:  "deleted account",
+			token: func(registered string) string { return registered },
+			change: func(ctx context.Context, service *AuthService, userID string) {
+				service.userRepo.Delete(ctx, userID)
+			},
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service, _ := newTestAuthService(t, LoginPolicy{})
+			registered := register(t, service, "jane@example.com")
+			if tt.change != nil {
+				tt.change(ctx, service, registered.User.ID)
+			}
+
+			userID, err := service.Authenticate(ctx, tt.token(registered.Token))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && userID != registered.User.ID {
+				t.Fatalf("Authenticate = %q, want %q", userID, registered.User.ID)
+			}
+		})
+	}
+}
+
+// A disabled account keeps working until its cached status expires.
+func TestAccountStatusIsCached(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestAuthService(t, LoginPolicy{})
+	registered := register(t, service, "jane@example.com")
+	userID := registered.User.ID
+
+	if err := service.CheckAccount(ctx, userID); err != nil {
+		t.Fatalf("CheckAccount: %v", err)
+	}
+	user, _ := service.userRepo.FindByID(ctx, userID)
+	now := time.Now()
+	user.DisabledAt = &now
+	service.userRepo.Update(ctx, user)
+
+	if err := service.CheckAccount(ctx, userID); err != nil {
+		t.Fatalf("CheckAccount within the TTL = %v, want the cached answer", err)
+	}
+
+	service.statuses.put(userID, nil, now.Add(-accountStatusTTL))
+	if _, err := service.Authenticate(ctx, registered.Token); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("Authenticate after the TTL = %v, want ErrAccountDisabled", err)
+	}
+}
+
+func TestAccountStatusCache(t *testing.T) {
+	cache := newAccountStatusCache()
+	start := time.Now()
+	cache.put("a", ErrAccountDisabled, start)
+
+	tests := []struct {
+		age    time.Duration
+		wantOK bool
+	}{
+		{0, true},
+		{accountStatusTTL - time.Millisecond, true},
+		{accountStatusTTL, false},
+		{time.Hour, false},
+	}
+	for _, tt := range tests {
+		status, ok := cache.get("a", start.Add(tt.age))
+		if ok != tt.wantOK {
+			t.Errorf("get after %v: ok = %v, want %v", tt.age, ok, tt.wantOK)
+		}
+		if ok && !errors.Is(status.err, ErrAccountDisabled) {
+			t.Errorf("get after %v = %v, want ErrAccountDisabled", tt.age, status.err)
+		}
+	}
+
+	// A put once the TTL has passed sweeps out expired entries
+	cache.put("b", nil, start.Add(accountStatusTTL+time.Second))
+	if _, ok := cache.entries["a"]; ok {
+		t.Fatal("expired entry was not swept")
+	}
+	if _, ok := cache.entries["b"]; !ok {
+		t.Fatal("fresh entry was swept")
+	}
+}
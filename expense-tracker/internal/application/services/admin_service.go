@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/events"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/domain/repositories"
+	"expense-tracker/internal/domain/valueobjects"
+	"math"
+	"math/rand"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AdminService holds the operator tasks of cmd/admin. It finds accounts by
+// email and skips the password checks users go through, so it must never be
+// reachable from the API.
+type AdminService struct {
+	txManager      repositories.TxManager
+	userRepo       repositories.UserRepository
+	attemptRepo    repositories.LoginAttemptRepository
+	expenseService *ExpenseService
+	events         *events.Bus
+}
+
+func NewAdminService(txManager repositories.TxManager, userRepo repositories.UserRepository, attemptRepo repositories.LoginAttemptRepository, expenseService *ExpenseService, bus *events.Bus) *AdminService {
+	return &AdminService{txManager: txManager, userRepo: userRepo, attemptRepo: attemptRepo, expenseService: expenseService, events: bus}
+}
+
+// CreateUser creates an account the way Register does, without logging in.
+func (s *AdminService) CreateUser(ctx context.Context, req dto.RegisterRequest) (*entities.User, error) {
+	user, err := createAccount(ctx, s.userRepo, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.events.Publish(ctx, events.UserRegistered{UserID: user.ID, Email: user.Email, Name: user.Name, OccurredAt: user.CreatedAt}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetDisabled disables or re-enables an account. Disabled users cannot log
+// in, and the API stops accepting tokens issued before within the time it
+// caches account status.
+func (s *AdminService) SetDisabled(ctx context.Context, email string, disabled bool) (*entities.User, error) {
+	user, err := s.findByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case disabled && user.DisabledAt == nil:
+		now := time.Now()
+		user.DisabledAt = &now
+	case !disabled:
+		user.DisabledAt = nil
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ResetPassword sets a new password and forgets the failed logins, so an
+// account locked out by them can log in with it at once.
+func (s *AdminService) ResetPassword(ctx context.Context, email, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := s.findByEmail(ctx, email)
+		if err != nil {
+			return err
+		}
+
+		user.Password = string(hashedPassword)
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.attemptRepo.DeleteFailures(ctx, user.Email)
+	})
+}
+
+// demoExpense is a kind of expense the demo data is generated from.
+type demoExpense struct {
+	category    valueobjects.Category
+	description string
+	min, max    float64
+}
+
+var (
+	// Bills paid on the first of every month
+	demoBills = []demoExpense{
+		{valueobjects.Utilities, "Electricity", 45, 90},
+		{valueobjects.Utilities, "Internet", 39.99, 39.99},
+		{valueobjects.Health, "Gym membership", 29, 29},
+	}
+	demoPurchases = []demoExpense{
+		{valueobjects.Groceries, "Supermarket", 15, 120},
+		{valueobjects.Groceries, "Bakery", 3, 12},
+		{valueobjects.Groceries, "Farmers market", 10, 45},
+		{valueobjects.Leisure, "Cinema", 9, 25},
+		{valueobjects.Leisure, "Dinner out", 25, 90},
+		{valueobjects.Leisure, "Concert tickets", 40, 120},
+		{valueobjects.Electronics, "Headphones", 30, 250},
+		{valueobjects.Electronics, "Phone charger", 10, 35},
+		{valueobjects.Clothing, "Shoes", 40, 150},
+		{valueobjects.Clothing, "T-shirts", 15, 60},
+		{valueobjects.Health, "Pharmacy", 5, 40},
+		{valueobjects.Others, "Gift", 20, 80},
+		{valueobjects.Others, "Parking", 2, 15},
+	}
+)
+
+// SeedDemoData creates the account in req with months of generated
+// expenses, all in one transaction. A fixed seed makes every run generate
+// the same expenses.
+func (s *AdminService) SeedDemoData(ctx context.Context, req dto.RegisterRequest, months int) (*entities.User, int, error) {
+	var user *entities.User
+	created := 0
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if user, err = s.CreateUser(ctx, req); err != nil {
+			return err
+		}
+
+		rng := rand.New(rand.NewSource(1))
+		add := func(kind demoExpense, date time.Time) error {
+			amount := kind.min + rng.Float64()*(kind.max-kind.min)
+			_, err := s.expenseService.CreateExpense(ctx, user.ID, dto.CreateExpenseRequest{
+				Amount:      math.Round(amount*100) / 100,
+				Category:    string(kind.category),
+				Description: kind.description,
+				Date:        date.Format("2006-01-02"),
+			})
+			created++
+			return err
+		}
+
+		today := time.Now().Truncate(24 * time.Hour)
+		for date := today.AddDate(0, -months, 0); !date.After(today); date = date.AddDate(0, 0, 1) {
+			if date.Day() == 1 {
+				for _, bill := range demoBills {
+					if err := add(bill, date); err != nil {
+						return err
+					}
+				}
+			}
+			for n := rng.Intn(3); n > 0; n-- {
+				if err := add(demoPurchases[rng.Intn(len(demoPurchases))], date); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return user, created, nil
+}
+
+func (s *AdminService) findByEmail(ctx context.Context, email string) (*entities.User, error) {
+	user, err := s.userRepo.FindByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
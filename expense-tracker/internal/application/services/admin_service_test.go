@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/events"
+	"expense-tracker/internal/infrastructure/repositories/memory"
+)
+
+// newTestAdminService shares the store of an AuthService, so accounts can
+// be used both ways.
+func newTestAdminService(t *testing.T, policy LoginPolicy) (*AdminService, *AuthService) {
+	t.Helper()
+	auth, store := newTestAuthService(t, policy)
+	tx := memory.NewTxManager(store)
+	bus := events.NewBus(memory.NewOutboxRepository(store))
+	expenses := NewExpenseService(tx, memory.NewExpenseRepository(store), memory.NewExpenseRevisionRepository(store), bus)
+	return NewAdminService(tx, memory.NewUserRepository(store), memory.NewLoginAttemptRepository(store), expenses, bus), auth
+}
+
+func TestAdminCreateUser(t *testing.T) {
+	ctx := context.Background()
+	admin, auth := newTestAdminService(t, LoginPolicy{})
+
+	user, err := admin.CreateUser(ctx, dto.RegisterRequest{Email: " Jane@Example.com ", Password: testPassword, Name: "Jane"})
+	if err != nil || user.Email != "jane@example.com" {
+		t.Fatalf("CreateUser = %+v, %v; want the email normalized", user, err)
+	}
+	if _, err := auth.Login(ctx, dto.LoginRequest{Email: "jane@example.com", Password: testPassword}); err != nil {
+		t.Fatalf("Login as the created user: %v", err)
+	}
+
+	// Both ways of creating accounts refuse a taken email
+	if _, err := admin.CreateUser(ctx, dto.RegisterRequest{Email: "JANE@example.com", Password: testPassword, Name: "Jane"}); !errors.Is(err, ErrEmailExists) {
+		t.Fatalf("CreateUser with a taken email = %v, want ErrEmailExists", err)
+	}
+	if _, err := auth.Register(ctx, dto.RegisterRequest{Email: "jane@example.com", Password: testPassword, Name: "Jane"}); !errors.Is(err, ErrEmailExists) {
+		t.Fatalf("Register with a taken email = %v, want ErrEmailExists", err)
+	}
+}
+
+func TestAdminResetPasswordLiftsLockout(t *testing.T) {
+	ctx := context.Background()
+	admin, auth := newTestAdminService(t, LoginPolicy{MaxFailedAttempts: 2, FailureWindow: time.Hour, LockoutDuration: time.Hour})
+	register(t, auth, "jane@example.com")
+
+	for i := 0; i < 2; i++ {
+		auth.Login(ctx, dto.LoginRequest{Email: "jane@example.com", Password: "wrong-password"})
+	}
+	if _, err := auth.Login(ctx, dto.LoginRequest{Email: "jane@example.com", Password: testPassword}); loginResult(err) != "locked" {
+		t.Fatalf("Login before the reset = %v, want locked", err)
+	}
+
+	if err := admin.ResetPassword(ctx, "Jane@example.com", "changed12"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if _, err := auth.Login(ctx, dto.LoginRequest{Email: "jane@example.com", Password: "changed12"}); err != nil {
+		t.Fatalf("Login after the reset: %v", err)
+	}
+	if err := admin.ResetPassword(ctx, "nobody@example.com", "changed12"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("ResetPassword of an unknown user = %v, want ErrUserNotFound", err)
+	}
+}
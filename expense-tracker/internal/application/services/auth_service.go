@@ -4,33 +4,96 @@ import (
 	"context"
 	"errors"
 	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/events"
 	"expense-tracker/internal/domain/entities"
 	"expense-tracker/internal/domain/repositories"
+	"expense-tracker/internal/pkg/logger"
+	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrAccountDisabled    = errors.New("account is disabled")
+	ErrInvalidToken       = errors.New("invalid token")
+)
+
+// AccountLockedError is returned by Login while an account is locked out
+// after too many failed attempts.
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	return "account temporarily locked due to too many failed login attempts"
+}
+
+// LoginPolicy controls progressive delays and lockout of failed logins.
+// A zero MaxFailedAttempts disables lockout.
+type LoginPolicy struct {
+	MaxFailedAttempts int
+	FailureWindow     time.Duration
+	LockoutDuration   time.Duration
+	DelayBase         time.Duration
+	DelayMax          time.Duration
+}
+
 type JWTManager interface {
 	GenerateToken(userID string) (string, error)
 	ValidateToken(token string) (string, error)
 }
 
 type AuthService struct {
-	userRepo repositories.UserRepository
-	jwtMgr   JWTManager
+	userRepo    repositories.UserRepository
+	attemptRepo repositories.LoginAttemptRepository
+	jwtMgr      JWTManager
+	policy      LoginPolicy
+	events      *events.Bus
+	statuses    *accountStatusCache
 }
 
-func NewAuthService(userRepo repositories.UserRepository, jwtMgr JWTManager) *AuthService {
-	return &AuthService{userRepo: userRepo, jwtMgr: jwtMgr}
+func NewAuthService(userRepo repositories.UserRepository, attemptRepo repositories.LoginAttemptRepository, jwtMgr JWTManager, policy LoginPolicy, bus *events.Bus) *AuthService {
+	return &AuthService{userRepo: userRepo, attemptRepo: attemptRepo, jwtMgr: jwtMgr, policy: policy, events: bus, statuses: newAccountStatusCache()}
 }
 
 func (s *AuthService) Register(ctx context.Context, req dto.RegisterRequest) (*dto.AuthResponse, error) {
-	exists, err := s.userRepo.ExistsByEmail(ctx, req.Email)
+	ctx, span := startSpan(ctx, "AuthService.Register", "")
+	defer span.End()
+
+	user, err := createAccount(ctx, s.userRepo, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, events.UserRegistered{UserID: user.ID, Email: user.Email, Name: user.Name, OccurredAt: user.CreatedAt})
+
+	token, err := s.jwtMgr.GenerateToken(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.AuthResponse{
+		Token: token,
+	}
+	response.User.ID = user.ID
+	response.User.Email = user.Email
+	response.User.Name = user.Name
+
+	return response, nil
+}
+
+// createAccount creates the user of a registration, for Register and the
+// admin tool alike.
+func createAccount(ctx context.Context, userRepo repositories.UserRepository, req dto.RegisterRequest) (*entities.User, error) {
+	email := normalizeEmail(req.Email)
+	exists, err := userRepo.ExistsByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, errors.New("email already exists")
+		return nil, ErrEmailExists
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
@@ -39,14 +102,43 @@ func (s *AuthService) Register(ctx context.Context, req dto.RegisterRequest) (*d
 	}
 
 	user := &entities.User{
-		Email:    req.Email,
+		Email:    email,
 		Password: string(hashedPassword),
 		Name:     req.Name,
 	}
+	if err := userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *AuthService) Login(ctx context.Context, req dto.LoginRequest) (*dto.AuthResponse, error) {
+	ctx, span := startSpan(ctx, "AuthService.Login", "")
+	defer span.End()
 
-	if err := s.userRepo.Create(ctx, user); err != nil {
+	email := normalizeEmail(req.Email)
+
+	failures, err := s.attemptRepo.FindFailuresSince(ctx, email, time.Now().Add(-s.policy.FailureWindow))
+	if err != nil {
 		return nil, err
 	}
+	if retryAfter := s.lockedFor(failures); retryAfter > 0 {
+		s.publishFailure(ctx, email, req.ClientIP, events.LoginFailureLocked)
+		return nil, &AccountLockedError{RetryAfter: retryAfter}
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil || user == nil || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+		return nil, s.loginFailed(ctx, email, req.ClientIP, len(failures)+1)
+	}
+	// Checked after the password so the state of an account is not revealed
+	if user.DisabledAt != nil {
+		s.publishFailure(ctx, email, req.ClientIP, events.LoginFailureDisabled)
+		return nil, ErrAccountDisabled
+	}
+
+	s.recordAttempt(ctx, email, req.ClientIP, true)
+	s.publish(ctx, events.UserLoggedIn{UserID: user.ID, Email: user.Email, ClientIP: req.ClientIP, OccurredAt: time.Now()})
 
 	token, err := s.jwtMgr.GenerateToken(user.ID)
 	if err != nil {
@@ -63,27 +155,70 @@ func (s *AuthService) Register(ctx context.Context, req dto.RegisterRequest) (*d
 	return response, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, req dto.LoginRequest) (*dto.AuthResponse, error) {
-	user, err := s.userRepo.FindByEmail(ctx, req.Email)
-	if err != nil {
-		return nil, errors.New("invalid credentials")
+// normalizeEmail is the form emails are stored, looked up and rate limited
+// in, so the letter case a user types does not matter.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// loginFailed records the failure, applies the progressive delay and reports
+// whether this failure locked the account.
+func (s *AuthService) loginFailed(ctx context.Context, email, clientIP string, failures int) error {
+	s.recordAttempt(ctx, email, clientIP, false)
+
+	if delay := s.loginDelay(failures); delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, errors.New("invalid credentials")
+	if s.policy.MaxFailedAttempts > 0 && failures >= s.policy.MaxFailedAttempts {
+		s.publishFailure(ctx, email, clientIP, events.LoginFailureLocked)
+		return &AccountLockedError{RetryAfter: s.policy.LockoutDuration}
 	}
+	s.publishFailure(ctx, email, clientIP, events.LoginFailureInvalidCredentials)
+	return ErrInvalidCredentials
+}
 
-	token, err := s.jwtMgr.GenerateToken(user.ID)
-	if err != nil {
-		return nil, err
+// loginDelay is the wait before answering the given consecutive failure:
+// DelayBase doubling with every failure, capped at DelayMax.
+func (s *AuthService) loginDelay(failures int) time.Duration {
+	delay := s.policy.DelayBase
+	for i := 1; i < failures && delay < s.policy.DelayMax; i++ {
+		delay *= 2
 	}
+	return min(delay, s.policy.DelayMax)
+}
 
-	response := &dto.AuthResponse{
-		Token: token,
+func (s *AuthService) lockedFor(failures []*entities.LoginAttempt) time.Duration {
+	if s.policy.MaxFailedAttempts <= 0 || len(failures) < s.policy.MaxFailedAttempts {
+		return 0
 	}
-	response.User.ID = user.ID
-	response.User.Email = user.Email
-	response.User.Name = user.Name
 
-	return response, nil
-}
\ No newline at end of file
+	lockedUntil := failures[len(failures)-1].CreatedAt.Add(s.policy.LockoutDuration)
+	return time.Until(lockedUntil)
+}
+
+func (s *AuthService) recordAttempt(ctx context.Context, email, clientIP string, success bool) {
+	attempt := &entities.LoginAttempt{
+		Email:     email,
+		IPAddress: clientIP,
+		Success:   success,
+	}
+	if err := s.attemptRepo.Record(ctx, attempt); err != nil {
+		logger.Error(ctx, "failed to record login attempt", "email", email, "error", err)
+	}
+}
+
+// publish reports a subscriber failure without failing the request; the
+// user has already been created or authenticated by then.
+func (s *AuthService) publish(ctx context.Context, event events.Event) {
+	if err := s.events.Publish(ctx, event); err != nil {
+		logger.Error(ctx, "failed to publish event", "event", event.EventName(), "error", err)
+	}
+}
+
+func (s *AuthService) publishFailure(ctx context.Context, email, clientIP, reason string) {
+	s.publish(ctx, events.LoginFailed{Email: email, ClientIP: clientIP, Reason: reason, OccurredAt: time.Now()})
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/events"
+	"expense-tracker/internal/infrastructure/jwt"
+	"expense-tracker/internal/infrastructure/repositories/memory"
+)
+
+const testPassword = "secret12"
+
+func newTestAuthService(t *testing.T, policy LoginPolicy) (*AuthService, *memory.Store) {
+	t.Helper()
+	store := memory.NewStore()
+	bus := events.NewBus(memory.NewOutboxRepository(store))
+	service := NewAuthService(memory.NewUserRepository(store), memory.NewLoginAttemptRepository(store), jwt.NewJWTManager("test-secret", time.Hour), policy, bus)
+	return service, store
+}
+
+func register(t *testing.T, service *AuthService, email string) *dto.AuthResponse {
+	t.Helper()
+	response, err := service.Register(context.Background(), dto.RegisterRequest{Email: email, Password: testPassword, Name: "Test"})
+	if err != nil {
+		t.Fatalf("Register(%q): %v", email, err)
+	}
+	return response
+}
+
+func TestLoginDelay(t *testing.T) {
+	service, _ := newTestAuthService(t, LoginPolicy{DelayBase: 100 * time.Millisecond, DelayMax: time.Second})
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{40, time.Second},
+		{100, time.Second}, // the shift overflows
+	}
+	for _, tt := range tests {
+		if got := service.loginDelay(tt.failures); got != tt.want {
+			t.Errorf("loginDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+
+	service, _ = newTestAuthService(t, LoginPolicy{})
+	if got := service.loginDelay(3); got != 0 {
+		t.Errorf("loginDelay without a policy = %v, want 0", got)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	type attempt struct {
+		email    string
+		password string
+		want     string // ok, invalid, locked or disabled
+	}
+	const email = "jane@example.com"
+	const wrong = "wrong-password"
+
+	tests := []struct {
+		name     string
+		policy   LoginPolicy
+		disabled bool
+		attempts []attempt
+	}{
+		{
+			name:   "locks after the maximum failures",
+			policy: LoginPolicy{MaxFailedAttempts: 3, FailureWindow: time.Hour, LockoutDuration: time.Hour},
+			attempts: []attempt{
+				{email, wrong, "invalid"},
+				{email, wrong, "invalid"},
+				{email, wrong, "locked"},
+				{email, testPassword, "locked"},
+			},
+		},
+		{
+			name:   "counts failures whatever the letter case",
+			policy: LoginPolicy{MaxFailedAttempts: 2, FailureWindow: time.Hour, LockoutDuration: time.Hour},
+			attempts: []attempt{
+				{"JANE@example.com", wrong, "invalid"},
+				{" Jane@Example.com ", wrong, "locked"},
+				{email, testPassword, "locked"},
+			},
+		},
+		{
+			name:   "success resets the failures",
+			policy: LoginPolicy{MaxFailedAttempts: 3, FailureWindow: time.Hour, LockoutDuration: time.Hour},
+			attempts: []attempt{
+				{email, wrong, "invalid"},
+				{email, wrong, "invalid"},
+				{email, testPassword, "ok"},
+				{email, wrong, "invalid"},
+				{email, wrong, "invalid"},
+			},
+		},
+		{
+			name:   "unknown emails are locked too",
+			policy: LoginPolicy{MaxFailedAttempts: 2, FailureWindow: time.Hour, LockoutDuration: time.Hour},
+			attempts: []attempt{
+				{"nobody@example.com", testPassword, "invalid"},
+				{"nobody@example.com", testPassword, "locked"},
+				{email, testPassword, "ok"},
+			},
+		},
+		{
+			name:   "lockout ends after its duration",
+			policy: LoginPolicy{MaxFailedAttempts: 2, FailureWindow: time.Hour, LockoutDuration: time.Nanosecond},
+			attempts: []attempt{
+				{email, wrong, "invalid"},
+				{email, wrong, "locked"},
+				{email, testPassword, "ok"},
+			},
+		},
+		{
+			name:   "zero maximum disables lockout",
+			policy: LoginPolicy{FailureWindow: time.Hour, LockoutDuration: time.Hour},
+			attempts: []attempt{
+				{email, wrong, "invalid"},
+				{email, wrong, "invalid"},
+				{email, wrong, "invalid"},
+				{email, testPassword, "ok"},
+			},
+		},
+		{
+			name:     "disabled accounts are reported after the password only",
+			policy:   LoginPolicy{MaxFailedAttempts: 3, FailureWindow: time.Hour, LockoutDuration: time.Hour},
+			disabled: true,
+			attempts: []attempt{
+				{email, wrong, "invalid"},
+				{email, testPassword, "disabled"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service, _ := newTestAuthService(t, tt.policy)
+			registered := register(t, service, email)
+			if tt.disabled {
+				user, _ := service.userRepo.FindByID(ctx, registered.User.ID)
+				now := time.Now()
+				user.DisabledAt = &now
+				if err := service.userRepo.Update(ctx, user); err != nil {
+					t.Fatalf("Update: %v", err)
+				}
+			}
+
+			for i, a := range tt.attempts {
+				_, err := service.Login(ctx, dto.LoginRequest{Email: a.email, Password: a.password, ClientIP: "192.0.2.1"})
+				if got := loginResult(err); got != a.want {
+					t.Fatalf("attempt %d (%s): got %s (%v), want %s", i+1, a.email, got, err, a.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLockedErrorCarriesRetryAfter(t *testing.T) {
+	service, _ := newTestAuthService(t, LoginPolicy{MaxFailedAttempts: 1, FailureWindow: time.Hour, LockoutDuration: time.Minute})
+	register(t, service, "jane@example.com")
+
+	for i := 0; i < 2; i++ {
+		_, err := service.Login(context.Background(), dto.LoginRequest{Email: "jane@example.com", Password: "wrong"})
+		var locked *AccountLockedError
+		if !errors.As(err, &locked) {
+			t.Fatalf("attempt %d: got %v, want AccountLockedError", i+1, err)
+		}
+		if locked.RetryAfter <= 0 || locked.RetryAfter > time.Minute {
+			t.Fatalf("attempt %d: RetryAfter = %v, want within the lockout duration", i+1, locked.RetryAfter)
+		}
+	}
+}
+
+func loginResult(err error) string {
+	var locked *AccountLockedError
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrInvalidCredentials):
+		return "invalid"
+	case errors.As(err, &locked):
+		return "locked"
+	case errors.Is(err, ErrAccountDisabled):
+		return "disabled"
+	}
+	return "error"
+}
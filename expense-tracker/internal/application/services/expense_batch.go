@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"expense-tracker/internal/application/dto"
+)
+
+var errBatchAborted = errors.New("not applied because another operation failed")
+
+// BatchOperation is one decoded and validated operation of a batch. Invalid
+// holds the decoding or validation error; such operations always fail.
+type BatchOperation struct {
+	Op      string
+	ID      string
+	Version int
+	Create  *dto.CreateExpenseRequest
+	Update  *dto.UpdateExpenseRequest
+	Invalid error
+}
+
+// Batch runs the operations in one transaction. In atomic mode the first
+// failure rolls everything back and the remaining operations are skipped.
+// In best-effort mode each operation runs in its own savepoint, so a
+// failure only undoes that operation.
+func (s *ExpenseService) Batch(ctx context.Context, userID string, atomic bool, ops []BatchOperation) ([]dto.BatchResult, error) {
+	ctx, span := startSpan(ctx, "ExpenseService.Batch", userID)
+	defer span.End()
+
+	var results []dto.BatchResult
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Rebuilt on every attempt in case the transaction is retried
+		results = make([]dto.BatchResult, len(ops))
+		for i, op := range ops {
+			results[i] = s.runBatchOperation(ctx, userID, i, op)
+			if atomic && results[i].Error != "" {
+				return errBatchAborted
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBatchAborted) {
+		return nil, err
+	}
+
+	if err != nil {
+		for i := range results {
+			if results[i].Status == 0 || results[i].Error == "" {
+				results[i] = dto.BatchResult{
+					Index:  i,
+					Op:     ops[i].Op,
+					ID:     ops[i].ID,
+					Status: http.StatusFailedDependency,
+					Error:  errBatchAborted.Error(),
+				}
+			}
+		}
+	}
+	return results, nil
+}
+
+func (s *ExpenseService) runBatchOperation(ctx context.Context, userID string, index int, op BatchOperation) dto.BatchResult {
+	result := dto.BatchResult{Index: index, Op: op.Op, ID: op.ID}
+	if op.Invalid != nil {
+		result.Status = http.StatusBadRequest
+		result.Error = op.Invalid.Error()
+		return result
+	}
+
+	var err error
+	switch op.Op {
+	case "create":
+		result.Expense, err = s.CreateExpense(ctx, userID, *op.Create)
+		result.Status = http.StatusCreated
+	case "update":
+		result.Expense, err = s.UpdateExpense(ctx, userID, op.ID, *op.Update, op.Version)
+		result.Status = http.StatusOK
+	case "delete":
+		err = s.DeleteExpense(ctx, userID, op.ID, op.Version)
+		result.Status = http.StatusNoContent
+	}
+
+	if err != nil {
+		result.Expense = nil
+		result.Status = batchErrorStatus(err)
+		result.Error = err.Error()
+	} else if result.Expense != nil {
+		result.ID = result.Expense.ID
+	}
+	return result
+}
+
+func batchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidCategory), errors.Is(err, ErrInvalidDate):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNotExpenseOwner):
+		return http.StatusForbidden
+	case errors.Is(err, ErrExpenseNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"testing"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/events"
+	"expense-tracker/internal/domain/repositories"
+	"expense-tracker/internal/infrastructure/repositories/memory"
+)
+
+const testUserID = "user-1"
+
+func newTestExpenseService(t *testing.T) (*ExpenseService, repositories.ExpenseRepository, *events.Bus) {
+	t.Helper()
+	store := memory.NewStore()
+	bus := events.NewBus(memory.NewOutboxRepository(store))
+	expenses := memory.NewExpenseRepository(store)
+	return NewExpenseService(bus.Transactional(memory.NewTxManager(store)), expenses, memory.NewExpenseRevisionRepository(store), bus), expenses, bus
+}
+
+func createTestExpense(t *testing.T, service *ExpenseService, description string) *dto.ExpenseResponse {
+	t.Helper()
+	expense, err := service.CreateExpense(context.Background(), testUserID, dto.CreateExpenseRequest{
+		Amount: 10, Category: "groceries", Description: description, Date: "2026-01-15",
+	})
+	if err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	return expense
+}
+
+// descriptions lists the user's live expenses, for comparing what a batch
+// left behind.
+func descriptions(t *testing.T, repo repositories.ExpenseRepository) []string {
+	t.Helper()
+	expenses, err := repo.FindByUserID(context.Background(), testUserID, repositories.ExpenseFilter{})
+	if err != nil {
+		t.Fatalf("FindByUserID: %v", err)
+	}
+	var names []string
+	for _, e := range expenses {
+		names = append(names, e.Description)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestBatch(t *testing.T) {
+	newDescription := "renamed"
+	create := func(description string) BatchOperation {
+		return BatchOperation{Op: "create", Create: &dto.CreateExpenseRequest{Amount: 5, Category: "leisure", Description: description, Date: "2026-02-01"}}
+	}
+	rename := func(version int) BatchOperation {
+		return BatchOperation{Op: "update", Update: &dto.UpdateExpenseRequest{Description: &newDescription}, Version: version}
+	}
+	remove := func(version int) BatchOperation {
+		return BatchOperation{Op: "delete", Version: version}
+	}
+
+	tests := []struct {
+		name         string
+		atomic       bool
+		ops          []BatchOperation // update and delete target the existing expense unless ID is set
+		wantStatuses []int
+		wantLeft     []string
+	}{
+		{
+			name:         "atomic success",
+			atomic:       true,
+			ops:          []BatchOperation{create("a"), rename(1), create("b")},
+			wantStatuses: []int{201, 200, 201},
+			wantLeft:     []string{"a", "b", "renamed"},
+		},
+		{
+			name:         "atomic failure rolls back everything",
+			atomic:       true,
+			ops:          []BatchOperation{create("a"), rename(2), create("b")},
+			wantStatuses: []int{424, 412, 424},
+			wantLeft:     []string{"existing"},
+		},
+		{
+			name:         "atomic invalid operation",
+			atomic:       true,
+			ops:          []BatchOperation{create("a"), {Op: "create", Invalid: errors.New("amount is required")}},
+			wantStatuses: []int{424, 400},
+			wantLeft:     []string{"existing"},
+		},
+		{
+			name:         "best effort keeps the successes",
+			ops:          []BatchOperation{create("a"), rename(2), create("b")},
+			wantStatuses: []int{201, 412, 201},
+			wantLeft:     []string{"a", "b", "existing"},
+		},
+		{
+			name:         "best effort undoes only the failed operation",
+			ops:          []BatchOperation{rename(1), remove(1), {Op: "delete", ID: "missing"}},
+			wantStatuses: []int{200, 412, 404},
+			wantLeft:     []string{"renamed"},
+		},
+		{
+			name:         "best effort sees earlier operations",
+			ops:          []BatchOperation{rename(1), remove(2)},
+			wantStatuses: []int{200, 204},
+			wantLeft:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo, _ := newTestExpenseService(t)
+			existing := createTestExpense(t, service, "existing")
+			for i := range tt.ops {
+				if tt.ops[i].Op != "create" && tt.ops[i].ID == "" {
+					tt.ops[i].ID = existing.ID
+				}
+			}
+
+			results, err := service.Batch(context.Background(), testUserID, tt.atomic, tt.ops)
+			if err != nil {
+				t.Fatalf("Batch: %v", err)
+			}
+
+			var statuses []int
+			for i, result := range results {
+				statuses = append(statuses, result.Status)
+				if result.Index != i {
+					t.Errorf("result %d has index %d", i, result.Index)
+				}
+				failed := result.Status >= http.StatusBadRequest
+				if failed != (result.Error != "") || (failed && result.Expense != nil) {
+					t.Errorf("result %d: status %d with error %q and expense %v", i, result.Status, result.Error, result.Expense)
+				}
+			}
+			if !slices.Equal(statuses, tt.wantStatuses) {
+				t.Fatalf("statuses = %v, want %v", statuses, tt.wantStatuses)
+			}
+			if got := descriptions(t, repo); !slices.Equal(got, tt.wantLeft) {
+				t.Fatalf("expenses left = %q, want %q", got, tt.wantLeft)
+			}
+		})
+	}
+}
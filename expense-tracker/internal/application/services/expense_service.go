@@ -4,29 +4,51 @@ import (
 	"context"
 	"errors"
 	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/events"
 	"expense-tracker/internal/domain/entities"
 	"expense-tracker/internal/domain/repositories"
 	"expense-tracker/internal/domain/valueobjects"
+	"sort"
 	"time"
 )
 
+var (
+	ErrExpenseNotFound  = errors.New("expense not found")
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrVersionMismatch  = errors.New("expense has been modified; fetch it again and retry")
+	ErrNotExpenseOwner  = errors.New("unauthorized")
+	ErrInvalidCategory  = errors.New("invalid category")
+	ErrInvalidDate      = errors.New("invalid date format")
+)
+
 type ExpenseService struct {
-	expenseRepo repositories.ExpenseRepository
+	txManager    repositories.TxManager
+	expenseRepo  repositories.ExpenseRepository
+	revisionRepo repositories.ExpenseRevisionRepository
+	events       *events.Bus
 }
 
-func NewExpenseService(expenseRepo repositories.ExpenseRepository) *ExpenseService {
-	return &ExpenseService{expenseRepo: expenseRepo}
+func NewExpenseService(txManager repositories.TxManager, expenseRepo repositories.ExpenseRepository, revisionRepo repositories.ExpenseRevisionRepository, bus *events.Bus) *ExpenseService {
+	return &ExpenseService{
+		txManager:    txManager,
+		expenseRepo:  expenseRepo,
+		revisionRepo: revisionRepo,
+		events:       bus,
+	}
 }
 
 func (s *ExpenseService) CreateExpense(ctx context.Context, userID string, req dto.CreateExpenseRequest) (*dto.ExpenseResponse, error) {
+	ctx, span := startSpan(ctx, "ExpenseService.CreateExpense", userID)
+	defer span.End()
+
 	category := valueobjects.Category(req.Category)
 	if !category.IsValid() {
-		return nil, errors.New("invalid category")
+		return nil, ErrInvalidCategory
 	}
 
 	date, err := time.Parse("2006-01-02", req.Date)
 	if err != nil {
-		return nil, errors.New("invalid date format")
+		return nil, ErrInvalidDate
 	}
 
 	expense := &entities.Expense{
@@ -37,14 +59,23 @@ func (s *ExpenseService) CreateExpense(ctx context.Context, userID string, req d
 		Date:        date,
 	}
 
-	if err := s.expenseRepo.Create(ctx, expense); err != nil {
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.expenseRepo.Create(ctx, expense); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, userID, expense, entities.ExpenseCreated, initialChanges(entities.ValuesOf(expense)), nil)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return s.toResponse(expense), nil
+	return toExpenseResponse(expense), nil
 }
 
 func (s *ExpenseService) GetExpenses(ctx context.Context, userID string, filter dto.FilterParams) ([]*dto.ExpenseResponse, error) {
+	ctx, span := startSpan(ctx, "ExpenseService.GetExpenses", userID)
+	defer span.End()
+
 	expenseFilter := repositories.ExpenseFilter{
 		UserID: userID,
 	}
@@ -79,71 +110,425 @@ func (s *ExpenseService) GetExpenses(ctx context.Context, userID string, filter
 		}
 	}
 
-	expenses, err := s.expenseRepo.FindByUserID(ctx, userID, expenseFilter)
+	return s.FindExpenses(ctx, userID, expenseFilter)
+}
+
+// FindExpenses lists the user's expenses matching filter. The filter is
+// always limited to the user, whatever its UserID says.
+func (s *ExpenseService) FindExpenses(ctx context.Context, userID string, filter repositories.ExpenseFilter) ([]*dto.ExpenseResponse, error) {
+	ctx, span := startSpan(ctx, "ExpenseService.FindExpenses", userID)
+	defer span.End()
+
+	filter.UserID = userID
+	expenses, err := s.expenseRepo.FindByUserID(ctx, userID, filter)
 	if err != nil {
 		return nil, err
 	}
 
 	responses := make([]*dto.ExpenseResponse, len(expenses))
 	for i, expense := range expenses {
-		responses[i] = s.toResponse(expense)
+		responses[i] = toExpenseResponse(expense)
 	}
 
 	return responses, nil
 }
 
-func (s *ExpenseService) UpdateExpense(ctx context.Context, userID, expenseID string, req dto.UpdateExpenseRequest) (*dto.ExpenseResponse, error) {
-	expense, err := s.expenseRepo.FindByID(ctx, expenseID)
+// GetCategoryTotals sums the user's expenses per category between the two
+// dates, inclusive, ordered by category.
+func (s *ExpenseService) GetCategoryTotals(ctx context.Context, userID string, startDate, endDate time.Time) ([]*dto.CategoryTotalResponse, error) {
+	ctx, span := startSpan(ctx, "ExpenseService.GetCategoryTotals", userID)
+	defer span.End()
+
+	totals, err := s.expenseRepo.GetTotalByCategory(ctx, userID, startDate, endDate)
 	if err != nil {
-		return nil, errors.New("expense not found")
+		return nil, err
 	}
 
-	if expense.UserID != userID {
-		return nil, errors.New("unauthorized")
+	responses := make([]*dto.CategoryTotalResponse, 0, len(totals))
+	for category, total := range totals {
+		responses = append(responses, &dto.CategoryTotalResponse{Category: category, Total: total})
 	}
+	sort.Slice(responses, func(i, j int) bool { return responses[i].Category < responses[j].Category })
+	return responses, nil
+}
+
+func (s *ExpenseService) GetExpense(ctx context.Context, userID, expenseID string) (*dto.ExpenseResponse, error) {
+	ctx, span := startSpan(ctx, "ExpenseService.GetExpense", userID)
+	defer span.End()
 
-	if req.Amount != nil {
-		expense.Amount = *req.Amount
+	expense, err := s.expenseRepo.FindByID(ctx, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	if expense == nil || expense.UserID != userID {
+		return nil, ErrExpenseNotFound
 	}
-	if req.Category != nil {
-		category := valueobjects.Category(*req.Category)
-		if !category.IsValid() {
-			return nil, errors.New("invalid category")
+
+	return toExpenseResponse(expense), nil
+}
+
+// UpdateExpense applies the changes in req. A non-zero expectedVersion makes
+// the update conditional: it fails with ErrVersionMismatch unless the
+// expense is still at that version.
+func (s *ExpenseService) UpdateExpense(ctx context.Context, userID, expenseID string, req dto.UpdateExpenseRequest, expectedVersion int) (*dto.ExpenseResponse, error) {
+	ctx, span := startSpan(ctx, "ExpenseService.UpdateExpense", userID)
+	defer span.End()
+
+	var expense *entities.Expense
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		expense, err = s.expenseRepo.FindByID(ctx, expenseID)
+		if err != nil {
+			return err
+		}
+		if expense == nil {
+			return ErrExpenseNotFound
+		}
+
+		if expense.UserID != userID {
+			return ErrNotExpenseOwner
+		}
+		if expectedVersion != 0 && expense.Version != expectedVersion {
+			return ErrVersionMismatch
+		}
+
+		before := entities.ValuesOf(expense)
+		if req.Amount != nil {
+			expense.Amount = *req.Amount
+		}
+		if req.Category != nil {
+			category := valueobjects.Category(*req.Category)
+			if !category.IsValid() {
+				return ErrInvalidCategory
+			}
+			expense.Category = category
+		}
+		if req.Description != nil {
+			expense.Description = *req.Description
+		}
+		if req.Date != nil {
+			date, err := time.Parse("2006-01-02", *req.Date)
+			if err != nil {
+				return ErrInvalidDate
+			}
+			expense.Date = date
 		}
-		expense.Category = category
+
+		if err := s.updateExpense(ctx, expense); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, userID, expense, entities.ExpenseUpdated, before.Diff(entities.ValuesOf(expense)), nil)
+	})
+	if err != nil {
+		return nil, err
 	}
-	if req.Description != nil {
-		expense.Description = *req.Description
+
+	return toExpenseResponse(expense), nil
+}
+
+// DeleteExpense moves the expense to the trash. expectedVersion works as in
+// UpdateExpense.
+func (s *ExpenseService) DeleteExpense(ctx context.Context, userID, expenseID string, expectedVersion int) error {
+	ctx, span := startSpan(ctx, "ExpenseService.DeleteExpense", userID)
+	defer span.End()
+
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		expense, err := s.expenseRepo.FindByID(ctx, expenseID)
+		if err != nil {
+			return err
+		}
+		if expense == nil {
+			return ErrExpenseNotFound
+		}
+
+		if expense.UserID != userID {
+			return ErrNotExpenseOwner
+		}
+		if expectedVersion != 0 && expense.Version != expectedVersion {
+			return ErrVersionMismatch
+		}
+
+		if err := s.expenseRepo.Delete(ctx, expense); err != nil {
+			return versionError(err)
+		}
+		changes := []entities.FieldChange{{Field: "deleted_at", Old: nil, New: *expense.DeletedAt}}
+		return s.recordRevision(ctx, userID, expense, entities.ExpenseDeleted, changes, nil)
+	})
+}
+
+func (s *ExpenseService) GetTrash(ctx context.Context, userID string) ([]*dto.ExpenseResponse, error) {
+	ctx, span := startSpan(ctx, "ExpenseService.GetTrash", userID)
+	defer span.End()
+
+	expenses, err := s.expenseRepo.FindTrashed(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
-	if req.Date != nil {
-		date, err := time.Parse("2006-01-02", *req.Date)
+
+	responses := make([]*dto.ExpenseResponse, len(expenses))
+	for i, expense := range expenses {
+		responses[i] = toExpenseResponse(expense)
+	}
+
+	return responses, nil
+}
+
+func (s *ExpenseService) RestoreExpense(ctx context.Context, userID, expenseID string) (*dto.ExpenseResponse, error) {
+	ctx, span := startSpan(ctx, "ExpenseService.RestoreExpense", userID)
+	defer span.End()
+
+	var expense *entities.Expense
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		expense, err = s.expenseRepo.FindTrashedByID(ctx, expenseID)
 		if err != nil {
-			return nil, errors.New("invalid date format")
+			return err
+		}
+		if expense == nil || expense.UserID != userID {
+			return ErrExpenseNotFound
 		}
-		expense.Date = date
+
+		changes := []entities.FieldChange{{Field: "deleted_at", Old: expense.DeletedAt, New: nil}}
+		if err := s.expenseRepo.Restore(ctx, expense); err != nil {
+			return versionError(err)
+		}
+		return s.recordRevision(ctx, userID, expense, entities.ExpenseRestored, changes, nil)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.expenseRepo.Update(ctx, expense); err != nil {
+	return toExpenseResponse(expense), nil
+}
+
+// GetHistory lists every revision of an expense, oldest first. The history
+// of trashed expenses stays available until they are purged.
+func (s *ExpenseService) GetHistory(ctx context.Context, userID, expenseID string) ([]*dto.ExpenseRevisionResponse, error) {
+	ctx, span := startSpan(ctx, "ExpenseService.GetHistory", userID)
+	defer span.End()
+
+	if _, err := s.findOwned(ctx, userID, expenseID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.revisionRepo.FindByExpenseID(ctx, expenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.ExpenseRevisionResponse, len(revisions))
+	for i, revision := range revisions {
+		responses[i] = toRevisionResponse(revision)
+	}
+	return responses, nil
+}
+
+// GetHistories loads the history of several of the user's expenses in one
+// query, keyed by expense ID. IDs of other users' expenses get no history.
+func (s *ExpenseService) GetHistories(ctx context.Context, userID string, expenseIDs []string) (map[string][]*dto.ExpenseRevisionResponse, error) {
+	ctx, span := startSpan(ctx, "ExpenseService.GetHistories", userID)
+	defer span.End()
+
+	revisions, err := s.revisionRepo.FindByExpenseIDs(ctx, userID, expenseIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	histories := make(map[string][]*dto.ExpenseRevisionResponse, len(expenseIDs))
+	for _, revision := range revisions {
+		histories[revision.ExpenseID] = append(histories[revision.ExpenseID], toRevisionResponse(revision))
+	}
+	return histories, nil
+}
+
+// RevertExpense sets the expense fields back to the values they had after
+// the given revision. The revert itself is recorded as a new revision.
+func (s *ExpenseService) RevertExpense(ctx context.Context, userID, expenseID string, revision int) (*dto.ExpenseResponse, error) {
+	ctx, span := startSpan(ctx, "ExpenseService.RevertExpense", userID)
+	defer span.End()
+
+	var expense *entities.Expense
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		expense, err = s.expenseRepo.FindByID(ctx, expenseID)
+		if err != nil {
+			return err
+		}
+		if expense == nil || expense.UserID != userID {
+			return ErrExpenseNotFound
+		}
+
+		target, err := s.revisionRepo.FindByRevision(ctx, expenseID, revision)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			return ErrRevisionNotFound
+		}
+
+		before := entities.ValuesOf(expense)
+		expense.Amount = target.Values.Amount
+		expense.Category = target.Values.Category
+		expense.Description = target.Values.Description
+		expense.Date = target.Values.Date
+
+		changes := before.Diff(entities.ValuesOf(expense))
+		if len(changes) == 0 {
+			return nil
+		}
+		if err := s.updateExpense(ctx, expense); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, userID, expense, entities.ExpenseReverted, changes, &target.Revision)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return s.toResponse(expense), nil
+	return toExpenseResponse(expense), nil
 }
 
-func (s *ExpenseService) DeleteExpense(ctx context.Context, userID, expenseID string) error {
+// PermanentlyDeleteExpense removes an expense for good, whether it is in
+// the trash or not.
+func (s *ExpenseService) PermanentlyDeleteExpense(ctx context.Context, userID, expenseID string) error {
+	ctx, span := startSpan(ctx, "ExpenseService.PermanentlyDeleteExpense", userID)
+	defer span.End()
+
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		expense, err := s.findOwned(ctx, userID, expenseID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.revisionRepo.DeleteByExpenseID(ctx, expenseID); err != nil {
+			return err
+		}
+		if err := s.expenseRepo.PermanentDelete(ctx, expenseID); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, events.ExpenseDeleted{
+			ActorID:    userID,
+			Expense:    *expense,
+			Permanent:  true,
+			OccurredAt: time.Now(),
+		})
+	})
+}
+
+// PurgeTrash permanently removes expenses that have been in the trash
+// longer than the retention period.
+func (s *ExpenseService) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, span := startSpan(ctx, "ExpenseService.PurgeTrash", "")
+	defer span.End()
+
+	before := time.Now().Add(-retention)
+	var purged int64
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.revisionRepo.DeleteTrashedBefore(ctx, before); err != nil {
+			return err
+		}
+		var err error
+		purged, err = s.expenseRepo.PurgeDeletedBefore(ctx, before)
+		return err
+	})
+	return purged, err
+}
+
+// updateExpense saves the expense, reporting a concurrent write as a
+// version mismatch.
+func (s *ExpenseService) updateExpense(ctx context.Context, expense *entities.Expense) error {
+	return versionError(s.expenseRepo.Update(ctx, expense))
+}
+
+// versionError reports the repository's ErrStaleVersion as a version
+// mismatch.
+func versionError(err error) error {
+	if errors.Is(err, repositories.ErrStaleVersion) {
+		return ErrVersionMismatch
+	}
+	return err
+}
+
+// findOwned returns the user's expense whether it is in the trash or not.
+func (s *ExpenseService) findOwned(ctx context.Context, userID, expenseID string) (*entities.Expense, error) {
 	expense, err := s.expenseRepo.FindByID(ctx, expenseID)
 	if err != nil {
-		return errors.New("expense not found")
+		return nil, err
 	}
+	if expense == nil {
+		if expense, err = s.expenseRepo.FindTrashedByID(ctx, expenseID); err != nil {
+			return nil, err
+		}
+	}
+	if expense == nil || expense.UserID != userID {
+		return nil, ErrExpenseNotFound
+	}
+	return expense, nil
+}
 
-	if expense.UserID != userID {
-		return errors.New("unauthorized")
+// recordRevision appends a revision for a change made by actorID and
+// publishes the matching event. Updates that change nothing are not recorded.
+func (s *ExpenseService) recordRevision(ctx context.Context, actorID string, expense *entities.Expense, action entities.ExpenseRevisionAction, changes []entities.FieldChange, revertedTo *int) error {
+	if action == entities.ExpenseUpdated && len(changes) == 0 {
+		return nil
 	}
 
-	return s.expenseRepo.Delete(ctx, expenseID)
+	err := s.revisionRepo.Create(ctx, &entities.ExpenseRevision{
+		ExpenseID:  expense.ID,
+		UserID:     expense.UserID,
+		ActorID:    actorID,
+		Action:     action,
+		Changes:    changes,
+		Values:     entities.ValuesOf(expense),
+		RevertedTo: revertedTo,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	switch action {
+	case entities.ExpenseCreated:
+		return s.events.Publish(ctx, events.ExpenseCreated{ActorID: actorID, Expense: *expense, OccurredAt: now})
+	case entities.ExpenseDeleted:
+		return s.events.Publish(ctx, events.ExpenseDeleted{ActorID: actorID, Expense: *expense, OccurredAt: now})
+	default:
+		return s.events.Publish(ctx, events.ExpenseUpdated{
+			ActorID:    actorID,
+			Expense:    *expense,
+			Action:     action,
+			Changes:    changes,
+			OccurredAt: now,
+		})
+	}
 }
 
-func (s *ExpenseService) toResponse(expense *entities.Expense) *dto.ExpenseResponse {
+func initialChanges(values entities.ExpenseValues) []entities.FieldChange {
+	return []entities.FieldChange{
+		{Field: "amount", Old: nil, New: values.Amount},
+		{Field: "category", Old: nil, New: string(values.Category)},
+		{Field: "description", Old: nil, New: values.Description},
+		{Field: "date", Old: nil, New: values.Date.Format("2006-01-02")},
+	}
+}
+
+func toRevisionResponse(revision *entities.ExpenseRevision) *dto.ExpenseRevisionResponse {
+	changes := make([]dto.FieldChange, len(revision.Changes))
+	for i, change := range revision.Changes {
+		changes[i] = dto.FieldChange{Field: change.Field, Old: change.Old, New: change.New}
+	}
+
+	return &dto.ExpenseRevisionResponse{
+		Revision:   revision.Revision,
+		Action:     string(revision.Action),
+		ActorID:    revision.ActorID,
+		Changes:    changes,
+		RevertedTo: revision.RevertedTo,
+		CreatedAt:  revision.CreatedAt,
+	}
+}
+
+func toExpenseResponse(expense *entities.Expense) *dto.ExpenseResponse {
 	return &dto.ExpenseResponse{
 		ID:          expense.ID,
 		Amount:      expense.Amount,
@@ -152,5 +537,7 @@ func (s *ExpenseService) toResponse(expense *entities.Expense) *dto.ExpenseRespo
 		Date:        expense.Date,
 		CreatedAt:   expense.CreatedAt,
 		UpdatedAt:   expense.UpdatedAt,
+		DeletedAt:   expense.DeletedAt,
+		Version:     expense.Version,
 	}
-}
\ No newline at end of file
+}
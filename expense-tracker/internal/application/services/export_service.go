@@ -0,0 +1,468 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/domain/repositories"
+	"expense-tracker/internal/pkg/logger"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrExportNotFound    = errors.New("export not found")
+	ErrExportNotReady    = errors.New("export is not ready yet")
+	ErrExportLinkInvalid = errors.New("invalid or expired download link")
+)
+
+// ExportStorage holds generated archives until their download link expires.
+type ExportStorage interface {
+	Create(ctx context.Context, name string) (io.WriteCloser, error)
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	RemoveOlderThan(ctx context.Context, before time.Time) (int, error)
+}
+
+// ExportService builds a ZIP archive of all personal data stored about a
+// user, down to the webhook deliveries and the queued events about their
+// expenses. Small accounts are exported inline, larger ones in the
+// background.
+type ExportService struct {
+	userRepo         repositories.UserRepository
+	expenseRepo      repositories.ExpenseRepository
+	revisionRepo     repositories.ExpenseRevisionRepository
+	webhookRepo      repositories.WebhookEndpointRepository
+	deliveryRepo     repositories.WebhookDeliveryRepository
+	outboxRepo       repositories.OutboxRepository
+	attemptRepo      repositories.LoginAttemptRepository
+	verificationRepo repositories.EmailVerificationRepository
+	exportRepo       repositories.DataExportRepository
+	storage          ExportStorage
+	signingKey       []byte
+	linkTTL          time.Duration
+	asyncThreshold   int
+	background       sync.WaitGroup // exports generated in the background
+}
+
+func NewExportService(
+	userRepo repositories.UserRepository,
+	expenseRepo repositories.ExpenseRepository,
+	revisionRepo repositories.ExpenseRevisionRepository,
+	webhookRepo repositories.WebhookEndpointRepository,
+	deliveryRepo repositories.WebhookDeliveryRepository,
+	outboxRepo repositories.OutboxRepository,
+	attemptRepo repositories.LoginAttemptRepository,
+	verificationRepo repositories.EmailVerificationRepository,
+	exportRepo repositories.DataExportRepository,
+	storage ExportStorage,
+	signingKey string,
+	linkTTL time.Duration,
+	asyncThreshold int,
+) *ExportService {
+	return &ExportService{
+		userRepo:         userRepo,
+		expenseRepo:      expenseRepo,
+		revisionRepo:     revisionRepo,
+		webhookRepo:      webhookRepo,
+		deliveryRepo:     deliveryRepo,
+		outboxRepo:       outboxRepo,
+		attemptRepo:      attemptRepo,
+		verificationRepo: verificationRepo,
+		exportRepo:       exportRepo,
+		storage:          storage,
+		signingKey:       []byte(signingKey),
+		linkTTL:          linkTTL,
+		asyncThreshold:   asyncThreshold,
+	}
+}
+
+func (s *ExportService) RequestExport(ctx context.Context, userID string) (*dto.ExportResponse, error) {
+	expenses, err := s.expenseRepo.FindByUserID(ctx, userID, repositories.ExpenseFilter{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	export := &entities.DataExport{
+		UserID: userID,
+		Status: entities.DataExportPending,
+	}
+	if err := s.exportRepo.Create(ctx, export); err != nil {
+		return nil, err
+	}
+
+	if len(expenses) <= s.asyncThreshold {
+		s.generate(ctx, export)
+		return s.toResponse(export), nil
+	}
+
+	response := s.toResponse(export)
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		s.generate(context.Background(), export)
+	}()
+	return response, nil
+}
+
+// Wait returns once the exports generating in the background are done, or
+// with ctx's error when ctx ends first.
+func (s *ExportService) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *ExportService) GetExport(ctx context.Context, userID, exportID string) (*dto.ExportResponse, error) {
+	export, err := s.exportRepo.FindByID(ctx, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil || export.UserID != userID {
+		return nil, ErrExportNotFound
+	}
+	return s.toResponse(export), nil
+}
+
+func (s *ExportService) ListExports(ctx context.Context, userID string) ([]*dto.ExportResponse, error) {
+	exports, err := s.exportRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.ExportResponse, len(exports))
+	for i, export := range exports {
+		responses[i] = s.toResponse(export)
+	}
+	return responses, nil
+}
+
+// OpenDownload checks a signed download link and opens the archive it
+// points to.
+func (s *ExportService) OpenDownload(ctx context.Context, exportID, expires, signature string) (io.ReadCloser, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, ErrExportLinkInvalid
+	}
+	if !hmac.Equal([]byte(s.sign(exportID, expiresAt)), []byte(signature)) {
+		return nil, ErrExportLinkInvalid
+	}
+
+	export, err := s.exportRepo.FindByID(ctx, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil || export.Status != entities.DataExportCompleted {
+		return nil, ErrExportLinkInvalid
+	}
+
+	return s.storage.Open(ctx, export.FileName)
+}
+
+// PurgeExpired removes export records and archives whose link has expired.
+func (s *ExportService) PurgeExpired(ctx context.Context) error {
+	now := time.Now()
+	if _, err := s.exportRepo.DeleteExpired(ctx, now); err != nil {
+		return err
+	}
+	_, err := s.storage.RemoveOlderThan(ctx, now.Add(-s.linkTTL))
+	return err
+}
+
+func (s *ExportService) generate(ctx context.Context, export *entities.DataExport) {
+	export.Status = entities.DataExportProcessing
+	if err := s.exportRepo.Update(ctx, export); err != nil {
+		logger.Error(ctx, "failed to update export", "export_id", export.ID, "error", err)
+	}
+
+	export.FileName = export.ID + ".zip"
+	if err := s.writeArchive(ctx, export); err != nil {
+		logger.Error(ctx, "export failed", "export_id", export.ID, "error", err)
+		export.Status = entities.DataExportFailed
+		export.Error = "export could not be generated"
+	} else {
+		now := time.Now()
+		expiresAt := now.Add(s.linkTTL)
+		export.Status = entities.DataExportCompleted
+		export.CompletedAt = &now
+		export.ExpiresAt = &expiresAt
+	}
+
+	if err := s.exportRepo.Update(ctx, export); err != nil {
+		logger.Error(ctx, "failed to update export", "export_id", export.ID, "error", err)
+	}
+}
+
+func (s *ExportService) writeArchive(ctx context.Context, export *entities.DataExport) error {
+	user, err := s.userRepo.FindByID(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	expenses, err := s.expenseRepo.FindByUserID(ctx, user.ID, repositories.ExpenseFilter{UserID: user.ID})
+	if err != nil {
+		return err
+	}
+	trashed, err := s.expenseRepo.FindTrashed(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	expenses = append(expenses, trashed...)
+
+	revisions, err := s.revisionRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	webhooks, err := s.webhookRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	deliveries := []*entities.WebhookDelivery{}
+	for _, webhook := range webhooks {
+		log, err := s.deliveryRepo.FindByEndpointID(ctx, webhook.ID, 0)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, log...)
+	}
+
+	queued, err := s.outboxRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	attempts, err := s.attemptRepo.FindByEmail(ctx, strings.ToLower(user.Email), 0)
+	if err != nil {
+		return err
+	}
+
+	verification, err := s.verificationRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	verifications := []*entities.EmailVerification{}
+	if verification != nil {
+		verifications = append(verifications, verification)
+	}
+
+	file, err := s.storage.Create(ctx, export.FileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+
+	if err := writeJSON(archive, "profile.json", user); err != nil {
+		return err
+	}
+	if err := writeCSV(archive, "profile.csv",
+		[]string{"id", "email", "name", "created_at", "updated_at"},
+		[][]string{{user.ID, user.Email, user.Name, formatTime(user.CreatedAt), formatTime(user.UpdatedAt)}},
+	); err != nil {
+		return err
+	}
+
+	if err := writeJSON(archive, "expenses.json", expenses); err != nil {
+		return err
+	}
+	expenseRows := make([][]string, len(expenses))
+	for i, e := range expenses {
+		expenseRows[i] = []string{
+			e.ID, strconv.FormatFloat(e.Amount, 'f', 2, 64), string(e.Category), e.Description,
+			e.Date.Format("2006-01-02"), formatTime(e.CreatedAt), formatTime(e.UpdatedAt), formatOptionalTime(e.DeletedAt),
+		}
+	}
+	if err := writeCSV(archive, "expenses.csv",
+		[]string{"id", "amount", "category", "description", "date", "created_at", "updated_at", "deleted_at"},
+		expenseRows,
+	); err != nil {
+		return err
+	}
+
+	if err := writeJSON(archive, "expense_history.json", revisions); err != nil {
+		return err
+	}
+	revisionRows := make([][]string, len(revisions))
+	for i, r := range revisions {
+		changes, err := json.Marshal(r.Changes)
+		if err != nil {
+			return err
+		}
+		revisionRows[i] = []string{r.ExpenseID, strconv.Itoa(r.Revision), string(r.Action), r.ActorID, string(changes), formatTime(r.CreatedAt)}
+	}
+	if err := writeCSV(archive, "expense_history.csv",
+		[]string{"expense_id", "revision", "action", "actor_id", "changes", "created_at"},
+		revisionRows,
+	); err != nil {
+		return err
+	}
+
+	if err := writeJSON(archive, "webhooks.json", webhooks); err != nil {
+		return err
+	}
+	webhookRows := make([][]string, len(webhooks))
+	for i, w := range webhooks {
+		webhookRows[i] = []string{
+			w.ID, w.URL, strings.Join(w.Events, ","), strconv.FormatBool(w.Active),
+			formatTime(w.CreatedAt), formatOptionalTime(w.DisabledAt),
+		}
+	}
+	if err := writeCSV(archive, "webhooks.csv",
+		[]string{"id", "url", "events", "active", "created_at", "disabled_at"},
+		webhookRows,
+	); err != nil {
+		return err
+	}
+
+	if err := writeJSON(archive, "webhook_deliveries.json", deliveries); err != nil {
+		return err
+	}
+	deliveryRows := make([][]string, len(deliveries))
+	for i, d := range deliveries {
+		deliveryRows[i] = []string{
+			d.ID, d.EndpointID, d.Event, string(d.Status), strconv.Itoa(d.Attempts), strconv.Itoa(d.ResponseCode),
+			d.Error, formatTime(d.CreatedAt), formatOptionalTime(d.LastAttemptAt),
+		}
+	}
+	if err := writeCSV(archive, "webhook_deliveries.csv",
+		[]string{"id", "endpoint_id", "event", "status", "attempts", "response_code", "error", "created_at", "last_attempt_at"},
+		deliveryRows,
+	); err != nil {
+		return err
+	}
+
+	if err := writeJSON(archive, "events.json", queued); err != nil {
+		return err
+	}
+	eventRows := make([][]string, len(queued))
+	for i, e := range queued {
+		eventRows[i] = []string{
+			strconv.FormatInt(e.ID, 10), e.EventType, e.Payload, strconv.Itoa(e.Attempts), e.LastError,
+			formatTime(e.CreatedAt), formatOptionalTime(e.ProcessedAt),
+		}
+	}
+	if err := writeCSV(archive, "events.csv",
+		[]string{"id", "event_type", "payload", "attempts", "last_error", "created_at", "processed_at"},
+		eventRows,
+	); err != nil {
+		return err
+	}
+
+	if err := writeJSON(archive, "login_attempts.json", attempts); err != nil {
+		return err
+	}
+	attemptRows := make([][]string, len(attempts))
+	for i, a := range attempts {
+		attemptRows[i] = []string{a.ID, a.Email, a.IPAddress, strconv.FormatBool(a.Success), formatTime(a.CreatedAt)}
+	}
+	if err := writeCSV(archive, "login_attempts.csv",
+		[]string{"id", "email", "ip_address", "success", "created_at"},
+		attemptRows,
+	); err != nil {
+		return err
+	}
+
+	if err := writeJSON(archive, "email_verifications.json", verifications); err != nil {
+		return err
+	}
+	verificationRows := make([][]string, len(verifications))
+	for i, v := range verifications {
+		verificationRows[i] = []string{v.Email, formatTime(v.ExpiresAt), formatTime(v.CreatedAt)}
+	}
+	if err := writeCSV(archive, "email_verifications.csv",
+		[]string{"email", "expires_at", "created_at"},
+		verificationRows,
+	); err != nil {
+		return err
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func (s *ExportService) toResponse(export *entities.DataExport) *dto.ExportResponse {
+	response := &dto.ExportResponse{
+		ID:          export.ID,
+		Status:      string(export.Status),
+		Error:       export.Error,
+		ExpiresAt:   export.ExpiresAt,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+	}
+
+	if export.Status == entities.DataExportCompleted && export.ExpiresAt != nil {
+		expires := export.ExpiresAt.Unix()
+		response.DownloadURL = fmt.Sprintf("/api/exports/%s/download?expires=%d&signature=%s",
+			export.ID, expires, s.sign(export.ID, expires))
+	}
+
+	return response
+}
+
+func (s *ExportService) sign(exportID string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	fmt.Fprintf(mac, "%s.%d", exportID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func writeJSON(archive *zip.Writer, name string, v interface{}) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeCSV(archive *zip.Writer, name string, header []string, rows [][]string) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/domain/valueobjects"
+	"expense-tracker/internal/infrastructure/repositories/memory"
+	"expense-tracker/internal/infrastructure/storage"
+)
+
+func newTestExportService(t *testing.T, store *memory.Store, linkTTL time.Duration) *ExportService {
+	t.Helper()
+	files, err := storage.NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewExportService(memory.NewUserRepository(store), memory.NewExpenseRepository(store), memory.NewExpenseRevisionRepository(store),
+		memory.NewWebhookEndpointRepository(store), memory.NewWebhookDeliveryRepository(store), memory.NewOutboxRepository(store),
+		memory.NewLoginAttemptRepository(store), memory.NewEmailVerificationRepository(store), memory.NewDataExportRepository(store),
+		files, "test-key", linkTTL, 100)
+}
+
+// seedAccount stores one record of every kind for jane@example.com and
+// another user's expense, returning Jane's ID.
+func seedAccount(t *testing.T, store *memory.Store) string {
+	t.Helper()
+	ctx := context.Background()
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	users := memory.NewUserRepository(store)
+	user := &entities.User{Email: "jane@example.com", Password: "hash", Name: "Jane"}
+	other := &entities.User{Email: "john@example.com", Password: "hash", Name: "John"}
+	must(users.Create(ctx, user))
+	must(users.Create(ctx, other))
+
+	expenses := memory.NewExpenseRepository(store)
+	expense := &entities.Expense{UserID: user.ID, Amount: 12.5, Category: valueobjects.Groceries, Description: "Bread", Date: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)}
+	trashed := &entities.Expense{UserID: user.ID, Amount: 3, Category: valueobjects.Others, Description: "Gum", Date: time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)}
+	must(expenses.Create(ctx, expense))
+	must(expenses.Create(ctx, trashed))
+	must(expenses.Delete(ctx, trashed))
+	must(expenses.Create(ctx, &entities.Expense{UserID: other.ID, Amount: 99, Category: valueobjects.Others, Description: "Not Jane's", Date: expense.Date}))
+
+	must(memory.NewExpenseRevisionRepository(store).Create(ctx, &entities.ExpenseRevision{ExpenseID: expense.ID, UserID: user.ID, ActorID: user.ID, Action: entities.ExpenseCreated}))
+
+	endpoint := &entities.WebhookEndpoint{UserID: user.ID, URL: "https://hooks.example.com/jane", Secret: "shh", Events: []string{entities.EventExpenseCreated}, Active: true}
+	must(memory.NewWebhookEndpointRepository(store).Create(ctx, endpoint))
+	must(memory.NewWebhookDeliveryRepository(store).Create(ctx, &entities.WebhookDelivery{
+		EndpointID: endpoint.ID, UserID: user.ID, Event: entities.EventExpenseCreated, Payload: `{"id":"` + expense.ID + `"}`,
+		Status: entities.WebhookDeliverySucceeded, Attempts: 1, ResponseCode: 200,
+	}))
+
+	outbox := memory.NewOutboxRepository(store)
+	must(outbox.Append(ctx, &entities.OutboxEvent{UserID: user.ID, EventType: "expense.created", Payload: `{"amount":12.5}`}))
+	must(outbox.Append(ctx, &entities.OutboxEvent{UserID: other.ID, EventType: "expense.created", Payload: `{"amount":99}`}))
+
+	must(memory.NewLoginAttemptRepository(store).Record(ctx, &entities.LoginAttempt{Email: user.Email, IPAddress: "192.0.2.1", Success: true}))
+	must(memory.NewEmailVerificationRepository(store).Save(ctx, &entities.EmailVerification{UserID: user.ID, Email: "jane.doe@example.com", TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}))
+	return user.ID
+}
+
+// download requests an export and reads the archive through its link.
+func download(t *testing.T, service *ExportService, userID string) map[string][]byte {
+	t.Helper()
+	ctx := context.Background()
+	export, err := service.RequestExport(ctx, userID)
+	if err != nil || export.Status != string(entities.DataExportCompleted) {
+		t.Fatalf("RequestExport = %+v, %v; want it completed", export, err)
+	}
+
+	id, expires, signature := downloadLink(t, export.DownloadURL)
+	file, err := service.OpenDownload(ctx, id, expires, signature)
+	if err != nil {
+		t.Fatalf("OpenDownload: %v", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("not a ZIP archive: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], err = io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return files
+}
+
+func downloadLink(t *testing.T, link string) (id, expires, signature string) {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("download URL %q: %v", link, err)
+	}
+	return path.Base(path.Dir(u.Path)), u.Query().Get("expires"), u.Query().Get("signature")
+}
+
+func TestExportArchive(t *testing.T) {
+	store := memory.NewStore()
+	userID := seedAccount(t, store)
+	files := download(t, newTestExportService(t, store, time.Hour), userID)
+
+	tests := []struct {
+		name    string
+		records int // rows of the CSV file, items of the JSON file
+		header  []string
+	}{
+		{"profile", 1, []string{"id", "email", "name", "created_at", "updated_at"}},
+		{"expenses", 2, []string{"id", "amount", "category", "description", "date", "created_at", "updated_at", "deleted_at"}},
+		{"expense_history", 1, []string{"expense_id", "revision", "action", "actor_id", "changes", "created_at"}},
+		{"webhooks", 1, []string{"id", "url", "events", "active", "created_at", "disabled_at"}},
+		{"webhook_deliveries", 1, []string{"id", "endpoint_id", "event", "status", "attempts", "response_code", "error", "created_at", "last_attempt_at"}},
+		{"events", 1, []string{"id", "event_type", "payload", "attempts", "last_error", "created_at", "processed_at"}},
+		{"login_attempts", 1, []string{"id", "email", "ip_address", "success", "created_at"}},
+		{"email_verifications", 1, []string{"email", "expires_at", "created_at"}},
+	}
+	if len(files) != 2*len(tests) {
+		t.Errorf("archive has %d files, want %d", len(files), 2*len(tests))
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := csv.NewReader(bytes.NewReader(files[tt.name+".csv"])).ReadAll()
+			if err != nil || len(rows) == 0 {
+				t.Fatalf("%s.csv: %v", tt.name, err)
+			}
+			if !slices.Equal(rows[0], tt.header) {
+				t.Fatalf("%s.csv header %q, want %q", tt.name, rows[0], tt.header)
+			}
+			if len(rows)-1 != tt.records {
+				t.Fatalf("%s.csv has %d rows, want %d", tt.name, len(rows)-1, tt.records)
+			}
+
+			var records []map[string]any
+			if tt.name == "profile" {
+				var record map[string]any
+				err = json.Unmarshal(files["profile.json"], &record)
+				records = append(records, record)
+			} else {
+				err = json.Unmarshal(files[tt.name+".json"], &records)
+			}
+			if err != nil || len(records) != tt.records {
+				t.Fatalf("%s.json has %d records (%v), want %d", tt.name, len(records), err, tt.records)
+			}
+		})
+	}
+
+	// Secrets and other users' data stay out
+	for name, content := range files {
+		for _, leaked := range []string{`"password"`, "shh", "Not Jane's", `{"amount":99}`} {
+			if bytes.Contains(content, []byte(leaked)) {
+				t.Errorf("%s contains %s", name, leaked)
+			}
+		}
+	}
+}
+
+func TestExportDownloadLink(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	userID := seedAccount(t, store)
+	service := newTestExportService(t, store, time.Hour)
+
+	export, err := service.RequestExport(ctx, userID)
+	if err != nil {
+		t.Fatalf("RequestExport: %v", err)
+	}
+	id, expires, signature := downloadLink(t, export.DownloadURL)
+	expiresAt, _ := strconv.ParseInt(expires, 10, 64)
+	past := time.Now().Add(-time.Minute).Unix()
+	tampered := []byte(signature)
+	tampered[0] ^= 1
+
+	tests := []struct {
+		name      string
+		id        string
+		expires   string
+		signature string
+		wantErr   error
+	}{
+		{"valid link", id, expires, signature, nil},
+		{"tampered signature", id, expires, string(tampered), ErrExportLinkInvalid},
+		{"missing signature", id, expires, "", ErrExportLinkInvalid},
+		{"later expiry", id, strconv.FormatInt(expiresAt+3600, 10), signature, ErrExportLinkInvalid},
+		{"another export", "another-export", expires, signature, ErrExportLinkInvalid},
+		{"expired link", id, strconv.FormatInt(past, 10), service.sign(id, past), ErrExportLinkInvalid},
+		{"malformed expiry", id, "tomorrow", signature, ErrExportLinkInvalid},
+		{"unknown export", "missing", expires, service.sign("missing", expiresAt), ErrExportLinkInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := service.OpenDownload(ctx, tt.id, tt.expires, tt.signature)
+			if file != nil {
+				file.Close()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("OpenDownload = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// A link signed with another key, as after a key rotation, is refused.
+func TestExportLinkSignedWithAnotherKey(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	userID := seedAccount(t, store)
+	service := newTestExportService(t, store, time.Hour)
+
+	export, err := service.RequestExport(ctx, userID)
+	if err != nil {
+		t.Fatalf("RequestExport: %v", err)
+	}
+	id, expires, signature := downloadLink(t, export.DownloadURL)
+
+	service.signingKey = []byte("rotated-key")
+	if _, err := service.OpenDownload(ctx, id, expires, signature); !errors.Is(err, ErrExportLinkInvalid) {
+		t.Fatalf("OpenDownload = %v, want ErrExportLinkInvalid", err)
+	}
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/events"
+)
+
+var (
+	ErrTooManyFeedConnections = errors.New("too many open feed connections")
+	ErrFeedOverflow           = errors.New("feed connection fell behind")
+	ErrFeedClosed             = errors.New("server is shutting down")
+)
+
+// FeedService fans expense events out to the live connections of their
+// owner. A short history per user lets a reconnecting client resume from
+// the last event it saw, for resumeWindow after the user's last event or
+// connection; then the history is dropped. Connections that cannot keep up
+// are dropped instead of slowing down the others; the client resumes on
+// reconnect.
+type FeedService struct {
+	mu           sync.Mutex
+	epoch        string
+	seq          uint64
+	users        map[string]*userFeed
+	historySize  int
+	bufferSize   int
+	maxPerUser   int
+	resumeWindow time.Duration
+	lastPrune    time.Time
+	closed       bool
+}
+
+type userFeed struct {
+	history []dto.FeedEvent
+	// evicted is the sequence of the newest event that fell out of history
+	evicted       uint64
+	subscriptions map[*FeedSubscription]struct{}
+	// lastActive is when the last event was published or the last
+	// connection ended
+	lastActive time.Time
+}
+
+// FeedSubscription is one live connection. Replay holds the events missed
+// since the Last-Event-ID; Events delivers new ones and is closed when the
+// subscription ends.
+type FeedSubscription struct {
+	Replay []dto.FeedEvent
+	Events <-chan dto.FeedEvent
+
+	service *FeedService
+	userID  string
+	ch      chan dto.FeedEvent
+	err     error
+}
+
+func NewFeedService(historySize, bufferSize, maxPerUser int, resumeWindow time.Duration) *FeedService {
+	return &FeedService{
+		// Event IDs from an earlier process are recognised as unknown
+		epoch:        strconv.FormatInt(time.Now().UnixNano(), 36),
+		users:        make(map[string]*userFeed),
+		historySize:  historySize,
+		bufferSize:   bufferSize,
+		maxPerUser:   maxPerUser,
+		resumeWindow: resumeWindow,
+		lastPrune:    time.Now(),
+	}
+}
+
+// Subscribe feeds expense events into the service once their transaction
+// has committed.
+func (s *FeedService) Subscribe(bus *events.Bus) {
+	events.SubscribeAsync(bus, func(ctx context.Context, e events.ExpenseCreated) error {
+		s.publish(e.Expense.UserID, dto.FeedEvent{Type: e.EventName(), Expense: toExpenseResponse(&e.Expense), OccurredAt: e.OccurredAt})
+		return nil
+	})
+	events.SubscribeAsync(bus, func(ctx context.Context, e events.ExpenseUpdated) error {
+		s.publish(e.Expense.UserID, dto.FeedEvent{Type: e.EventName(), Expense: toExpenseResponse(&e.Expense), OccurredAt: e.OccurredAt})
+		return nil
+	})
+	events.SubscribeAsync(bus, func(ctx context.Context, e events.ExpenseDeleted) error {
+		s.publish(e.Expense.UserID, dto.FeedEvent{Type: e.EventName(), Expense: toExpenseResponse(&e.Expense), Permanent: e.Permanent, OccurredAt: e.OccurredAt})
+		return nil
+	})
+}
+
+// Connect opens a subscription for the user. With a lastEventID the
+// subscription starts with the events after it; when those are no longer
+// known the replay is a single reset event, telling the client to reload.
+func (s *FeedService) Connect(userID, lastEventID string) (*FeedSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrFeedClosed
+	}
+	s.prune(time.Now())
+	feed := s.feed(userID)
+	if s.maxPerUser > 0 && len(feed.subscriptions) >= s.maxPerUser {
+		return nil, ErrTooManyFeedConnections
+	}
+
+	ch := make(chan dto.FeedEvent, s.bufferSize)
+	sub := &FeedSubscription{
+		Events:  ch,
+		service: s,
+		userID:  userID,
+		ch:      ch,
+	}
+	if lastEventID != "" {
+		sub.Replay = s.replay(feed, lastEventID)
+	}
+	feed.subscriptions[sub] = struct{}{}
+	return sub, nil
+}
+
+// Close ends the subscription. It is safe to call more than once.
+func (sub *FeedSubscription) Close() {
+	sub.service.mu.Lock()
+	defer sub.service.mu.Unlock()
+	sub.service.remove(sub, nil)
+}
+
+// Err reports why Events was closed: ErrFeedOverflow when the connection
+// fell behind, ErrFeedClosed when the server shuts down, nil otherwise.
+func (sub *FeedSubscription) Err() error {
+	sub.service.mu.Lock()
+	defer sub.service.mu.Unlock()
+	return sub.err
+}
+
+// Close ends every subscription with ErrFeedClosed and refuses new ones, so
+// streaming connections finish when the server shuts down.
+func (s *FeedService) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for _, feed := range s.users {
+		for sub := range feed.subscriptions {
+			s.remove(sub, ErrFeedClosed)
+		}
+	}
+}
+
+func (s *FeedService) publish(userID string, event dto.FeedEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.prune(now)
+	feed := s.feed(userID)
+	feed.lastActive = now
+
+	s.seq++
+	event.ID = s.eventID(s.seq)
+	feed.history = append(feed.history, event)
+	if len(feed.history) > s.historySize {
+		feed.evicted = s.sequence(feed.history[0].ID)
+		feed.history = feed.history[1:]
+	}
+
+	for sub := range feed.subscriptions {
+		select {
+		case sub.ch <- event:
+		default:
+			s.remove(sub, ErrFeedOverflow)
+		}
+	}
+}
+
+// replay returns the events after lastEventID, or a reset event when the
+// ID is from another process or older than the history.
+func (s *FeedService) replay(feed *userFeed, lastEventID string) []dto.FeedEvent {
+	epoch, seqPart, ok := strings.Cut(lastEventID, "-")
+	last, err := strconv.ParseUint(seqPart, 10, 64)
+	if !ok || err != nil || epoch != s.epoch || last > s.seq || last < feed.evicted {
+		return []dto.FeedEvent{{ID: s.eventID(s.seq), Type: dto.FeedReset, OccurredAt: time.Now()}}
+	}
+
+	var missed []dto.FeedEvent
+	for _, event := range feed.history {
+		if s.sequence(event.ID) > last {
+			missed = append(missed, event)
+		}
+	}
+	return missed
+}
+
+func (s *FeedService) remove(sub *FeedSubscription, reason error) {
+	feed, ok := s.users[sub.userID]
+	if !ok {
+		return
+	}
+	if _, ok := feed.subscriptions[sub]; !ok {
+		return
+	}
+
+	delete(feed.subscriptions, sub)
+	sub.err = reason
+	close(sub.ch)
+	feed.lastActive = time.Now()
+}
+
+// prune drops the feeds of users without connections whose last activity
+// is older than the resume window. It runs at most once per window.
+func (s *FeedService) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < s.resumeWindow {
+		return
+	}
+	s.lastPrune = now
+	for userID, feed := range s.users {
+		if len(feed.subscriptions) == 0 && now.Sub(feed.lastActive) >= s.resumeWindow {
+			delete(s.users, userID)
+		}
+	}
+}
+
+func (s *FeedService) feed(userID string) *userFeed {
+	feed, ok := s.users[userID]
+	if !ok {
+		// The user's earlier events, if any, were pruned with an earlier
+		// feed, so resuming from before now needs a reset
+		feed = &userFeed{
+			evicted:       s.seq,
+			subscriptions: make(map[*FeedSubscription]struct{}),
+			lastActive:    time.Now(),
+		}
+		s.users[userID] = feed
+	}
+	return feed
+}
+
+func (s *FeedService) eventID(seq uint64) string {
+	return fmt.Sprintf("%s-%d", s.epoch, seq)
+}
+
+func (s *FeedService) sequence(eventID string) uint64 {
+	_, seqPart, _ := strings.Cut(eventID, "-")
+	seq, _ := strconv.ParseUint(seqPart, 10, 64)
+	return seq
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/application/dto"
+)
+
+// publishFeed publishes one event per user in order and returns their IDs.
+func publishFeed(s *FeedService, users ...string) []string {
+	var ids []string
+	for _, userID := range users {
+		s.publish(userID, dto.FeedEvent{Type: "expense.created"})
+		ids = append(ids, s.eventID(s.seq))
+	}
+	return ids
+}
+
+func feedIDs(events []dto.FeedEvent) []string {
+	var ids []string
+	for _, event := range events {
+		if event.Type == dto.FeedReset {
+			ids = append(ids, dto.FeedReset)
+			continue
+		}
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestFeedReplay(t *testing.T) {
+	s := NewFeedService(3, 10, 0, time.Hour)
+	// Sequences 1 to 7; user a keeps its last three, 4, 6 and 7
+	ids := publishFeed(s, "a", "a", "b", "a", "a", "a", "b")
+	a1, a2, b3, a4, a5, a6 := ids[0], ids[1], ids[2], ids[3], ids[4], ids[5]
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		want        []string
+	}{
+		{"new connection", "", nil},
+		{"missed some", a4, []string{a5, a6}},
+		{"up to date", a6, nil},
+		{"another user's event", b3, []string{a4, a5, a6}},
+		{"newest evicted event", a2, []string{a4, a5, a6}},
+		{"older than the history", a1, []string{dto.FeedReset}},
+		{"from another process", "0abc-5", []string{dto.FeedReset}},
+		{"from the future", s.eventID(99), []string{dto.FeedReset}},
+		{"malformed", "garbage", []string{dto.FeedReset}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := s.Connect("a", tt.lastEventID)
+			if err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer sub.Close()
+			if got := feedIDs(sub.Replay); !slices.Equal(got, tt.want) {
+				t.Fatalf("replay = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// A reset carries the current position, so resuming from it does not reset
+// again.
+func TestFeedResetIsResumable(t *testing.T) {
+	s := NewFeedService(1, 10, 0, time.Hour)
+	publishFeed(s, "a", "a", "a")
+
+	sub, _ := s.Connect("a", "unknown")
+	sub.Close()
+	if len(sub.Replay) != 1 || sub.Replay[0].Type != dto.FeedReset {
+		t.Fatalf("replay = %v, want a reset", sub.Replay)
+	}
+
+	latest := publishFeed(s, "a")
+	resumed, _ := s.Connect("a", sub.Replay[0].ID)
+	defer resumed.Close()
+	if got := feedIDs(resumed.Replay); !slices.Equal(got, latest) {
+		t.Fatalf("replay after reset = %q, want %q", got, latest)
+	}
+}
+
+func TestFeedLiveEvents(t *testing.T) {
+	s := NewFeedService(10, 2, 2, time.Hour)
+
+	sub, _ := s.Connect("a", "")
+	other, _ := s.Connect("b", "")
+	defer other.Close()
+	ids := publishFeed(s, "a", "b")
+	if event := <-sub.Events; event.ID != ids[0] {
+		t.Fatalf("got event %s, want %s", event.ID, ids[0])
+	}
+	if event := <-other.Events; event.ID != ids[1] {
+		t.Fatalf("other user got event %s, want %s", event.ID, ids[1])
+	}
+
+	// The buffer holds two events; the third drops the connection
+	publishFeed(s, "a", "a", "a")
+	<-sub.Events
+	<-sub.Events
+	if _, ok := <-sub.Events; ok || !errors.Is(sub.Err(), ErrFeedOverflow) {
+		t.Fatalf("slow subscription: err %v, want ErrFeedOverflow", sub.Err())
+	}
+	if len(other.Events) != 0 {
+		t.Fatal("another user's connection received events")
+	}
+}
+
+func TestFeedConnectionLimitAndClose(t *testing.T) {
+	s := NewFeedService(10, 2, 1, time.Hour)
+
+	sub, err := s.Connect("a", "")
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if _, err := s.Connect("a", ""); !errors.Is(err, ErrTooManyFeedConnections) {
+		t.Fatalf("second connection: %v, want ErrTooManyFeedConnections", err)
+	}
+	if other, err := s.Connect("b", ""); err != nil {
+		t.Fatalf("other user's connection: %v", err)
+	} else {
+		other.Close()
+	}
+
+	s.Close()
+	if _, ok := <-sub.Events; ok || !errors.Is(sub.Err(), ErrFeedClosed) {
+		t.Fatalf("after Close: err %v, want ErrFeedClosed", sub.Err())
+	}
+	sub.Close()
+	if _, err := s.Connect("a", ""); !errors.Is(err, ErrFeedClosed) {
+		t.Fatalf("Connect after Close: %v, want ErrFeedClosed", err)
+	}
+}
+
+// Feeds of users without connections are dropped once the resume window
+// has passed since their last activity; resuming from them then resets.
+func TestFeedPrune(t *testing.T) {
+	s := NewFeedService(10, 10, 0, time.Minute)
+	ids := publishFeed(s, "idle", "connected", "recent")
+	sub, _ := s.Connect("connected", "")
+	defer sub.Close()
+
+	now := time.Now()
+	s.users["recent"].lastActive = now.Add(2 * time.Minute)
+	s.prune(now.Add(30 * time.Second))
+	if len(s.users) != 3 {
+		t.Fatalf("pruned within the window: %d feeds left", len(s.users))
+	}
+
+	s.prune(now.Add(2 * time.Minute))
+	if _, ok := s.users["idle"]; ok {
+		t.Fatal("idle feed was kept")
+	}
+	if _, ok := s.users["connected"]; !ok {
+		t.Fatal("feed with a connection was pruned")
+	}
+	if _, ok := s.users["recent"]; !ok {
+		t.Fatal("recently active feed was pruned")
+	}
+
+	resumed, _ := s.Connect("idle", ids[0])
+	defer resumed.Close()
+	if got := feedIDs(resumed.Replay); !slices.Equal(got, []string{dto.FeedReset}) {
+		t.Fatalf("replay after pruning = %q, want a reset", got)
+	}
+
+	// Events after the reset resume normally
+	latest := publishFeed(s, "idle")
+	again, _ := s.Connect("idle", resumed.Replay[0].ID)
+	defer again.Close()
+	if got := feedIDs(again.Replay); !slices.Equal(got, latest) {
+		t.Fatalf("replay after the reset = %q, want %q", got, latest)
+	}
+}
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("expense-tracker/internal/application/services")
+
+// startSpan starts the span of a service call. Failures are recorded on the
+// spans of the SQL statements and HTTP requests around it.
+func startSpan(ctx context.Context, name, userID string) (context.Context, trace.Span) {
+	if userID == "" {
+		return tracer.Start(ctx, name)
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(semconv.UserID(userID)))
+}
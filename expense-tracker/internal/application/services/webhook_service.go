@@ -0,0 +1,466 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/events"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/domain/repositories"
+	"expense-tracker/internal/pkg/logger"
+	"expense-tracker/internal/pkg/netguard"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWebhookNotFound     = errors.New("webhook endpoint not found")
+	ErrWebhookDisabled     = errors.New("webhook endpoint is disabled")
+	ErrDeliveryNotFound    = errors.New("webhook delivery not found")
+	ErrInvalidWebhookURL   = errors.New("url must be an absolute http or https URL")
+	ErrPrivateWebhookURL   = errors.New("url must point to a public address")
+	ErrInvalidWebhookEvent = errors.New("events must list at least one of expense.created, expense.updated, expense.deleted, budget.exceeded")
+)
+
+const maxResponseBodyLogged = 1024
+
+// WebhookSender makes one delivery attempt and reports the response.
+type WebhookSender interface {
+	Send(ctx context.Context, endpoint *entities.WebhookEndpoint, delivery *entities.WebhookDelivery) (statusCode int, body string, err error)
+}
+
+// WebhookPolicy controls retries. A delivery is attempted up to MaxAttempts
+// times, waiting BackoffBase doubled after each failure up to BackoffMax.
+// An endpoint is disabled after DisableAfter failed attempts in a row.
+// ProcessDue claims a batch of BatchSize deliveries for ClaimFor, which
+// must outlast attempting the whole batch; a delivery whose instance died
+// is retried once its claim expires.
+// AllowPrivateURLs accepts loopback and private addresses, for local
+// receivers during development; otherwise users could make the server
+// request internal services and read the answers from the delivery log.
+type WebhookPolicy struct {
+	MaxAttempts      int
+	BackoffBase      time.Duration
+	BackoffMax       time.Duration
+	DisableAfter     int
+	BatchSize        int
+	ClaimFor         time.Duration
+	AllowPrivateURLs bool
+}
+
+type WebhookService struct {
+	txManager    repositories.TxManager
+	endpointRepo repositories.WebhookEndpointRepository
+	deliveryRepo repositories.WebhookDeliveryRepository
+	sender       WebhookSender
+	policy       WebhookPolicy
+}
+
+func NewWebhookService(txManager repositories.TxManager, endpointRepo repositories.WebhookEndpointRepository, deliveryRepo repositories.WebhookDeliveryRepository, sender WebhookSender, policy WebhookPolicy) *WebhookService {
+	return &WebhookService{
+		txManager:    txManager,
+		endpointRepo: endpointRepo,
+		deliveryRepo: deliveryRepo,
+		sender:       sender,
+		policy:       policy,
+	}
+}
+
+func (s *WebhookService) CreateEndpoint(ctx context.Context, userID string, req dto.CreateWebhookRequest) (*dto.WebhookResponse, error) {
+	if err := s.validateURL(ctx, req.URL); err != nil {
+		return nil, err
+	}
+	events, err := validateWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := &entities.WebhookEndpoint{
+		UserID: userID,
+		URL:    req.URL,
+		Secret: "whsec_" + secret,
+		Events: events,
+		Active: true,
+	}
+	if err := s.endpointRepo.Create(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	response := toWebhookResponse(endpoint)
+	response.Secret = endpoint.Secret
+	return response, nil
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context, userID string) ([]*dto.WebhookResponse, error) {
+	endpoints, err := s.endpointRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.WebhookResponse, len(endpoints))
+	for i, endpoint := range endpoints {
+		responses[i] = toWebhookResponse(endpoint)
+	}
+	return responses, nil
+}
+
+func (s *WebhookService) GetEndpoint(ctx context.Context, userID, endpointID string) (*dto.WebhookResponse, error) {
+	endpoint, err := s.findEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookResponse(endpoint), nil
+}
+
+// UpdateEndpoint changes the URL or events. Setting active re-enables an
+// endpoint that was disabled after repeated failures.
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, userID, endpointID string, req dto.UpdateWebhookRequest) (*dto.WebhookResponse, error) {
+	endpoint, err := s.findEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := s.validateURL(ctx, *req.URL); err != nil {
+			return nil, err
+		}
+		endpoint.URL = *req.URL
+	}
+	if req.Events != nil {
+		events, err := validateWebhookEvents(*req.Events)
+		if err != nil {
+			return nil, err
+		}
+		endpoint.Events = events
+	}
+	if req.Active != nil {
+		endpoint.Active = *req.Active
+		if endpoint.Active {
+			endpoint.ConsecutiveFailures = 0
+			endpoint.DisabledAt = nil
+		}
+	}
+
+	if err := s.endpointRepo.Update(ctx, endpoint); err != nil {
+		return nil, err
+	}
+	return toWebhookResponse(endpoint), nil
+}
+
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, userID, endpointID string) error {
+	if _, err := s.findEndpoint(ctx, userID, endpointID); err != nil {
+		return err
+	}
+
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.deliveryRepo.DeleteByEndpointID(ctx, endpointID); err != nil {
+			return err
+		}
+		return s.endpointRepo.Delete(ctx, endpointID)
+	})
+}
+
+// ListDeliveries returns the latest 100 deliveries of an endpoint.
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID, endpointID string) ([]*dto.WebhookDeliveryResponse, error) {
+	if _, err := s.findEndpoint(ctx, userID, endpointID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.deliveryRepo.FindByEndpointID(ctx, endpointID, 100)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		responses[i] = toDeliveryResponse(delivery)
+	}
+	return responses, nil
+}
+
+// Redeliver queues the payload of an earlier delivery again. The original
+// stays in the log unchanged.
+func (s *WebhookService) Redeliver(ctx context.Context, userID, endpointID, deliveryID string) (*dto.WebhookDeliveryResponse, error) {
+	endpoint, err := s.findEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	if !endpoint.Active {
+		return nil, ErrWebhookDisabled
+	}
+
+	original, err := s.deliveryRepo.FindByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil || original.EndpointID != endpointID {
+		return nil, ErrDeliveryNotFound
+	}
+
+	now := time.Now()
+	delivery := &entities.WebhookDelivery{
+		EndpointID:    endpoint.ID,
+		UserID:        endpoint.UserID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        entities.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+	}
+	if err := s.deliveryRepo.Create(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return toDeliveryResponse(delivery), nil
+}
+
+// Subscribe queues deliveries for expense events. The subscribers are
+// synchronous, so deliveries are queued in the same transaction as the
+// change that caused them.
+func (s *WebhookService) Subscribe(bus *events.Bus) {
+	events.Subscribe(bus, func(ctx context.Context, e events.ExpenseCreated) error {
+		return s.Dispatch(ctx, e.Expense.UserID, entities.EventExpenseCreated, toExpenseResponse(&e.Expense))
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.ExpenseUpdated) error {
+		return s.Dispatch(ctx, e.Expense.UserID, entities.EventExpenseUpdated, toExpenseResponse(&e.Expense))
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.ExpenseDeleted) error {
+		// Trashed expenses were reported as deleted when they were trashed
+		if e.Permanent && e.Expense.DeletedAt != nil {
+			return nil
+		}
+		return s.Dispatch(ctx, e.Expense.UserID, entities.EventExpenseDeleted, toExpenseResponse(&e.Expense))
+	})
+}
+
+// Dispatch queues a delivery of the event to every active endpoint of the
+// user subscribed to it. Called inside the caller's transaction, the
+// deliveries are only queued if the change itself is committed.
+func (s *WebhookService) Dispatch(ctx context.Context, userID, event string, data interface{}) error {
+	endpoints, err := s.endpointRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	now := time.Now()
+	for _, endpoint := range endpoints {
+		if !endpoint.Active || !endpoint.Subscribes(event) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(map[string]interface{}{
+				"id":         uuid.New().String(),
+				"event":      event,
+				"created_at": now,
+				"data":       data,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err := s.deliveryRepo.Create(ctx, &entities.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			UserID:        userID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        entities.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProcessDue makes one attempt for each delivery that is due and schedules
+// retries. It returns the number of deliveries attempted.
+func (s *WebhookService) ProcessDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	deliveries, err := s.deliveryRepo.ClaimDue(ctx, now, now.Add(s.policy.ClaimFor), s.policy.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		if err := s.attempt(ctx, delivery); err != nil {
+			logger.Error(ctx, "failed to process webhook delivery", "delivery_id", delivery.ID, "error", err)
+		}
+	}
+	return len(deliveries), nil
+}
+
+func (s *WebhookService) attempt(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	endpoint, err := s.endpointRepo.FindByID(ctx, delivery.EndpointID)
+	if err != nil {
+		return err
+	}
+	if endpoint == nil || !endpoint.Active {
+		delivery.Status = entities.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.Error = ErrWebhookDisabled.Error()
+		return s.deliveryRepo.Update(ctx, delivery)
+	}
+
+	// The request is made outside any transaction
+	statusCode, body, sendErr := s.sender.Send(ctx, endpoint, delivery)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseCode = statusCode
+	if len(body) > maxResponseBodyLogged {
+		body = body[:maxResponseBodyLogged]
+	}
+	delivery.ResponseBody = body
+	delivery.Error = ""
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+
+	succeeded := sendErr == nil && statusCode >= 200 && statusCode < 300
+	switch {
+	case succeeded:
+		delivery.Status = entities.WebhookDeliverySucceeded
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= s.policy.MaxAttempts:
+		delivery.Status = entities.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(s.backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+			return err
+		}
+
+		// Reload so changes made by the user during the request are kept
+		endpoint, err := s.endpointRepo.FindByID(ctx, delivery.EndpointID)
+		if err != nil || endpoint == nil {
+			return err
+		}
+
+		if succeeded {
+			if endpoint.ConsecutiveFailures == 0 {
+				return nil
+			}
+			endpoint.ConsecutiveFailures = 0
+			return s.endpointRepo.Update(ctx, endpoint)
+		}
+
+		endpoint.ConsecutiveFailures++
+		if s.policy.DisableAfter > 0 && endpoint.ConsecutiveFailures >= s.policy.DisableAfter {
+			endpoint.Active = false
+			endpoint.DisabledAt = &now
+			logger.Warn(ctx, "disabled webhook endpoint", "endpoint_id", endpoint.ID, "failed_attempts", endpoint.ConsecutiveFailures)
+			if err := s.deliveryRepo.FailPending(ctx, endpoint.ID, ErrWebhookDisabled.Error()); err != nil {
+				return err
+			}
+		}
+		return s.endpointRepo.Update(ctx, endpoint)
+	})
+}
+
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	delay := s.policy.BackoffBase
+	for i := 1; i < attempts && delay < s.policy.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > s.policy.BackoffMax {
+		delay = s.policy.BackoffMax
+	}
+	return delay
+}
+
+func (s *WebhookService) findEndpoint(ctx context.Context, userID, endpointID string) (*entities.WebhookEndpoint, error) {
+	endpoint, err := s.endpointRepo.FindByID(ctx, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == nil || endpoint.UserID != userID {
+		return nil, ErrWebhookNotFound
+	}
+	return endpoint, nil
+}
+
+// validateURL rejects URLs the sender could not or must not deliver to.
+// The sender checks the address again when connecting, as DNS may change.
+func (s *WebhookService) validateURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	if s.policy.AllowPrivateURLs {
+		return nil
+	}
+	if u.Hostname() == "localhost" || netguard.CheckHost(ctx, u.Hostname()) != nil {
+		return ErrPrivateWebhookURL
+	}
+	return nil
+}
+
+// validateWebhookEvents checks the events against the known ones and drops
+// duplicates.
+func validateWebhookEvents(events []string) ([]string, error) {
+	seen := make(map[string]bool)
+	valid := []string{}
+	for _, event := range events {
+		known := false
+		for _, candidate := range entities.WebhookEvents {
+			if event == candidate {
+				known = true
+			}
+		}
+		if !known {
+			return nil, ErrInvalidWebhookEvent
+		}
+		if !seen[event] {
+			seen[event] = true
+			valid = append(valid, event)
+		}
+	}
+	if len(valid) == 0 {
+		return nil, ErrInvalidWebhookEvent
+	}
+	return valid, nil
+}
+
+func toWebhookResponse(endpoint *entities.WebhookEndpoint) *dto.WebhookResponse {
+	return &dto.WebhookResponse{
+		ID:                  endpoint.ID,
+		URL:                 endpoint.URL,
+		Events:              endpoint.Events,
+		Active:              endpoint.Active,
+		ConsecutiveFailures: endpoint.ConsecutiveFailures,
+		DisabledAt:          endpoint.DisabledAt,
+		CreatedAt:           endpoint.CreatedAt,
+		UpdatedAt:           endpoint.UpdatedAt,
+	}
+}
+
+func toDeliveryResponse(delivery *entities.WebhookDelivery) *dto.WebhookDeliveryResponse {
+	return &dto.WebhookDeliveryResponse{
+		ID:            delivery.ID,
+		Event:         delivery.Event,
+		Status:        string(delivery.Status),
+		Attempts:      delivery.Attempts,
+		ResponseCode:  delivery.ResponseCode,
+		ResponseBody:  delivery.ResponseBody,
+		Error:         delivery.Error,
+		NextAttemptAt: delivery.NextAttemptAt,
+		LastAttemptAt: delivery.LastAttemptAt,
+		CreatedAt:     delivery.CreatedAt,
+		Payload:       delivery.Payload,
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/infrastructure/repositories/memory"
+)
+
+// scriptedSender answers the statuses in order, 200 once they run out; 0
+// stands for a connection error.
+type scriptedSender struct {
+	statuses []int
+	sent     int
+}
+
+func (s *scriptedSender) Send(ctx context.Context, endpoint *entities.WebhookEndpoint, delivery *entities.WebhookDelivery) (int, string, error) {
+	s.sent++
+	status := 200
+	if s.sent <= len(s.statuses) {
+		status = s.statuses[s.sent-1]
+	}
+	if status == 0 {
+		return 0, "", errors.New("connection refused")
+	}
+	return status, "ok", nil
+}
+
+func newTestWebhookService(sender WebhookSender, policy WebhookPolicy) *WebhookService {
+	store := memory.NewStore()
+	policy.BatchSize = 10
+	return NewWebhookService(memory.NewTxManager(store), memory.NewWebhookEndpointRepository(store), memory.NewWebhookDeliveryRepository(store), sender, policy)
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	service := newTestWebhookService(nil, WebhookPolicy{BackoffBase: 30 * time.Second, BackoffMax: 10 * time.Minute})
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{5, 8 * time.Minute},
+		{6, 10 * time.Minute},
+		{100, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := service.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookDeliveryRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		maxAttempts  int
+		wantStatus   entities.WebhookDeliveryStatus
+		wantAttempts int
+	}{
+		{"delivered at once", nil, 3, entities.WebhookDeliverySucceeded, 1},
+		{"delivered after retries", []int{500, 0}, 3, entities.WebhookDeliverySucceeded, 3},
+		{"redirect is a failure", []int{302, 302, 302}, 3, entities.WebhookDeliveryFailed, 3},
+		{"gives up after the maximum attempts", []int{503, 503, 503, 503}, 3, entities.WebhookDeliveryFailed, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			sender := &scriptedSender{statuses: tt.statuses}
+			// A nanosecond backoff makes every retry due at the next run
+			service := newTestWebhookService(sender, WebhookPolicy{MaxAttempts: tt.maxAttempts, BackoffBase: time.Nanosecond, BackoffMax: time.Nanosecond, AllowPrivateURLs: true})
+			endpoint := createTestEndpoint(t, service)
+			if err := service.Dispatch(ctx, testUserID, "expense.created", map[string]string{"id": "e1"}); err != nil {
+				t.Fatalf("Dispatch: %v", err)
+			}
+
+			for i := 0; i < tt.maxAttempts+2; i++ {
+				if _, err := service.ProcessDue(ctx); err != nil {
+					t.Fatalf("ProcessDue: %v", err)
+				}
+			}
+
+			delivery := onlyDelivery(t, service, endpoint.ID)
+			if entities.WebhookDeliveryStatus(delivery.Status) != tt.wantStatus || delivery.Attempts != tt.wantAttempts {
+				t.Fatalf("delivery is %s after %d attempts, want %s after %d", delivery.Status, delivery.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+			if delivery.NextAttemptAt != nil {
+				t.Fatalf("finished delivery is still scheduled for %v", delivery.NextAttemptAt)
+			}
+		})
+	}
+}
+
+func TestWebhookRetryIsScheduledWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	service := newTestWebhookService(&scriptedSender{statuses: []int{500}}, WebhookPolicy{MaxAttempts: 5, BackoffBase: time.Minute, BackoffMax: time.Hour, AllowPrivateURLs: true})
+	endpoint := createTestEndpoint(t, service)
+	service.Dispatch(ctx, testUserID, "expense.created", nil)
+
+	before := time.Now()
+	service.ProcessDue(ctx)
+	if n, _ := service.ProcessDue(ctx); n != 0 {
+		t.Fatalf("retry was attempted %d times before its backoff", n)
+	}
+
+	delivery := onlyDelivery(t, service, endpoint.ID)
+	if delivery.Status != string(entities.WebhookDeliveryPending) || delivery.NextAttemptAt == nil {
+		t.Fatalf("delivery is %s with next attempt %v, want pending", delivery.Status, delivery.NextAttemptAt)
+	}
+	if wait := delivery.NextAttemptAt.Sub(before); wait < time.Minute || wait > time.Minute+time.Second {
+		t.Fatalf("next attempt in %v, want a minute", wait)
+	}
+}
+
+// senderFunc adapts a function to WebhookSender.
+type senderFunc func(ctx context.Context) (int, string, error)
+
+func (f senderFunc) Send(ctx context.Context, endpoint *entities.WebhookEndpoint, delivery *entities.WebhookDelivery) (int, string, error) {
+	return f(ctx)
+}
+
+func TestWebhookDeliveryInFlightIsSkippedByOtherInstances(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	policy := WebhookPolicy{MaxAttempts: 3, BackoffBase: time.Nanosecond, BackoffMax: time.Nanosecond, BatchSize: 10, ClaimFor: time.Minute, AllowPrivateURLs: true}
+	newInstance := func(sender WebhookSender) *WebhookService {
+		return NewWebhookService(memory.NewTxManager(store), memory.NewWebhookEndpointRepository(store), memory.NewWebhookDeliveryRepository(store), sender, policy)
+	}
+
+	other := newInstance(&scriptedSender{})
+	sent := 0
+	service := newInstance(senderFunc(func(ctx context.Context) (int, string, error) {
+		sent++
+		// The other instance runs while this one waits for the receiver
+		if n, err := other.ProcessDue(ctx); err != nil || n != 0 {
+			t.Errorf("other instance attempted %d deliveries, %v; want none", n, err)
+		}
+		return 500, "", nil
+	}))
+	endpoint := createTestEndpoint(t, service)
+	service.Dispatch(ctx, testUserID, "expense.created", nil)
+
+	if n, err := service.ProcessDue(ctx); err != nil || n != 1 || sent != 1 {
+		t.Fatalf("ProcessDue = %d, %v with %d sent; want one", n, err, sent)
+	}
+
+	// The failed attempt released the claim, so the retry goes to whichever
+	// instance runs next
+	if n, err := other.ProcessDue(ctx); err != nil || n != 1 {
+		t.Fatalf("retry by the other instance = %d, %v; want one", n, err)
+	}
+	if delivery := onlyDelivery(t, service, endpoint.ID); delivery.Status != string(entities.WebhookDeliverySucceeded) || delivery.Attempts != 2 {
+		t.Fatalf("delivery is %s after %d attempts, want succeeded after 2", delivery.Status, delivery.Attempts)
+	}
+}
+
+func TestWebhookEndpointIsDisabledAfterFailures(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		disableAfter int
+		wantActive   bool
+		wantFailures int
+	}{
+		{"disabled after consecutive failures", []int{500, 500, 500}, 3, false, 3},
+		{"success resets the count", []int{500, 500, 200, 500, 500}, 3, true, 2},
+		{"zero never disables", []int{500, 500, 500, 500}, 0, true, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			sender := &scriptedSender{statuses: tt.statuses}
+			service := newTestWebhookService(sender, WebhookPolicy{MaxAttempts: 1, BackoffBase: time.Minute, BackoffMax: time.Minute, DisableAfter: tt.disableAfter, AllowPrivateURLs: true})
+			endpoint := createTestEndpoint(t, service)
+
+			// One delivery per status, each attempted once
+			for range tt.statuses {
+				service.Dispatch(ctx, testUserID, "expense.created", nil)
+				service.ProcessDue(ctx)
+			}
+			// Queued after the last failure, to see what happens to pending work
+			service.Dispatch(ctx, testUserID, "expense.created", nil)
+
+			got, err := service.GetEndpoint(ctx, testUserID, endpoint.ID)
+			if err != nil {
+				t.Fatalf("GetEndpoint: %v", err)
+			}
+			if got.Active != tt.wantActive || got.ConsecutiveFailures != tt.wantFailures {
+				t.Fatalf("endpoint active=%v with %d failures, want active=%v with %d", got.Active, got.ConsecutiveFailures, tt.wantActive, tt.wantFailures)
+			}
+			if !tt.wantActive && got.DisabledAt == nil {
+				t.Fatal("disabled endpoint has no DisabledAt")
+			}
+
+			// A disabled endpoint gets no new deliveries; an active one does
+			deliveries, _ := service.ListDeliveries(ctx, testUserID, endpoint.ID)
+			want := len(tt.statuses)
+			if tt.wantActive {
+				want++
+			}
+			if len(deliveries) != want {
+				t.Fatalf("%d deliveries, want %d", len(deliveries), want)
+			}
+			sent := sender.sent
+			service.ProcessDue(ctx)
+			if !tt.wantActive && sender.sent != sent {
+				t.Fatal("a delivery was sent to a disabled endpoint")
+			}
+		})
+	}
+}
+
+func TestWebhookURLValidation(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		wantErr      error
+	}{
+		{"https://93.184.215.14/hook", false, nil},
+		{"ftp://93.184.215.14/hook", false, ErrInvalidWebhookURL},
+		{"https:///hook", false, ErrInvalidWebhookURL},
+		{"http://localhost:8080/hook", false, ErrPrivateWebhookURL},
+		{"http://127.0.0.1/hook", false, ErrPrivateWebhookURL},
+		{"http://[::1]/hook", false, ErrPrivateWebhookURL},
+		{"http://[::ffff:127.0.0.1]/hook", false, ErrPrivateWebhookURL},
+		{"http://169.254.169.254/latest/meta-data", false, ErrPrivateWebhookURL},
+		{"http://10.0.0.5/hook", false, ErrPrivateWebhookURL},
+		{"http://localhost:8080/hook", true, nil},
+		{"http://10.0.0.5/hook", true, nil},
+		{"ftp://localhost/hook", true, ErrInvalidWebhookURL},
+	}
+	for _, tt := range tests {
+		service := newTestWebhookService(nil, WebhookPolicy{AllowPrivateURLs: tt.allowPrivate})
+		_, err := service.CreateEndpoint(context.Background(), testUserID, dto.CreateWebhookRequest{URL: tt.url, Events: []string{"expense.created"}})
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("CreateEndpoint(%s, allowPrivate=%v) = %v, want %v", tt.url, tt.allowPrivate, err, tt.wantErr)
+		}
+	}
+}
+
+func createTestEndpoint(t *testing.T, service *WebhookService) *dto.WebhookResponse {
+	t.Helper()
+	endpoint, err := service.CreateEndpoint(context.Background(), testUserID, dto.CreateWebhookRequest{URL: "http://localhost:9000/hook", Events: []string{"expense.created"}})
+	if err != nil {
+		t.Fatalf("CreateEndpoint: %v", err)
+	}
+	return endpoint
+}
+
+func onlyDelivery(t *testing.T, service *WebhookService, endpointID string) *dto.WebhookDeliveryResponse {
+	t.Helper()
+	deliveries, err := service.ListDeliveries(context.Background(), testUserID, endpointID)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("ListDeliveries = %d deliveries, %v; want one", len(deliveries), err)
+	}
+	return deliveries[0]
+}
@@ -1,67 +1,311 @@
+// Package config loads the settings of the API and the admin command. The
+// defaults are overridden by an optional YAML file, and both by environment
+// variables; the result is validated before use.
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
-	"strconv"
+	"reflect"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	Development = "development"
+	Production  = "production"
 )
 
+// Fields are named in the file by their yaml tag and overridden by the
+// variable in their env tag. Durations are written like "90s" or "15m"; in
+// variables a bare number is read in the unit tag, as earlier releases
+// did. Fields tagged secret are redacted when the configuration is printed.
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
+	// Production refuses insecure defaults and never falls back to demo mode
+	Environment string `yaml:"environment" env:"APP_ENV"`
+
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	JWT       JWTConfig       `yaml:"jwt"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Account   AccountConfig   `yaml:"account"`
+	Export    ExportConfig    `yaml:"export"`
+	Trash     TrashConfig     `yaml:"trash"`
+	Expense   ExpenseConfig   `yaml:"expense"`
+	Webhook   WebhookConfig   `yaml:"webhook"`
+	Outbox    OutboxConfig    `yaml:"outbox"`
+	Feed      FeedConfig      `yaml:"feed"`
+	GraphQL   GraphQLConfig   `yaml:"graphql"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Logging   LoggingConfig   `yaml:"logging"`
+	Health    HealthConfig    `yaml:"health"`
 }
 
 type ServerConfig struct {
-	Port string
+	Port     string `yaml:"port" env:"PORT"`
+	GRPCPort string `yaml:"grpc_port" env:"GRPC_PORT"` // empty disables the gRPC server
+
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT" unit:"1s"` // for the whole request including the body
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" unit:"1s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" unit:"1s"` // live feeds extend it on every event
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" unit:"1s"`   // between keep-alive requests
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" unit:"1s"` // to drain connections and stop workers
+	DrainDelay        time.Duration `yaml:"drain_delay" env:"SERVER_DRAIN_DELAY" unit:"1s"`           // between failing readiness and closing listeners
 }
 
 type DatabaseConfig struct {
-	Type     string // "postgres" or "sqlite"
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Type     string `yaml:"type" env:"DB_TYPE"` // "postgres" or "sqlite"
+	Host     string `yaml:"host" env:"DB_HOST"`
+	Port     string `yaml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" env:"DB_USER"`
+	Password string `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	DBName   string `yaml:"name" env:"DB_NAME"` // the file for sqlite
+	SSLMode  string `yaml:"sslmode" env:"DB_SSLMODE"`
+
+	AutoMigrate bool `yaml:"auto_migrate" env:"DB_AUTO_MIGRATE"` // apply pending migrations at startup
 }
 
 type JWTConfig struct {
-	SecretKey     string
-	TokenDuration int // in seconds
+	SecretKey     string        `yaml:"secret" env:"JWT_SECRET" secret:"true"` // empty generates one per process outside production
+	TokenDuration time.Duration `yaml:"token_duration" env:"JWT_TOKEN_DURATION" unit:"1s"`
+}
+
+type RateLimitConfig struct {
+	LoginIPPerMinute      int           `yaml:"login_ip_per_minute" env:"LOGIN_RATE_LIMIT_IP_PER_MINUTE"` // requests per minute allowed from one IP
+	LoginIPBurst          int           `yaml:"login_ip_burst" env:"LOGIN_RATE_LIMIT_IP_BURST"`
+	LoginAccountPerMinute int           `yaml:"login_account_per_minute" env:"LOGIN_RATE_LIMIT_ACCOUNT_PER_MINUTE"` // requests per minute allowed for one email
+	LoginAccountBurst     int           `yaml:"login_account_burst" env:"LOGIN_RATE_LIMIT_ACCOUNT_BURST"`
+	TrustProxyHeaders     bool          `yaml:"trust_proxy_headers" env:"LOGIN_RATE_LIMIT_TRUST_PROXY"` // behind one proxy, use its X-Forwarded-For entry / X-Real-IP as client IP
+	MaxFailedAttempts     int           `yaml:"max_failed_attempts" env:"LOGIN_MAX_FAILED_ATTEMPTS"`    // failures before the account is locked
+	FailureWindow         time.Duration `yaml:"failure_window" env:"LOGIN_FAILURE_WINDOW" unit:"1s"`
+	LockoutDuration       time.Duration `yaml:"lockout_duration" env:"LOGIN_LOCKOUT_DURATION" unit:"1s"`
+	DelayBase             time.Duration `yaml:"delay_base" env:"LOGIN_DELAY_BASE_MS" unit:"1ms"` // doubled on every failure
+	DelayMax              time.Duration `yaml:"delay_max" env:"LOGIN_DELAY_MAX_MS" unit:"1ms"`
+}
+
+type AccountConfig struct {
+	EmailVerificationTTL time.Duration `yaml:"email_verification_ttl" env:"EMAIL_VERIFICATION_TTL" unit:"1s"`
+}
+
+type ExportConfig struct {
+	Dir            string        `yaml:"dir" env:"EXPORT_DIR"` // directory where archives are stored
+	LinkTTL        time.Duration `yaml:"link_ttl" env:"EXPORT_LINK_TTL" unit:"1s"`
+	AsyncThreshold int           `yaml:"async_threshold" env:"EXPORT_ASYNC_THRESHOLD"` // accounts with more expenses are exported in the background
+	PurgeInterval  time.Duration `yaml:"purge_interval" env:"EXPORT_PURGE_INTERVAL" unit:"1s"`
+}
+
+type ExpenseConfig struct {
+	RequireIfMatch bool          `yaml:"require_if_match" env:"EXPENSE_REQUIRE_IF_MATCH"`     // reject updates and deletes without an If-Match header
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl" env:"IDEMPOTENCY_KEY_TTL" unit:"1s"` // how long Idempotency-Key responses are replayed
+}
+
+type OutboxConfig struct {
+	PollInterval  time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL" unit:"1s"`
+	BatchSize     int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE"`
+	MaxAttempts   int           `yaml:"max_attempts" env:"OUTBOX_MAX_ATTEMPTS"`           // attempts per event before it is given up on
+	BackoffBase   time.Duration `yaml:"backoff_base" env:"OUTBOX_BACKOFF_BASE" unit:"1s"` // doubled after each failed attempt
+	BackoffMax    time.Duration `yaml:"backoff_max" env:"OUTBOX_BACKOFF_MAX" unit:"1s"`
+	Retention     time.Duration `yaml:"retention" env:"OUTBOX_RETENTION_HOURS" unit:"1h"` // processed events are kept this long
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"OUTBOX_PURGE_INTERVAL" unit:"1s"`
+}
+
+type FeedConfig struct {
+	Heartbeat      time.Duration `yaml:"heartbeat" env:"FEED_HEARTBEAT_INTERVAL" unit:"1s"`
+	HistorySize    int           `yaml:"history_size" env:"FEED_HISTORY_SIZE"`             // events kept per user for Last-Event-ID resume
+	BufferSize     int           `yaml:"buffer_size" env:"FEED_BUFFER_SIZE"`               // events queued per connection before it is dropped
+	MaxConnections int           `yaml:"max_connections" env:"FEED_MAX_CONNECTIONS"`       // open feed connections per user
+	ResumeWindow   time.Duration `yaml:"resume_window" env:"FEED_RESUME_WINDOW" unit:"1s"` // history is kept this long after a user's last event or connection
+}
+
+type GraphQLConfig struct {
+	MaxDepth      int `yaml:"max_depth" env:"GRAPHQL_MAX_DEPTH"`           // nesting levels of fields in one query
+	MaxComplexity int `yaml:"max_complexity" env:"GRAPHQL_MAX_COMPLEXITY"` // fields per query, with list fields counting ten times
+}
+
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" env:"METRICS_ENABLED"`           // serve /metrics
+	Token   string `yaml:"token" env:"METRICS_TOKEN" secret:"true"` // bearer token required to scrape; empty leaves /metrics open
+}
+
+type TracingConfig struct {
+	Exporter      string `yaml:"exporter" env:"TRACING_EXPORTER"`           // "none", "stdout" or "otlp"
+	OTLPEndpoint  string `yaml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT"` // OTLP/HTTP URL; empty uses OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4318
+	ServiceName   string `yaml:"service_name" env:"TRACING_SERVICE_NAME"`
+	SamplePercent int    `yaml:"sample_percent" env:"TRACING_SAMPLE_PERCENT"` // share of new traces recorded; incoming sampled traces are always kept
+}
+
+type LoggingConfig struct {
+	Level      string `yaml:"level" env:"LOG_LEVEL"`                           // debug, info, warn or error
+	Format     string `yaml:"format" env:"LOG_FORMAT"`                         // "text" or "json"
+	LevelToken string `yaml:"level_token" env:"LOG_LEVEL_TOKEN" secret:"true"` // bearer token for /log-level; empty leaves the endpoint off
+}
+
+type HealthConfig struct {
+	CheckTimeout  time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" unit:"1s"`     // for all readiness checks together
+	MinFreeDiskMB int           `yaml:"min_free_disk_mb" env:"HEALTH_MIN_FREE_DISK_MB"`         // free space below which a SQLite database is not ready
+	DetailsToken  string        `yaml:"details_token" env:"HEALTH_DETAILS_TOKEN" secret:"true"` // bearer token that shows why components are down
 }
 
-func Load() *Config {
+type WebhookConfig struct {
+	MaxAttempts  int           `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS"`           // attempts per delivery before it is marked failed
+	BackoffBase  time.Duration `yaml:"backoff_base" env:"WEBHOOK_BACKOFF_BASE" unit:"1s"` // doubled after each failed attempt
+	BackoffMax   time.Duration `yaml:"backoff_max" env:"WEBHOOK_BACKOFF_MAX" unit:"1s"`
+	DisableAfter int           `yaml:"disable_after" env:"WEBHOOK_DISABLE_AFTER"` // consecutive failed attempts before an endpoint is disabled
+	Timeout      time.Duration `yaml:"timeout" env:"WEBHOOK_TIMEOUT" unit:"1s"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"WEBHOOK_POLL_INTERVAL" unit:"1s"`
+
+	AllowPrivateURLs bool `yaml:"allow_private_urls" env:"WEBHOOK_ALLOW_PRIVATE_URLS"` // deliver to loopback and private addresses; development only
+}
+
+type TrashConfig struct {
+	Retention     time.Duration `yaml:"retention" env:"TRASH_RETENTION_DAYS" unit:"24h"` // trashed expenses are purged after this long
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"TRASH_PURGE_INTERVAL" unit:"1s"`
+}
+
+// Default returns the settings used when neither the file nor the
+// environment sets them.
+func Default() *Config {
 	return &Config{
+		Environment: Development,
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8081"),
+			Port:     "8081",
+			GRPCPort: "9090",
+
+			ReadTimeout:       30 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      time.Minute,
+			IdleTimeout:       2 * time.Minute,
+			MaxHeaderBytes:    1 << 20,
+			ShutdownTimeout:   30 * time.Second,
 		},
 		Database: DatabaseConfig{
-			Type:     getEnv("DB_TYPE", "sqlite"), // Default to sqlite
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "expense_user"),
-			Password: getEnv("DB_PASSWORD", "expense_password"),
-			DBName:   getEnv("DB_NAME", "expense_tracker.db"), // .db for sqlite
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Type:     "sqlite",
+			Host:     "localhost",
+			Port:     "5432",
+			User:     "expense_user",
+			Password: defaultDBPassword,
+			DBName:   "expense_tracker.db",
+			SSLMode:  "disable",
+
+			AutoMigrate: true,
 		},
 		JWT: JWTConfig{
-			SecretKey:     getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-			TokenDuration: getEnvAsInt("JWT_TOKEN_DURATION", 7*24*3600), // 7 days
+			TokenDuration: 7 * 24 * time.Hour,
+		},
+		RateLimit: RateLimitConfig{
+			LoginIPPerMinute:      20,
+			LoginIPBurst:          10,
+			LoginAccountPerMinute: 5,
+			LoginAccountBurst:     5,
+			MaxFailedAttempts:     5,
+			FailureWindow:         15 * time.Minute,
+			LockoutDuration:       15 * time.Minute,
+			DelayBase:             250 * time.Millisecond,
+			DelayMax:              4 * time.Second,
+		},
+		Account: AccountConfig{
+			EmailVerificationTTL: 24 * time.Hour,
+		},
+		Export: ExportConfig{
+			Dir:            "exports",
+			LinkTTL:        24 * time.Hour,
+			AsyncThreshold: 1000,
+			PurgeInterval:  time.Hour,
+		},
+		Expense: ExpenseConfig{
+			IdempotencyTTL: 24 * time.Hour,
+		},
+		Outbox: OutboxConfig{
+			PollInterval:  2 * time.Second,
+			BatchSize:     100,
+			MaxAttempts:   10,
+			BackoffBase:   5 * time.Second,
+			BackoffMax:    10 * time.Minute,
+			Retention:     7 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Feed: FeedConfig{
+			Heartbeat:      15 * time.Second,
+			HistorySize:    100,
+			BufferSize:     64,
+			MaxConnections: 10,
+			ResumeWindow:   10 * time.Minute,
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      10,
+			MaxComplexity: 5000,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Tracing: TracingConfig{
+			Exporter:      "none",
+			ServiceName:   "expense-tracker",
+			SamplePercent: 100,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Health: HealthConfig{
+			CheckTimeout:  2 * time.Second,
+			MinFreeDiskMB: 100,
+		},
+		Webhook: WebhookConfig{
+			MaxAttempts:  8,
+			BackoffBase:  30 * time.Second,
+			BackoffMax:   6 * time.Hour,
+			DisableAfter: 20,
+			Timeout:      10 * time.Second,
+			PollInterval: 5 * time.Second,
+		},
+		Trash: TrashConfig{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
 		},
 	}
 }
 
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+// Load reads the YAML file at path, when path is not empty, over the
+// defaults, then applies the environment variables and validates the
+// result. The error lists every problem found.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, fmt.Errorf("invalid environment variable: %w", err)
 	}
-	return defaultValue
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	valueStr := getEnv(key, "")
-	if value, err := strconv.Atoi(valueStr); err == nil {
-		return value
+func (c *Config) readFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	// A misspelled key would otherwise be ignored without a word
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
 	}
-	return defaultValue
-}
\ No newline at end of file
+	return nil
+}
+
+func (c *Config) IsProduction() bool {
+	return c.Environment == Production
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfigFile(t, "server:\n  port: \"9000\"\n  read_timeout: 45s\nfeed:\n  heartbeat: 20s\n")
+	// Variables win over the file; bare numbers are read in the unit tag
+	t.Setenv("FEED_HEARTBEAT_INTERVAL", "5")
+	t.Setenv("LOGIN_DELAY_BASE_MS", "100")
+	t.Setenv("TRASH_RETENTION_DAYS", "7")
+	t.Setenv("OUTBOX_BACKOFF_MAX", "15m")
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tests := []struct {
+		setting string
+		got     any
+		want    any
+	}{
+		{"server.port from the file", c.Server.Port, "9000"},
+		{"server.read_timeout from the file", c.Server.ReadTimeout, 45 * time.Second},
+		{"feed.heartbeat from the variable", c.Feed.Heartbeat, 5 * time.Second},
+		{"rate_limit.delay_base in milliseconds", c.RateLimit.DelayBase, 100 * time.Millisecond},
+		{"trash.retention in days", c.Trash.Retention, 7 * 24 * time.Hour},
+		{"outbox.backoff_max as a duration", c.Outbox.BackoffMax, 15 * time.Minute},
+		{"server.write_timeout by default", c.Server.WriteTimeout, time.Minute},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.setting, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"misspelled key", "server:\n  prot: \"9000\"\n", nil, "field prot not found"},
+		{"bad duration", "", map[string]string{"FEED_HEARTBEAT_INTERVAL": "soon"}, "FEED_HEARTBEAT_INTERVAL"},
+		{"bad number", "", map[string]string{"OUTBOX_BATCH_SIZE": "many"}, "must be a whole number"},
+		{"bad boolean", "", map[string]string{"METRICS_ENABLED": "maybe"}, "must be true or false"},
+		{"invalid setting", "", map[string]string{"APP_ENV": "production", "JWT_SECRET": ""}, "jwt.secret (JWT_SECRET): must be set in production"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			path := ""
+			if tt.file != "" {
+				path = writeConfigFile(t, tt.file)
+			}
+			if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load = %v, want an error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
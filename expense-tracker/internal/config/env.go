@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv sets the fields of v, a struct, from the variables named in
+// their env tags that are set.
+func applyEnv(v reflect.Value) error {
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			errs = append(errs, applyEnv(v.Field(i)))
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		raw, ok := os.LookupEnv(name)
+		if name == "" || !ok {
+			continue
+		}
+		if err := setField(v.Field(i), raw, field.Tag.Get("unit")); err != nil {
+			errs = append(errs, fmt.Errorf("%s=%q: %w", name, raw, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setField(field reflect.Value, raw, unit string) error {
+	if field.Kind() == reflect.String {
+		field.SetString(raw)
+		return nil
+	}
+	// Like before, an empty variable leaves the setting alone
+	if raw == "" {
+		return nil
+	}
+
+	switch {
+	case field.Type() == durationType:
+		d, err := parseDuration(raw, unit)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return errors.New("must be a whole number")
+		}
+		field.SetInt(int64(n))
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
+
+// parseDuration reads Go durations such as "90s", and bare numbers in unit.
+func parseDuration(raw, unit string) (time.Duration, error) {
+	if n, err := strconv.Atoi(raw); err == nil && unit != "" {
+		step, err := time.ParseDuration(unit)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * step, nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, errors.New(`must be a duration such as "90s" or "15m"`)
+	}
+	return d, nil
+}
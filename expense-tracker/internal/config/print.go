@@ -0,0 +1,41 @@
+package config
+
+import (
+	"io"
+	"reflect"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const redacted = "REDACTED"
+
+// Redacted returns a copy of the configuration with the secrets that are
+// set replaced, so it can be printed or logged.
+func (c *Config) Redacted() *Config {
+	out := *c
+	redact(reflect.ValueOf(&out).Elem())
+	return &out
+}
+
+func redact(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			redact(v.Field(i))
+		case field.Tag.Get("secret") == "true" && v.Field(i).String() != "":
+			v.Field(i).SetString(redacted)
+		}
+	}
+}
+
+// WriteYAML writes the redacted configuration in the format of the
+// configuration file.
+func (c *Config) WriteYAML(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
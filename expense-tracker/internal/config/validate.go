@@ -0,0 +1,252 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Shipped in docker-compose.yml and the examples; never fine in production
+	defaultDBPassword = "expense_password"
+	// Used as the JWT secret by earlier releases when JWT_SECRET was unset
+	placeholderSecret = "your-secret-key-change-in-production"
+
+	minProductionSecretLength = 32
+)
+
+// problems collects the invalid settings, each named by its key in the file
+// and its environment variable.
+type problems struct {
+	env  map[string]string
+	list []string
+}
+
+func (p *problems) add(key, format string, args ...any) {
+	name := key
+	if env := p.env[key]; env != "" {
+		name += " (" + env + ")"
+	}
+	p.list = append(p.list, name+": "+fmt.Sprintf(format, args...))
+}
+
+func (p *problems) oneOf(key, value string, allowed ...string) {
+	if !slices.Contains(allowed, value) {
+		p.add(key, "%q is not one of %s", value, strings.Join(allowed, ", "))
+	}
+}
+
+func (p *problems) port(key, value string, optional bool) {
+	if value == "" && optional {
+		return
+	}
+	if n, err := strconv.Atoi(value); err != nil || n < 1 || n > 65535 {
+		p.add(key, "%q is not a port number", value)
+	}
+}
+
+func positive[T int | time.Duration](p *problems, key string, value T) {
+	if value <= 0 {
+		p.add(key, "must be greater than zero")
+	}
+}
+
+func notNegative[T int | time.Duration](p *problems, key string, value T) {
+	if value < 0 {
+		p.add(key, "must not be negative")
+	}
+}
+
+func (p *problems) err() error {
+	if len(p.list) == 0 {
+		return nil
+	}
+	return errors.New("invalid configuration:\n  " + strings.Join(p.list, "\n  "))
+}
+
+// Validate reports every invalid setting at once. In production it also
+// refuses the insecure defaults meant for local development.
+func (c *Config) Validate() error {
+	p := &problems{env: envNames(reflect.TypeOf(*c), "")}
+
+	p.oneOf("environment", c.Environment, Development, Production)
+
+	s := c.Server
+	p.port("server.port", s.Port, false)
+	p.port("server.grpc_port", s.GRPCPort, true)
+	if s.GRPCPort != "" && s.GRPCPort == s.Port {
+		p.add("server.grpc_port", "must differ from server.port")
+	}
+	notNegative(p, "server.read_timeout", s.ReadTimeout)
+	notNegative(p, "server.read_header_timeout", s.ReadHeaderTimeout)
+	notNegative(p, "server.write_timeout", s.WriteTimeout)
+	notNegative(p, "server.idle_timeout", s.IdleTimeout)
+	positive(p, "server.max_header_bytes", s.MaxHeaderBytes)
+	positive(p, "server.shutdown_timeout", s.ShutdownTimeout)
+	notNegative(p, "server.drain_delay", s.DrainDelay)
+
+	db := c.Database
+	p.oneOf("database.type", db.Type, "sqlite", "postgres")
+	if db.DBName == "" {
+		p.add("database.name", "must not be empty")
+	}
+	if db.Type == "postgres" {
+		if db.Host == "" {
+			p.add("database.host", "must not be empty")
+		}
+		p.port("database.port", db.Port, false)
+		p.oneOf("database.sslmode", db.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	}
+
+	positive(p, "jwt.token_duration", c.JWT.TokenDuration)
+
+	rl := c.RateLimit
+	notNegative(p, "rate_limit.login_ip_per_minute", rl.LoginIPPerMinute)
+	notNegative(p, "rate_limit.login_ip_burst", rl.LoginIPBurst)
+	notNegative(p, "rate_limit.login_account_per_minute", rl.LoginAccountPerMinute)
+	notNegative(p, "rate_limit.login_account_burst", rl.LoginAccountBurst)
+	notNegative(p, "rate_limit.max_failed_attempts", rl.MaxFailedAttempts)
+	positive(p, "rate_limit.failure_window", rl.FailureWindow)
+	notNegative(p, "rate_limit.lockout_duration", rl.LockoutDuration)
+	notNegative(p, "rate_limit.delay_base", rl.DelayBase)
+	if rl.DelayMax < rl.DelayBase {
+		p.add("rate_limit.delay_max", "must not be less than rate_limit.delay_base")
+	}
+
+	positive(p, "account.email_verification_ttl", c.Account.EmailVerificationTTL)
+
+	if c.Export.Dir == "" {
+		p.add("export.dir", "must not be empty")
+	}
+	positive(p, "export.link_ttl", c.Export.LinkTTL)
+	notNegative(p, "export.async_threshold", c.Export.AsyncThreshold)
+	positive(p, "export.purge_interval", c.Export.PurgeInterval)
+
+	positive(p, "trash.retention", c.Trash.Retention)
+	positive(p, "trash.purge_interval", c.Trash.PurgeInterval)
+
+	positive(p, "expense.idempotency_ttl", c.Expense.IdempotencyTTL)
+
+	wh := c.Webhook
+	positive(p, "webhook.max_attempts", wh.MaxAttempts)
+	positive(p, "webhook.backoff_base", wh.BackoffBase)
+	if wh.BackoffMax < wh.BackoffBase {
+		p.add("webhook.backoff_max", "must not be less than webhook.backoff_base")
+	}
+	notNegative(p, "webhook.disable_after", wh.DisableAfter)
+	positive(p, "webhook.timeout", wh.Timeout)
+	positive(p, "webhook.poll_interval", wh.PollInterval)
+
+	ob := c.Outbox
+	positive(p, "outbox.poll_interval", ob.PollInterval)
+	positive(p, "outbox.batch_size", ob.BatchSize)
+	positive(p, "outbox.max_attempts", ob.MaxAttempts)
+	positive(p, "outbox.backoff_base", ob.BackoffBase)
+	if ob.BackoffMax < ob.BackoffBase {
+		p.add("outbox.backoff_max", "must not be less than outbox.backoff_base")
+	}
+	positive(p, "outbox.retention", ob.Retention)
+	positive(p, "outbox.purge_interval", ob.PurgeInterval)
+
+	positive(p, "feed.heartbeat", c.Feed.Heartbeat)
+	notNegative(p, "feed.history_size", c.Feed.HistorySize)
+	positive(p, "feed.buffer_size", c.Feed.BufferSize)
+	notNegative(p, "feed.max_connections", c.Feed.MaxConnections)
+	positive(p, "feed.resume_window", c.Feed.ResumeWindow)
+
+	positive(p, "graphql.max_depth", c.GraphQL.MaxDepth)
+	positive(p, "graphql.max_complexity", c.GraphQL.MaxComplexity)
+
+	tr := c.Tracing
+	p.oneOf("tracing.exporter", tr.Exporter, "none", "stdout", "otlp")
+	if tr.OTLPEndpoint != "" {
+		if u, err := url.Parse(tr.OTLPEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			p.add("tracing.otlp_endpoint", "%q is not an http or https URL", tr.OTLPEndpoint)
+		}
+	}
+	if tr.SamplePercent < 0 || tr.SamplePercent > 100 {
+		p.add("tracing.sample_percent", "must be between 0 and 100")
+	}
+
+	p.oneOf("logging.level", c.Logging.Level, "debug", "info", "warn", "error")
+	p.oneOf("logging.format", c.Logging.Format, "text", "json")
+
+	positive(p, "health.check_timeout", c.Health.CheckTimeout)
+	notNegative(p, "health.min_free_disk_mb", c.Health.MinFreeDiskMB)
+
+	if c.IsProduction() {
+		c.validateProduction(p)
+	}
+	return p.err()
+}
+
+func (c *Config) validateProduction(p *problems) {
+	switch secret := c.JWT.SecretKey; {
+	case secret == "":
+		p.add("jwt.secret", "must be set in production")
+	case secret == placeholderSecret:
+		p.add("jwt.secret", "is the placeholder from the examples")
+	case len(secret) < minProductionSecretLength:
+		p.add("jwt.secret", "must be at least %d characters in production", minProductionSecretLength)
+	}
+
+	if c.Database.Type == "postgres" && (c.Database.Password == "" || c.Database.Password == defaultDBPassword) {
+		p.add("database.password", "must be set to a non-default value in production")
+	}
+	if c.Webhook.AllowPrivateURLs {
+		p.add("webhook.allow_private_urls", "would let users reach internal services; not allowed in production")
+	}
+}
+
+// Warnings lists settings that are allowed but questionable for the
+// environment, to be logged at startup.
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if !c.IsProduction() {
+		if c.JWT.SecretKey == "" {
+			warnings = append(warnings, "jwt.secret is not set: tokens are signed with a random key and stop working on restart")
+		}
+		return warnings
+	}
+
+	if c.Database.Type == "sqlite" {
+		warnings = append(warnings, "database.type is sqlite in production")
+	}
+	if c.Database.Type == "postgres" && c.Database.SSLMode == "disable" && !isLocalHost(c.Database.Host) {
+		warnings = append(warnings, "database.sslmode is disable for a remote database host")
+	}
+	if c.Metrics.Enabled && c.Metrics.Token == "" {
+		warnings = append(warnings, "metrics.token is not set: /metrics is open to anyone who can reach the server")
+	}
+	if c.Logging.Level == "debug" {
+		warnings = append(warnings, "logging.level is debug in production")
+	}
+	return warnings
+}
+
+func isLocalHost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1" || strings.HasPrefix(host, "/")
+}
+
+// envNames maps the file keys of the fields of t to their environment
+// variables.
+func envNames(t reflect.Type, prefix string) map[string]string {
+	names := make(map[string]string)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := prefix + field.Tag.Get("yaml")
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			for k, v := range envNames(field.Type, key+".") {
+				names[k] = v
+			}
+			continue
+		}
+		names[key] = field.Tag.Get("env")
+	}
+	return names
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// problemKeys returns the settings an error from Validate names, in order.
+func problemKeys(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if lines[0] != "invalid configuration:" {
+		t.Fatalf("unexpected error %q", err)
+	}
+	var keys []string
+	for _, line := range lines[1:] {
+		key, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+		key, _, _ = strings.Cut(key, " (")
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string
+	}{
+		{"defaults", func(c *Config) {}, nil},
+		{"unknown environment", func(c *Config) { c.Environment = "staging" }, []string{"environment"}},
+		{"bad ports", func(c *Config) { c.Server.Port = "http"; c.Server.GRPCPort = "70000" }, []string{"server.port", "server.grpc_port"}},
+		{"gRPC disabled", func(c *Config) { c.Server.GRPCPort = "" }, nil},
+		{"same port twice", func(c *Config) { c.Server.GRPCPort = c.Server.Port }, []string{"server.grpc_port"}},
+		{"negative timeout", func(c *Config) { c.Server.ReadTimeout = -time.Second }, []string{"server.read_timeout"}},
+		{"zero shutdown timeout", func(c *Config) { c.Server.ShutdownTimeout = 0 }, []string{"server.shutdown_timeout"}},
+		{"unknown database", func(c *Config) { c.Database.Type = "mysql" }, []string{"database.type"}},
+		{"postgres settings", func(c *Config) {
+			c.Database.Type = "postgres"
+			c.Database.Host = ""
+			c.Database.SSLMode = "always"
+		}, []string{"database.host", "database.sslmode"}},
+		{"postgres settings ignored for sqlite", func(c *Config) { c.Database.SSLMode = "always" }, nil},
+		{"delay max below base", func(c *Config) { c.RateLimit.DelayMax = c.RateLimit.DelayBase / 2 }, []string{"rate_limit.delay_max"}},
+		{"zero lockout turns it off", func(c *Config) { c.RateLimit.MaxFailedAttempts = 0; c.RateLimit.LockoutDuration = 0 }, nil},
+		{"backoff max below base", func(c *Config) {
+			c.Webhook.BackoffMax = 0
+			c.Outbox.BackoffMax = 0
+		}, []string{"webhook.backoff_max", "outbox.backoff_max"}},
+		{"graphql limits", func(c *Config) { c.GraphQL.MaxDepth = 0 }, []string{"graphql.max_depth"}},
+		{"otlp endpoint", func(c *Config) { c.Tracing.OTLPEndpoint = "collector:4318" }, []string{"tracing.otlp_endpoint"}},
+		{"sample percent", func(c *Config) { c.Tracing.SamplePercent = 101 }, []string{"tracing.sample_percent"}},
+		{"every problem at once", func(c *Config) {
+			c.Logging.Level = "verbose"
+			c.Logging.Format = "xml"
+			c.Feed.BufferSize = 0
+		}, []string{"feed.buffer_size", "logging.level", "logging.format"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			tt.change(c)
+			if got := problemKeys(t, c.Validate()); !slices.Equal(got, tt.want) {
+				t.Fatalf("problems with %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNamesEnvironmentVariables(t *testing.T) {
+	c := Default()
+	c.Feed.Heartbeat = 0
+	err := c.Validate()
+	if err == nil || !strings.Contains(err.Error(), "feed.heartbeat (FEED_HEARTBEAT_INTERVAL): must be greater than zero") {
+		t.Fatalf("Validate = %v, want the key and variable named", err)
+	}
+}
+
+func TestValidateProduction(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string
+	}{
+		{"secure settings", func(c *Config) {}, nil},
+		{"no secret", func(c *Config) { c.JWT.SecretKey = "" }, []string{"jwt.secret"}},
+		{"placeholder secret", func(c *Config) { c.JWT.SecretKey = placeholderSecret }, []string{"jwt.secret"}},
+		{"short secret", func(c *Config) { c.JWT.SecretKey = secret[:31] }, []string{"jwt.secret"}},
+		{"default postgres password", func(c *Config) { c.Database.Password = defaultDBPassword }, []string{"database.password"}},
+		{"empty postgres password", func(c *Config) { c.Database.Password = "" }, []string{"database.password"}},
+		{"sqlite needs no password", func(c *Config) { c.Database.Type = "sqlite"; c.Database.Password = "" }, nil},
+		{"private webhook URLs", func(c *Config) { c.Webhook.AllowPrivateURLs = true }, []string{"webhook.allow_private_urls"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			c.Environment = Production
+			c.JWT.SecretKey = secret
+			c.Database.Type = "postgres"
+			c.Database.Password = "a-real-password"
+			tt.change(c)
+			if got := problemKeys(t, c.Validate()); !slices.Equal(got, tt.want) {
+				t.Fatalf("problems with %q, want %q", got, tt.want)
+			}
+
+			// Development accepts the same settings
+			c.Environment = Development
+			if err := c.Validate(); err != nil {
+				t.Fatalf("in development: %v", err)
+			}
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string // prefixes of the warnings
+	}{
+		{"development", func(c *Config) { c.JWT.SecretKey = "set" }, nil},
+		{"development without a secret", func(c *Config) {}, []string{"jwt.secret is not set"}},
+		{"production", func(c *Config) { c.Environment = Production; c.Database.Type = "postgres"; c.Metrics.Token = "t" }, nil},
+		{"production on sqlite", func(c *Config) { c.Environment = Production; c.Metrics.Token = "t" }, []string{"database.type is sqlite"}},
+		{"remote database without TLS", func(c *Config) {
+			c.Environment = Production
+			c.Database.Type = "postgres"
+			c.Database.Host = "db.internal"
+			c.Metrics.Token = "t"
+		}, []string{"database.sslmode is disable"}},
+		{"open metrics and debug logs", func(c *Config) {
+			c.Environment = Production
+			c.Database.Type = "postgres"
+			c.Logging.Level = "debug"
+		}, []string{"metrics.token is not set", "logging.level is debug"}},
+		{"metrics turned off", func(c *Config) { c.Environment = Production; c.Database.Type = "postgres"; c.Metrics.Enabled = false }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			tt.change(c)
+			got := c.Warnings()
+			if len(got) != len(tt.want) {
+				t.Fatalf("warnings %q, want %q", got, tt.want)
+			}
+			for i, prefix := range tt.want {
+				if !strings.HasPrefix(got[i], prefix) {
+					t.Fatalf("warnings %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"time"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportPending    DataExportStatus = "pending"
+	DataExportProcessing DataExportStatus = "processing"
+	DataExportCompleted  DataExportStatus = "completed"
+	DataExportFailed     DataExportStatus = "failed"
+)
+
+// DataExport tracks one archive of everything stored about a user.
+type DataExport struct {
+	ID          string           `json:"id" db:"id"`
+	UserID      string           `json:"user_id" db:"user_id"`
+	Status      DataExportStatus `json:"status" db:"status"`
+	FileName    string           `json:"-" db:"file_name"`
+	Error       string           `json:"error,omitempty" db:"error"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty" db:"completed_at"`
+}
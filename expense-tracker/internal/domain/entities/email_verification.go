@@ -0,0 +1,15 @@
+package entities
+
+import (
+	"time"
+)
+
+// EmailVerification is a pending change of a user's email address. The new
+// address only replaces the current one once the token has been confirmed.
+type EmailVerification struct {
+	UserID    string    `json:"user_id" db:"user_id"`
+	Email     string    `json:"email" db:"email"`
+	TokenHash string    `json:"-" db:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
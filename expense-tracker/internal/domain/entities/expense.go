@@ -6,12 +6,14 @@ import (
 )
 
 type Expense struct {
-	ID          string                `json:"id"`
-	UserID      string                `json:"user_id"`
-	Amount      float64               `json:"amount"`
-	Category    valueobjects.Category `json:"category"`
-	Description string                `json:"description"`
-	Date        time.Time             `json:"date"`
-	CreatedAt   time.Time             `json:"created_at"`
-	UpdatedAt   time.Time             `json:"updated_at"`
-}
\ No newline at end of file
+	ID          string                `json:"id" db:"id"`
+	UserID      string                `json:"user_id" db:"user_id"`
+	Amount      float64               `json:"amount" db:"amount"`
+	Category    valueobjects.Category `json:"category" db:"category"`
+	Description string                `json:"description" db:"description"`
+	Date        time.Time             `json:"date" db:"date"`
+	CreatedAt   time.Time             `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at" db:"updated_at"`
+	DeletedAt   *time.Time            `json:"deleted_at,omitempty" db:"deleted_at"`
+	Version     int                   `json:"version" db:"version"`
+}
@@ -0,0 +1,76 @@
+package entities
+
+import (
+	"time"
+
+	"expense-tracker/internal/domain/valueobjects"
+)
+
+type ExpenseRevisionAction string
+
+const (
+	ExpenseCreated  ExpenseRevisionAction = "created"
+	ExpenseUpdated  ExpenseRevisionAction = "updated"
+	ExpenseDeleted  ExpenseRevisionAction = "deleted"
+	ExpenseRestored ExpenseRevisionAction = "restored"
+	ExpenseReverted ExpenseRevisionAction = "reverted"
+)
+
+// FieldChange is the old and new value of one expense field.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// ExpenseValues are the user-editable fields of an expense, as they were
+// after a revision was applied.
+type ExpenseValues struct {
+	Amount      float64               `json:"amount"`
+	Category    valueobjects.Category `json:"category"`
+	Description string                `json:"description"`
+	Date        time.Time             `json:"date"`
+}
+
+// ExpenseRevision is an immutable record of one change to an expense.
+// Revisions are numbered from 1 per expense.
+type ExpenseRevision struct {
+	ID         string                `json:"id"`
+	ExpenseID  string                `json:"expense_id"`
+	UserID     string                `json:"user_id"`
+	ActorID    string                `json:"actor_id"`
+	Revision   int                   `json:"revision"`
+	Action     ExpenseRevisionAction `json:"action"`
+	Changes    []FieldChange         `json:"changes"`
+	Values     ExpenseValues         `json:"values"`
+	RevertedTo *int                  `json:"reverted_to,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+}
+
+// ValuesOf returns the user-editable fields of an expense.
+func ValuesOf(expense *Expense) ExpenseValues {
+	return ExpenseValues{
+		Amount:      expense.Amount,
+		Category:    expense.Category,
+		Description: expense.Description,
+		Date:        expense.Date,
+	}
+}
+
+// Diff lists the fields that differ between two sets of values.
+func (v ExpenseValues) Diff(next ExpenseValues) []FieldChange {
+	changes := []FieldChange{}
+	if v.Amount != next.Amount {
+		changes = append(changes, FieldChange{Field: "amount", Old: v.Amount, New: next.Amount})
+	}
+	if v.Category != next.Category {
+		changes = append(changes, FieldChange{Field: "category", Old: string(v.Category), New: string(next.Category)})
+	}
+	if v.Description != next.Description {
+		changes = append(changes, FieldChange{Field: "description", Old: v.Description, New: next.Description})
+	}
+	if !v.Date.Equal(next.Date) {
+		changes = append(changes, FieldChange{Field: "date", Old: v.Date.Format("2006-01-02"), New: next.Date.Format("2006-01-02")})
+	}
+	return changes
+}
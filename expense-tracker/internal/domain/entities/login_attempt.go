@@ -0,0 +1,13 @@
+package entities
+
+import (
+	"time"
+)
+
+type LoginAttempt struct {
+	ID        string    `json:"id" db:"id"`
+	Email     string    `json:"email" db:"email"`
+	IPAddress string    `json:"ip_address" db:"ip_address"`
+	Success   bool      `json:"success" db:"success"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
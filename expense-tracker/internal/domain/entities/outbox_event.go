@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"time"
+)
+
+// OutboxEvent is a domain event stored in the same transaction as the change
+// that caused it, until the relay has delivered it. ProcessedAt is set once
+// it is delivered or given up on; LastError then tells which.
+type OutboxEvent struct {
+	ID            int64      `json:"id" db:"id"`
+	UserID        string     `json:"user_id" db:"user_id"`
+	EventType     string     `json:"event_type" db:"event_type"`
+	Payload       string     `json:"payload" db:"payload"`
+	Attempts      int        `json:"attempts" db:"attempts"`
+	LastError     string     `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty" db:"processed_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
@@ -5,10 +5,11 @@ import (
 )
 
 type User struct {
-	ID        string    `json:"id"`
-	Email     string    `json:"email"`
-	Password  string    `json:"-"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-}
\ No newline at end of file
+	ID         string     `json:"id" db:"id"`
+	Email      string     `json:"email" db:"email"`
+	Password   string     `json:"-" db:"password"`
+	Name       string     `json:"name" db:"name"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+	DisabledAt *time.Time `json:"-" db:"disabled_at"` // set while an operator has disabled the account
+}
@@ -0,0 +1,68 @@
+package entities
+
+import (
+	"time"
+)
+
+// Webhook event types. BudgetExceeded can be subscribed to ahead of budgets
+// being tracked; nothing emits it yet.
+const (
+	EventExpenseCreated = "expense.created"
+	EventExpenseUpdated = "expense.updated"
+	EventExpenseDeleted = "expense.deleted"
+	EventBudgetExceeded = "budget.exceeded"
+)
+
+var WebhookEvents = []string{EventExpenseCreated, EventExpenseUpdated, EventExpenseDeleted, EventBudgetExceeded}
+
+// WebhookEndpoint is a URL that receives the user's events. Endpoints that
+// keep failing are deactivated and DisabledAt is set.
+type WebhookEndpoint struct {
+	ID                  string     `json:"id" db:"id"`
+	UserID              string     `json:"user_id" db:"user_id"`
+	URL                 string     `json:"url" db:"url"`
+	Secret              string     `json:"-" db:"secret"`
+	Events              []string   `json:"events" db:"-"`
+	Active              bool       `json:"active" db:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures" db:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Subscribes reports whether the endpoint wants the event.
+func (e *WebhookEndpoint) Subscribes(event string) bool {
+	for _, subscribed := range e.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event sent to one endpoint, with the outcome of
+// its latest attempt.
+type WebhookDelivery struct {
+	ID            string                `json:"id" db:"id"`
+	EndpointID    string                `json:"endpoint_id" db:"endpoint_id"`
+	UserID        string                `json:"user_id" db:"user_id"`
+	Event         string                `json:"event" db:"event"`
+	Payload       string                `json:"payload" db:"payload"`
+	Status        WebhookDeliveryStatus `json:"status" db:"status"`
+	Attempts      int                   `json:"attempts" db:"attempts"`
+	NextAttemptAt *time.Time            `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	ResponseCode  int                   `json:"response_code" db:"response_code"`
+	ResponseBody  string                `json:"response_body,omitempty" db:"response_body"`
+	Error         string                `json:"error,omitempty" db:"error"`
+	CreatedAt     time.Time             `json:"created_at" db:"created_at"`
+	LastAttemptAt *time.Time            `json:"last_attempt_at,omitempty" db:"last_attempt_at"`
+	LockedUntil   *time.Time            `json:"-" db:"locked_until"` // set while an instance is attempting it
+}
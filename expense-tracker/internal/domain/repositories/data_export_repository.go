@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"expense-tracker/internal/domain/entities"
+	"time"
+)
+
+type DataExportRepository interface {
+	Create(ctx context.Context, export *entities.DataExport) error
+	Update(ctx context.Context, export *entities.DataExport) error
+	FindByID(ctx context.Context, id string) (*entities.DataExport, error)
+	FindByUserID(ctx context.Context, userID string) ([]*entities.DataExport, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
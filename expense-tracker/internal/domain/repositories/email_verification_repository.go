@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"expense-tracker/internal/domain/entities"
+)
+
+type EmailVerificationRepository interface {
+	// Save replaces any pending verification of the same user.
+	Save(ctx context.Context, verification *entities.EmailVerification) error
+	FindByUserID(ctx context.Context, userID string) (*entities.EmailVerification, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*entities.EmailVerification, error)
+	DeleteByUserID(ctx context.Context, userID string) error
+}
@@ -2,10 +2,15 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"expense-tracker/internal/domain/entities"
 	"time"
 )
 
+// ErrStaleVersion is returned by Update when the stored expense no longer
+// has the version the caller read.
+var ErrStaleVersion = errors.New("expense was modified concurrently")
+
 type ExpenseFilter struct {
 	UserID    string
 	StartDate *time.Time
@@ -17,7 +22,26 @@ type ExpenseRepository interface {
 	Create(ctx context.Context, expense *entities.Expense) error
 	FindByID(ctx context.Context, id string) (*entities.Expense, error)
 	FindByUserID(ctx context.Context, userID string, filter ExpenseFilter) ([]*entities.Expense, error)
+	// Update only succeeds while the stored version equals expense.Version,
+	// otherwise it returns ErrStaleVersion. The version is incremented on
+	// every write, including Delete and Restore, and the stored timestamps
+	// and version are set on the expense.
 	Update(ctx context.Context, expense *entities.Expense) error
-	Delete(ctx context.Context, id string) error
+	// Delete moves the expense to the trash. Trashed expenses are excluded
+	// from every query except the trash ones below. Like Update, it returns
+	// ErrStaleVersion unless the stored expense is live at expense.Version.
+	Delete(ctx context.Context, expense *entities.Expense) error
 	GetTotalByCategory(ctx context.Context, userID string, startDate, endDate time.Time) (map[string]float64, error)
-}
\ No newline at end of file
+	FindTrashedByID(ctx context.Context, id string) (*entities.Expense, error)
+	FindTrashed(ctx context.Context, userID string) ([]*entities.Expense, error)
+	// Restore takes the expense out of the trash, returning ErrStaleVersion
+	// unless it is trashed at expense.Version.
+	Restore(ctx context.Context, expense *entities.Expense) error
+	// PermanentDelete removes the expense whether or not it is trashed. Its
+	// history goes first, with ExpenseRevisionRepository.DeleteByExpenseID.
+	PermanentDelete(ctx context.Context, id string) error
+	// PurgeDeletedBefore permanently removes expenses trashed before the
+	// given time. Their history goes first, with
+	// ExpenseRevisionRepository.DeleteTrashedBefore.
+	PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error)
+}
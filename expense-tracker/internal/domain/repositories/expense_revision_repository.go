@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+	"expense-tracker/internal/domain/entities"
+	"time"
+)
+
+// ExpenseRevisionRepository stores the change history of expenses.
+// Revisions are append-only; they are only removed together with their
+// expense.
+type ExpenseRevisionRepository interface {
+	// Create assigns the ID, the next revision number for the expense and
+	// the creation time.
+	Create(ctx context.Context, revision *entities.ExpenseRevision) error
+	FindByExpenseID(ctx context.Context, expenseID string) ([]*entities.ExpenseRevision, error)
+	// FindByExpenseIDs loads the revisions of several expenses of one user
+	// at once, ordered by expense and revision.
+	FindByExpenseIDs(ctx context.Context, userID string, expenseIDs []string) ([]*entities.ExpenseRevision, error)
+	FindByRevision(ctx context.Context, expenseID string, revision int) (*entities.ExpenseRevision, error)
+	FindByUserID(ctx context.Context, userID string) ([]*entities.ExpenseRevision, error)
+	// DeleteByExpenseID removes the history of an expense about to be
+	// permanently deleted.
+	DeleteByExpenseID(ctx context.Context, expenseID string) error
+	// DeleteTrashedBefore removes the history of the expenses trashed before
+	// the given time, which are about to be purged.
+	DeleteTrashedBefore(ctx context.Context, before time.Time) error
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// LeaseRepository hands out named leases, so that a job runs in only one
+// process at a time. Expiry is judged by the clocks of the processes, which
+// should be roughly in sync.
+type LeaseRepository interface {
+	// Acquire takes the lease for holder, or extends it if holder already
+	// has it, until now plus ttl. It returns false while another holder's
+	// lease has not expired.
+	Acquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error)
+	// Release ends the lease if holder has it.
+	Release(ctx context.Context, name, holder string) error
+}
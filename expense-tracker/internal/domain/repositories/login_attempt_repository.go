@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+	"expense-tracker/internal/domain/entities"
+	"time"
+)
+
+type LoginAttemptRepository interface {
+	Record(ctx context.Context, attempt *entities.LoginAttempt) error
+	// FindFailuresSince returns the failed attempts for email recorded after
+	// since and after the most recent successful login, oldest first.
+	FindFailuresSince(ctx context.Context, email string, since time.Time) ([]*entities.LoginAttempt, error)
+	// FindByEmail returns the newest attempts first; a limit of zero or less
+	// returns all of them.
+	FindByEmail(ctx context.Context, email string, limit int) ([]*entities.LoginAttempt, error)
+	// DeleteFailures removes the failed attempts for email, which lifts a
+	// lockout.
+	DeleteFailures(ctx context.Context, email string) error
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"expense-tracker/internal/domain/entities"
+	"time"
+)
+
+type OutboxRepository interface {
+	// Append assigns an increasing ID and the creation time.
+	Append(ctx context.Context, event *entities.OutboxEvent) error
+	// FindUnprocessed returns events not processed yet in the order they
+	// were appended, including those waiting for a retry.
+	FindUnprocessed(ctx context.Context, limit int) ([]*entities.OutboxEvent, error)
+	// FindDue returns the unprocessed events that may be attempted at now,
+	// in the order they were appended. Events waiting for a retry are left
+	// out, and so are the later events of their users.
+	FindDue(ctx context.Context, now time.Time, limit int) ([]*entities.OutboxEvent, error)
+	// FindByUserID returns every event of the user still stored, processed
+	// or not, in the order they were appended.
+	FindByUserID(ctx context.Context, userID string) ([]*entities.OutboxEvent, error)
+	// Update saves the attempts, error, next attempt and processed time.
+	Update(ctx context.Context, event *entities.OutboxEvent) error
+	DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error)
+}
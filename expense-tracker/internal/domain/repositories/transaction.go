@@ -0,0 +1,13 @@
+package repositories
+
+import "context"
+
+// TxManager runs fn in a transaction. Repositories called with the context
+// passed to fn take part in that transaction. Calls nest: an inner
+// WithinTransaction becomes a savepoint that rolls back on its own when fn
+// fails. The outermost transaction may be retried when the database reports
+// a transient conflict, so fn must not have side effects outside the
+// repositories.
+type TxManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -10,4 +10,9 @@ type UserRepository interface {
 	FindByEmail(ctx context.Context, email string) (*entities.User, error)
 	FindByID(ctx context.Context, id string) (*entities.User, error)
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
-}
\ No newline at end of file
+	Update(ctx context.Context, user *entities.User) error
+	// Delete removes the user together with their expenses and every other
+	// record that belongs to them. It runs several statements, so callers
+	// run it within a transaction.
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+	"expense-tracker/internal/domain/entities"
+	"time"
+)
+
+type WebhookEndpointRepository interface {
+	Create(ctx context.Context, endpoint *entities.WebhookEndpoint) error
+	Update(ctx context.Context, endpoint *entities.WebhookEndpoint) error
+	FindByID(ctx context.Context, id string) (*entities.WebhookEndpoint, error)
+	FindByUserID(ctx context.Context, userID string) ([]*entities.WebhookEndpoint, error)
+	// Delete removes the endpoint. Its delivery log goes first, with
+	// WebhookDeliveryRepository.DeleteByEndpointID.
+	Delete(ctx context.Context, id string) error
+}
+
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, delivery *entities.WebhookDelivery) error
+	Update(ctx context.Context, delivery *entities.WebhookDelivery) error
+	FindByID(ctx context.Context, id string) (*entities.WebhookDelivery, error)
+	// FindByEndpointID returns the newest deliveries first; limit <= 0 returns all.
+	FindByEndpointID(ctx context.Context, endpointID string, limit int) ([]*entities.WebhookDelivery, error)
+	// ClaimDue claims the pending deliveries whose next attempt is at or
+	// before now and returns them, oldest first. Claimed deliveries are not
+	// returned again until lockedUntil, so instances sharing the database
+	// never attempt the same delivery at once. Update releases the claim.
+	ClaimDue(ctx context.Context, now, lockedUntil time.Time, limit int) ([]*entities.WebhookDelivery, error)
+	// FailPending marks every pending delivery of the endpoint as failed.
+	FailPending(ctx context.Context, endpointID, reason string) error
+	// DeleteByEndpointID removes the delivery log of an endpoint about to be
+	// deleted.
+	DeleteByEndpointID(ctx context.Context, endpointID string) error
+}
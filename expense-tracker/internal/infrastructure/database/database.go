@@ -9,15 +9,7 @@ import (
 func Connect(cfg config.DatabaseConfig) (*sqlx.DB, error) {
 	switch cfg.Type {
 	case "postgres":
-		pgConfig := PostgresConfig{
-			Host:     cfg.Host,
-			Port:     cfg.Port,
-			User:     cfg.User,
-			Password: cfg.Password,
-			DBName:   cfg.DBName,
-			SSLMode:  cfg.SSLMode,
-		}
-		return NewPostgresDB(pgConfig)
+		return NewPostgresDB(postgresConfig(cfg))
 	case "sqlite":
 		sqliteConfig := SQLiteConfig{
 			DBPath: cfg.DBName,
@@ -30,4 +22,15 @@ func Connect(cfg config.DatabaseConfig) (*sqlx.DB, error) {
 		}
 		return NewSQLiteDB(sqliteConfig)
 	}
-}
\ No newline at end of file
+}
+
+func postgresConfig(cfg config.DatabaseConfig) PostgresConfig {
+	return PostgresConfig{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		User:     cfg.User,
+		Password: cfg.Password,
+		DBName:   cfg.DBName,
+		SSLMode:  cfg.SSLMode,
+	}
+}
@@ -0,0 +1,23 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Vacuum reclaims the space of deleted rows and refreshes the statistics
+// the query planner uses. Neither statement may run inside a transaction.
+func Vacuum(ctx context.Context, db *sqlx.DB) error {
+	statements := []string{`VACUUM ANALYZE`}
+	if db.DriverName() == "sqlite3" {
+		statements = []string{`VACUUM`, `ANALYZE`}
+	}
+
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// postgresMigrationLock is the pg_advisory_lock key held while migrating.
+const postgresMigrationLock = 7294031122
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	Version   int       `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// Migrator applies the embedded migrations of one dialect and records them
+// in schema_migrations. Runs are serialized across instances: Postgres holds
+// an advisory lock, SQLite runs everything in one BEGIN IMMEDIATE transaction.
+type Migrator struct {
+	db         *sqlx.DB
+	dialect    string
+	migrations []Migration
+}
+
+// NewMigrator loads the migrations for the database's driver from the
+// "sqlite" or "postgres" directory of fsys.
+func NewMigrator(db *sqlx.DB, fsys fs.FS) (*Migrator, error) {
+	dialect := "postgres"
+	if db.DriverName() == "sqlite3" {
+		dialect = "sqlite"
+	}
+
+	migrations, err := loadMigrations(fsys, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Up applies all pending migrations and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.run(ctx, func(conn *sqlx.Conn, done map[int]appliedMigration) error {
+		if err := m.verify(done); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			slog.Info("applying migration", "version", migration.Version, "name", migration.Name)
+			err := m.step(ctx, conn, migration.Up, `
+				INSERT INTO schema_migrations (version, name, checksum, applied_at)
+				VALUES ($1, $2, $3, $4)
+			`, migration.Version, migration.Name, migration.Checksum, time.Now())
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the latest steps applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	rolledBack := 0
+	err := m.run(ctx, func(conn *sqlx.Conn, done map[int]appliedMigration) error {
+		if err := m.verify(done); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && rolledBack < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %04d_%s has no down script", migration.Version, migration.Name)
+			}
+
+			slog.Info("rolling back migration", "version", migration.Version, "name", migration.Name)
+			err := m.step(ctx, conn, migration.Down, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			rolledBack++
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Version returns the highest applied migration version, 0 if none.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	if err := m.ensureTable(ctx, m.db); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	err := m.db.GetContext(ctx, &version, `SELECT MAX(version) FROM schema_migrations`)
+	return int(version.Int64), err
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.ensureTable(ctx, m.db); err != nil {
+		return nil, err
+	}
+
+	done, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if a, ok := done[migration.Version]; ok {
+			appliedAt := a.AppliedAt
+			statuses[i].Applied = true
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// Latest returns the highest version known to this binary.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// verify fails when an applied migration was edited or is unknown to this
+// binary, instead of silently running against an unexpected schema.
+func (m *Migrator) verify(done map[int]appliedMigration) error {
+	known := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	for version, a := range done {
+		migration, ok := known[version]
+		if !ok {
+			return fmt.Errorf("database has migration %04d_%s which is unknown to this build", version, a.Name)
+		}
+		if migration.Checksum != a.Checksum {
+			return fmt.Errorf("checksum mismatch for migration %04d_%s: it was modified after being applied", version, migration.Name)
+		}
+	}
+	return nil
+}
+
+// run holds the migration lock on a dedicated connection for the duration
+// of fn and passes it the migrations applied so far.
+func (m *Migrator) run(ctx context.Context, fn func(conn *sqlx.Conn, done map[int]appliedMigration) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.dialect == "postgres" {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, postgresMigrationLock); err != nil {
+			return err
+		}
+		defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, postgresMigrationLock)
+	} else {
+		// SQLite DDL is transactional, so the whole run is one write transaction
+		if _, err := conn.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+			return err
+		}
+	}
+
+	// The table is created under the lock so concurrent first runs do not race
+	err = m.ensureTable(ctx, conn)
+	var done map[int]appliedMigration
+	if err == nil {
+		done, err = m.applied(ctx, conn)
+	}
+	if err == nil {
+		err = fn(conn, done)
+	}
+
+	if m.dialect == "sqlite" {
+		if err != nil {
+			conn.ExecContext(context.Background(), `ROLLBACK`)
+			return err
+		}
+		_, err = conn.ExecContext(ctx, `COMMIT`)
+	}
+	return err
+}
+
+// step executes a migration script and its bookkeeping statement. On Postgres
+// each step gets its own transaction; on SQLite the caller already holds one.
+func (m *Migrator) step(ctx context.Context, conn *sqlx.Conn, script, record string, args ...interface{}) error {
+	if m.dialect == "sqlite" {
+		if _, err := conn.ExecContext(ctx, script); err != nil {
+			return err
+		}
+		_, err := conn.ExecContext(ctx, record, args...)
+		return err
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, db sqlx.ExecerContext) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum VARCHAR(64) NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`)
+	return err
+}
+
+func (m *Migrator) applied(ctx context.Context, db sqlx.QueryerContext) (map[int]appliedMigration, error) {
+	var rows []appliedMigration
+	if err := sqlx.SelectContext(ctx, db, &rows, `SELECT version, name, checksum, applied_at FROM schema_migrations`); err != nil {
+		return nil, err
+	}
+
+	done := make(map[int]appliedMigration, len(rows))
+	for _, row := range rows {
+		done[row.Version] = row
+	}
+	return done, nil
+}
@@ -0,0 +1,257 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func migrationFS(files map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, content := range files {
+		fsys["sqlite/"+name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return fsys
+}
+
+var testMigrations = map[string]string{
+	"0001_accounts.up.sql":   "CREATE TABLE accounts (id INTEGER PRIMARY KEY);",
+	"0001_accounts.down.sql": "DROP TABLE accounts;",
+	"0002_notes.up.sql":      "CREATE TABLE notes (id INTEGER PRIMARY KEY);",
+	"0002_notes.down.sql":    "DROP TABLE notes;",
+}
+
+func withFiles(base map[string]string, changes map[string]string) map[string]string {
+	files := make(map[string]string, len(base)+len(changes))
+	for name, content := range base {
+		files[name] = content
+	}
+	for name, content := range changes {
+		if content == "" {
+			delete(files, name)
+		} else {
+			files[name] = content
+		}
+	}
+	return files
+}
+
+func openSQLite(t *testing.T, path string) *sqlx.DB {
+	t.Helper()
+	db, err := NewSQLiteDB(SQLiteConfig{DBPath: path})
+	if err != nil {
+		t.Fatalf("opening sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newMigrator(t *testing.T, db *sqlx.DB, files map[string]string) *Migrator {
+	t.Helper()
+	migrator, err := NewMigrator(db, migrationFS(files))
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	return migrator
+}
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		versions []int
+		wantErr  string
+	}{
+		{
+			name:     "sorted by version",
+			files:    withFiles(testMigrations, map[string]string{"0010_tags.up.sql": "SELECT 1;"}),
+			versions: []int{1, 2, 10},
+		},
+		{
+			name:     "other files are ignored",
+			files:    withFiles(testMigrations, map[string]string{"README.md": "notes", "0003_draft.sql": "SELECT 1;"}),
+			versions: []int{1, 2},
+		},
+		{
+			name:    "missing up script",
+			files:   withFiles(testMigrations, map[string]string{"0002_notes.up.sql": ""}),
+			wantErr: "has no up script",
+		},
+		{
+			name:    "conflicting names",
+			files:   withFiles(testMigrations, map[string]string{"0002_notes.down.sql": "", "0002_other.down.sql": "SELECT 1;"}),
+			wantErr: "conflicting names",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(migrationFS(tt.files), "sqlite")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadMigrations error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadMigrations: %v", err)
+			}
+
+			var versions []int
+			for _, m := range migrations {
+				versions = append(versions, m.Version)
+				sum := sha256.Sum256([]byte(m.Up))
+				if m.Checksum != hex.EncodeToString(sum[:]) {
+					t.Errorf("migration %d: checksum %s is not the SHA-256 of its up script", m.Version, m.Checksum)
+				}
+			}
+			if !slices.Equal(versions, tt.versions) {
+				t.Fatalf("versions = %v, want %v", versions, tt.versions)
+			}
+		})
+	}
+}
+
+func TestMigratorVerifiesAppliedMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes map[string]string
+		wantErr string
+	}{
+		{
+			name:    "edited up script",
+			changes: map[string]string{"0001_accounts.up.sql": "CREATE TABLE accounts (id INTEGER PRIMARY KEY, name TEXT);"},
+			wantErr: "checksum mismatch for migration 0001_accounts",
+		},
+		{
+			name:    "migration missing from the build",
+			changes: map[string]string{"0002_notes.up.sql": "", "0002_notes.down.sql": ""},
+			wantErr: "0002_notes which is unknown to this build",
+		},
+		{
+			name:    "edited down script",
+			changes: map[string]string{"0002_notes.down.sql": "DROP TABLE IF EXISTS notes;"},
+		},
+		{
+			name:    "new migration",
+			changes: map[string]string{"0003_tags.up.sql": "CREATE TABLE tags (id INTEGER PRIMARY KEY);", "0003_tags.down.sql": "DROP TABLE tags;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := openSQLite(t, filepath.Join(t.TempDir(), "test.db"))
+			if _, err := newMigrator(t, db, testMigrations).Up(ctx); err != nil {
+				t.Fatalf("first Up: %v", err)
+			}
+
+			migrator := newMigrator(t, db, withFiles(testMigrations, tt.changes))
+			_, upErr := migrator.Up(ctx)
+			_, downErr := migrator.Down(ctx, 1)
+			for op, err := range map[string]error{"Up": upErr, "Down": downErr} {
+				if tt.wantErr == "" && err != nil {
+					t.Errorf("%s: %v", op, err)
+				}
+				if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+					t.Errorf("%s error = %v, want %q", op, err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestMigratorUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t, filepath.Join(t.TempDir(), "test.db"))
+	migrator := newMigrator(t, db, testMigrations)
+
+	steps := []struct {
+		op          string
+		steps       int
+		wantChanged int
+		wantVersion int
+	}{
+		{"up", 0, 2, 2},
+		{"up", 0, 0, 2},
+		{"down", 1, 1, 1},
+		{"up", 0, 1, 2},
+		{"down", 5, 2, 0},
+	}
+	for i, step := range steps {
+		var changed int
+		var err error
+		if step.op == "up" {
+			changed, err = migrator.Up(ctx)
+		} else {
+			changed, err = migrator.Down(ctx, step.steps)
+		}
+		if err != nil {
+			t.Fatalf("step %d (%s): %v", i+1, step.op, err)
+		}
+		if changed != step.wantChanged {
+			t.Errorf("step %d (%s): changed %d migrations, want %d", i+1, step.op, changed, step.wantChanged)
+		}
+		if version, _ := migrator.Version(ctx); version != step.wantVersion {
+			t.Errorf("step %d (%s): version %d, want %d", i+1, step.op, version, step.wantVersion)
+		}
+	}
+}
+
+func TestMigratorRollsBackFailedRunOnSQLite(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t, filepath.Join(t.TempDir(), "test.db"))
+	migrator := newMigrator(t, db, withFiles(testMigrations, map[string]string{"0002_notes.up.sql": "CREATE TABLE notes (;"}))
+
+	if _, err := migrator.Up(ctx); err == nil || !strings.Contains(err.Error(), "migration 0002_notes") {
+		t.Fatalf("Up error = %v, want a failure of 0002_notes", err)
+	}
+	if version, _ := migrator.Version(ctx); version != 0 {
+		t.Fatalf("version after failed run = %d, want 0", version)
+	}
+	var tables int
+	db.Get(&tables, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'accounts'`)
+	if tables != 0 {
+		t.Fatal("0001_accounts was kept although the run failed")
+	}
+}
+
+// The up scripts fail if run twice, so concurrent runs only pass if they
+// are serialized and each sees what the other applied.
+func TestMigratorSerializesConcurrentRuns(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	const runs = 4
+	var wg sync.WaitGroup
+	applied := make([]int, runs)
+	errs := make([]error, runs)
+	for i := 0; i < runs; i++ {
+		migrator := newMigrator(t, openSQLite(t, path), testMigrations)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			applied[i], errs[i] = migrator.Up(ctx)
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for i := 0; i < runs; i++ {
+		if errs[i] != nil {
+			t.Fatalf("run %d: %v", i, errs[i])
+		}
+		total += applied[i]
+	}
+	if total != 2 {
+		t.Fatalf("runs applied %d migrations in total, want 2", total)
+	}
+}
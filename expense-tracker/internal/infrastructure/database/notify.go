@@ -0,0 +1,60 @@
+package database
+
+import (
+	"log/slog"
+	"time"
+
+	"expense-tracker/internal/config"
+
+	"github.com/lib/pq"
+)
+
+// Listener receives Postgres notifications on one channel over a dedicated
+// connection.
+type Listener struct {
+	listener *pq.Listener
+	done     chan struct{}
+}
+
+// Listen calls notify for every notification on channel, and after the
+// connection is re-established, as notifications may have been missed while
+// it was down. It returns nil for databases other than Postgres, which have
+// no notifications.
+func Listen(cfg config.DatabaseConfig, channel string, notify func()) (*Listener, error) {
+	if cfg.Type != "postgres" {
+		return nil, nil
+	}
+
+	listener := pq.NewListener(postgresConfig(cfg).dsn(), time.Second, time.Minute,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				slog.Warn("postgres listener error", "channel", channel, "error", err)
+			}
+		})
+	if err := listener.Listen(channel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	l := &Listener{listener: listener, done: make(chan struct{})}
+	go func() {
+		for {
+			select {
+			case <-l.done:
+				return
+			// A nil notification is sent after a reconnect
+			case <-listener.Notify:
+				notify()
+			}
+		}
+	}()
+	return l, nil
+}
+
+func (l *Listener) Close() error {
+	if l == nil {
+		return nil
+	}
+	close(l.done)
+	return l.listener.Close()
+}
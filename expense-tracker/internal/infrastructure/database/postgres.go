@@ -3,7 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -19,11 +19,13 @@ type PostgresConfig struct {
 	SSLMode  string
 }
 
-func NewPostgresDB(cfg PostgresConfig) (*sqlx.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+func (cfg PostgresConfig) dsn() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode)
+}
 
-	db, err := sqlx.Connect("postgres", dsn)
+func NewPostgresDB(cfg PostgresConfig) (*sqlx.DB, error) {
+	db, err := sqlx.Connect("postgres", cfg.dsn())
 	if err != nil {
 		return nil, err
 	}
@@ -39,6 +41,6 @@ func NewPostgresDB(cfg PostgresConfig) (*sqlx.DB, error) {
 		return nil, err
 	}
 
-	log.Println("Connected to PostgreSQL database")
+	slog.Info("connected to PostgreSQL database", "host", cfg.Host, "database", cfg.DBName)
 	return db, nil
-}
\ No newline at end of file
+}
@@ -2,7 +2,8 @@ package database
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -14,7 +15,7 @@ type SQLiteConfig struct {
 }
 
 func NewSQLiteDB(cfg SQLiteConfig) (*sqlx.DB, error) {
-	db, err := sqlx.Connect("sqlite3", cfg.DBPath)
+	db, err := sqlx.Connect("sqlite3", sqliteDSN(cfg.DBPath))
 	if err != nil {
 		return nil, err
 	}
@@ -31,6 +32,19 @@ func NewSQLiteDB(cfg SQLiteConfig) (*sqlx.DB, error) {
 		return nil, err
 	}
 
-	log.Println("Connected to SQLite database:", cfg.DBPath)
+	slog.Info("connected to SQLite database", "path", cfg.DBPath)
 	return db, nil
-}
\ No newline at end of file
+}
+
+// sqliteOptions are appended to the configured path. The driver reads the
+// first value of each option, so options already in the path take precedence.
+const sqliteOptions = "_foreign_keys=on&_busy_timeout=5000&_txlock=immediate"
+
+// sqliteDSN adds sqliteOptions to path, which may already carry a query such
+// as "file:app.db?mode=rwc".
+func sqliteDSN(path string) string {
+	if strings.Contains(path, "?") {
+		return path + "&" + sqliteOptions
+	}
+	return path + "?" + sqliteOptions
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteDSN(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"app.db", "app.db?" + sqliteOptions},
+		{"file:app.db?mode=rwc", "file:app.db?mode=rwc&" + sqliteOptions},
+		{"file:app.db?", "file:app.db?&" + sqliteOptions},
+	}
+	for _, tt := range tests {
+		if got := sqliteDSN(tt.path); got != tt.want {
+			t.Errorf("sqliteDSN(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSQLitePathWithOptions(t *testing.T) {
+	db, err := NewSQLiteDB(SQLiteConfig{DBPath: "file:" + filepath.Join(t.TempDir(), "app.db") + "?mode=rwc"})
+	if err != nil {
+		t.Fatalf("NewSQLiteDB: %v", err)
+	}
+	defer db.Close()
+
+	var enabled int
+	if err := db.Get(&enabled, `PRAGMA foreign_keys`); err != nil {
+		t.Fatalf("reading foreign_keys: %v", err)
+	}
+	if enabled != 1 {
+		t.Errorf("foreign_keys = %d, want 1", enabled)
+	}
+}
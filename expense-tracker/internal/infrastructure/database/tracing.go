@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("expense-tracker/internal/infrastructure/database")
+
+// tracedConn records a client span for every statement run through Conn
+// within a trace. Statements of background polling, which has no trace, are
+// left out. Spans of queries end once the rows are returned, not once they
+// are read.
+type tracedConn struct {
+	sqlx.ExtContext
+}
+
+func (c tracedConn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := c.startSpan(ctx, query)
+	result, err := c.ExtContext.ExecContext(ctx, query, args...)
+	endSpan(span, err)
+	return result, err
+}
+
+func (c tracedConn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := c.startSpan(ctx, query)
+	rows, err := c.ExtContext.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (c tracedConn) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	ctx, span := c.startSpan(ctx, query)
+	rows, err := c.ExtContext.QueryxContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (c tracedConn) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	ctx, span := c.startSpan(ctx, query)
+	row := c.ExtContext.QueryRowxContext(ctx, query, args...)
+	// sql.ErrNoRows only surfaces on Scan and is not a failure anyway
+	endSpan(span, row.Err())
+	return row
+}
+
+func (c tracedConn) startSpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+
+	operation := "SQL"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+
+	system := semconv.DBSystemNamePostgreSQL
+	if c.DriverName() == "sqlite3" {
+		system = semconv.DBSystemNameSQLite
+	}
+
+	return tracer.Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		system,
+		semconv.DBOperationName(operation),
+		semconv.DBQueryText(query),
+	))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// startTxSpan groups the statements of one transaction attempt within a
+// trace.
+func startTxSpan(ctx context.Context, attempt int) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return tracer.Start(ctx, "transaction", trace.WithAttributes(attribute.Int("db.transaction.attempt", attempt)))
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+type txKey struct{}
+
+type txState struct {
+	tx    *sqlx.Tx
+	depth int
+}
+
+// Conn returns the transaction active in ctx, or db when there is none.
+// Repositories run every statement through it, so each one is traced.
+func Conn(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return tracedConn{state.tx}
+	}
+	return tracedConn{db}
+}
+
+// TxManager implements repositories.TxManager on top of sqlx. Postgres
+// transactions run at SERIALIZABLE, so a read-modify-write spanning several
+// repositories cannot interleave with another one; the loser fails with a
+// serialization failure and is retried. SQLite serializes writers anyway.
+type TxManager struct {
+	db         *sqlx.DB
+	opts       *sql.TxOptions
+	maxRetries int
+	backoff    time.Duration
+}
+
+func NewTxManager(db *sqlx.DB) *TxManager {
+	m := &TxManager{db: db, maxRetries: 5, backoff: 20 * time.Millisecond}
+	if db.DriverName() == "postgres" {
+		m.opts = &sql.TxOptions{Isolation: sql.LevelSerializable}
+	}
+	return m
+}
+
+func (m *TxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return m.withinSavepoint(ctx, state, fn)
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = m.run(ctx, fn, attempt)
+		if err == nil || !isRetryable(err) || attempt >= m.maxRetries {
+			return err
+		}
+
+		select {
+		case <-time.After(m.backoff << attempt):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+func (m *TxManager) run(ctx context.Context, fn func(ctx context.Context) error, attempt int) (err error) {
+	ctx, span := startTxSpan(ctx, attempt)
+	defer func() { endSpan(span, err) }()
+
+	tx, err := m.db.BeginTxx(ctx, m.opts)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, &txState{tx: tx})); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *TxManager) withinSavepoint(ctx context.Context, parent *txState, fn func(ctx context.Context) error) error {
+	state := &txState{tx: parent.tx, depth: parent.depth + 1}
+	name := fmt.Sprintf("sp_%d", state.depth)
+
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		if _, rbErr := state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+
+	_, err := state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+// isRetryable reports transient conflicts: a busy or locked SQLite database,
+// and Postgres serialization failures and deadlocks.
+func isRetryable(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "40001" || pqErr.Code == "40P01"
+	}
+
+	return false
+}
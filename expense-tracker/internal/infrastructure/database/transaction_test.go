@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+func newTestTxManager(t *testing.T) (*TxManager, *sqlx.DB) {
+	t.Helper()
+	db := openSQLite(t, filepath.Join(t.TempDir(), "test.db"))
+	if _, err := db.Exec(`CREATE TABLE items (name TEXT NOT NULL)`); err != nil {
+		t.Fatalf("creating table: %v", err)
+	}
+	m := NewTxManager(db)
+	m.backoff = 0
+	return m, db
+}
+
+func insertItem(ctx context.Context, db *sqlx.DB, name string) error {
+	_, err := Conn(ctx, db).ExecContext(ctx, `INSERT INTO items (name) VALUES ($1)`, name)
+	return err
+}
+
+func items(t *testing.T, db *sqlx.DB) []string {
+	t.Helper()
+	var names []string
+	if err := db.Select(&names, `SELECT name FROM items ORDER BY rowid`); err != nil {
+		t.Fatalf("listing items: %v", err)
+	}
+	return names
+}
+
+func TestSavepoints(t *testing.T) {
+	errInner := errors.New("inner failed")
+
+	tests := []struct {
+		name    string
+		fn      func(ctx context.Context, m *TxManager, db *sqlx.DB) error
+		wantErr error
+		want    []string
+	}{
+		{
+			name: "failed savepoint keeps the outer work",
+			fn: func(ctx context.Context, m *TxManager, db *sqlx.DB) error {
+				insertItem(ctx, db, "outer")
+				err := m.WithinTransaction(ctx, func(ctx context.Context) error {
+					insertItem(ctx, db, "inner")
+					return errInner
+				})
+				if !errors.Is(err, errInner) {
+					return fmt.Errorf("savepoint returned %v", err)
+				}
+				return insertItem(ctx, db, "after")
+			},
+			want: []string{"outer", "after"},
+		},
+		{
+			name: "released savepoint commits with the outer transaction",
+			fn: func(ctx context.Context, m *TxManager, db *sqlx.DB) error {
+				insertItem(ctx, db, "outer")
+				return m.WithinTransaction(ctx, func(ctx context.Context) error {
+					return insertItem(ctx, db, "inner")
+				})
+			},
+			want: []string{"outer", "inner"},
+		},
+		{
+			name: "nested savepoints roll back independently",
+			fn: func(ctx context.Context, m *TxManager, db *sqlx.DB) error {
+				return m.WithinTransaction(ctx, func(ctx context.Context) error {
+					insertItem(ctx, db, "level 1")
+					m.WithinTransaction(ctx, func(ctx context.Context) error {
+						insertItem(ctx, db, "level 2")
+						return errInner
+					})
+					return m.WithinTransaction(ctx, func(ctx context.Context) error {
+						return insertItem(ctx, db, "level 2 again")
+					})
+				})
+			},
+			want: []string{"level 1", "level 2 again"},
+		},
+		{
+			name: "failed outer transaction discards released savepoints",
+			fn: func(ctx context.Context, m *TxManager, db *sqlx.DB) error {
+				m.WithinTransaction(ctx, func(ctx context.Context) error {
+					return insertItem(ctx, db, "inner")
+				})
+				return errInner
+			},
+			wantErr: errInner,
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, db := newTestTxManager(t)
+			err := m.WithinTransaction(context.Background(), func(ctx context.Context) error {
+				return tt.fn(ctx, m, db)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithinTransaction = %v, want %v", err, tt.wantErr)
+			}
+			if got := items(t, db); !slices.Equal(got, tt.want) {
+				t.Fatalf("items = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetries(t *testing.T) {
+	busy := sqlite3.Error{Code: sqlite3.ErrBusy}
+	permanent := errors.New("constraint violated")
+
+	tests := []struct {
+		name      string
+		failures  []error // returned by the first attempts, in order
+		wantErr   error
+		wantCalls int
+	}{
+		{"success", nil, nil, 1},
+		{"busy then success", []error{busy, busy}, nil, 3},
+		{"permanent error", []error{permanent}, permanent, 1},
+		{"busy then permanent", []error{busy, permanent}, permanent, 2},
+		{"gives up after the maximum retries", []error{busy, busy, busy, busy, busy, busy, busy}, busy, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, db := newTestTxManager(t)
+			calls := 0
+			err := m.WithinTransaction(context.Background(), func(ctx context.Context) error {
+				calls++
+				if err := insertItem(ctx, db, fmt.Sprintf("attempt %d", calls)); err != nil {
+					return err
+				}
+				if calls <= len(tt.failures) {
+					return tt.failures[calls-1]
+				}
+				return nil
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithinTransaction = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("fn ran %d times, want %d", calls, tt.wantCalls)
+			}
+			// Only the successful attempt may leave a row behind
+			want := []string(nil)
+			if tt.wantErr == nil {
+				want = []string{fmt.Sprintf("attempt %d", calls)}
+			}
+			if got := items(t, db); !slices.Equal(got, want) {
+				t.Fatalf("items = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// A conflict inside a savepoint is retried as a whole by the outermost
+// transaction, not by the savepoint.
+func TestRetriesRestartTheOuterTransaction(t *testing.T) {
+	m, db := newTestTxManager(t)
+	outer, inner := 0, 0
+	err := m.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		outer++
+		insertItem(ctx, db, "outer")
+		return m.WithinTransaction(ctx, func(ctx context.Context) error {
+			inner++
+			if inner == 1 {
+				return sqlite3.Error{Code: sqlite3.ErrBusy}
+			}
+			return insertItem(ctx, db, "inner")
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction: %v", err)
+	}
+	if outer != 2 || inner != 2 {
+		t.Fatalf("outer ran %d times and inner %d, want 2 and 2", outer, inner)
+	}
+	if got, want := items(t, db), []string{"outer", "inner"}; !slices.Equal(got, want) {
+		t.Fatalf("items = %q, want %q", got, want)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{sqlite3.Error{Code: sqlite3.ErrBusy}, true},
+		{sqlite3.Error{Code: sqlite3.ErrLocked}, true},
+		{sqlite3.Error{Code: sqlite3.ErrConstraint}, false},
+		{&pq.Error{Code: "40001"}, true}, // serialization_failure
+		{&pq.Error{Code: "40P01"}, true}, // deadlock_detected
+		{&pq.Error{Code: "23505"}, false},
+		{fmt.Errorf("saving: %w", &pq.Error{Code: "40001"}), true},
+		{context.Canceled, false},
+	}
+	for _, tt := range tests {
+		if got := isRetryable(tt.err); got != tt.want {
+			t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/pkg/logger"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type contextKey struct{}
+
+// Authenticator returns the user a bearer token was issued to, failing with
+// services.ErrInvalidToken or services.ErrAccountDisabled.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (string, error)
+}
+
+// authenticator checks the "authorization" metadata like the HTTP auth
+// middleware checks the header. Services listed as public are served
+// without a token.
+type authenticator struct {
+	auth   Authenticator
+	public map[string]bool
+}
+
+func (a *authenticator) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authenticator) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+func (a *authenticator) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	service, _, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if a.public[service] {
+		return ctx, nil
+	}
+
+	values := metadata.ValueFromIncomingContext(ctx, "authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata required")
+	}
+
+	parts := strings.Split(values[0], " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization metadata format")
+	}
+
+	userID, err := a.auth.Authenticate(ctx, parts[1])
+	switch {
+	case errors.Is(err, services.ErrInvalidToken):
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	case errors.Is(err, services.ErrAccountDisabled):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case err != nil:
+		logger.Error(ctx, "failed to authenticate call", "error", err)
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+	return context.WithValue(ctx, contextKey{}, userID), nil
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func userIDFromContext(ctx context.Context) (string, error) {
+	userID, ok := ctx.Value(contextKey{}).(string)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	return userID, nil
+}
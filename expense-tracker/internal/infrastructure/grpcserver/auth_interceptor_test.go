@@ -0,0 +1,101 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"expense-tracker/internal/application/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tokens is an Authenticator mapping tokens to users; other tokens are
+// answered with err.
+type tokens struct {
+	users map[string]string
+	err   error
+}
+
+func (a tokens) Authenticate(ctx context.Context, token string) (string, error) {
+	if userID, ok := a.users[token]; ok {
+		return userID, nil
+	}
+	return "", a.err
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		err           error
+		wantCode      codes.Code
+		wantUser      string
+	}{
+		{"valid token", "/expense.ExpenseService/ListExpenses", "Bearer good", nil, codes.OK, "user-1"},
+		{"public service", "/expense.AuthService/Login", "", nil, codes.OK, ""},
+		{"missing metadata", "/expense.ExpenseService/ListExpenses", "", nil, codes.Unauthenticated, ""},
+		{"not a bearer token", "/expense.ExpenseService/ListExpenses", "Basic good", nil, codes.Unauthenticated, ""},
+		{"bearer without a token", "/expense.ExpenseService/ListExpenses", "Bearer", nil, codes.Unauthenticated, ""},
+		{"invalid token", "/expense.ExpenseService/ListExpenses", "Bearer bad", services.ErrInvalidToken, codes.Unauthenticated, ""},
+		{"disabled account", "/expense.ExpenseService/ListExpenses", "Bearer bad", services.ErrAccountDisabled, codes.PermissionDenied, ""},
+		{"lookup failure", "/expense.ExpenseService/ListExpenses", "Bearer bad", errors.New("database is down"), codes.Internal, ""},
+		{"method named like a public service", "/expense.ExpenseService/expense.AuthService", "", nil, codes.Unauthenticated, ""},
+	}
+
+	for _, tt := range tests {
+		auth := &authenticator{
+			auth:   tokens{users: map[string]string{"good": "user-1"}, err: tt.err},
+			public: map[string]bool{"expense.AuthService": true},
+		}
+		ctx := context.Background()
+		if tt.authorization != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.authorization))
+		}
+
+		t.Run(tt.name+" unary", func(t *testing.T) {
+			var gotUser string
+			_, err := auth.unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				gotUser, _ = userIDFromContext(ctx)
+				return nil, nil
+			})
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code %v (%v), want %v", code, err, tt.wantCode)
+			}
+			if gotUser != tt.wantUser {
+				t.Fatalf("handler saw user %q, want %q", gotUser, tt.wantUser)
+			}
+		})
+
+		t.Run(tt.name+" stream", func(t *testing.T) {
+			var gotUser string
+			err := auth.stream(nil, &testStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: tt.method}, func(srv interface{}, ss grpc.ServerStream) error {
+				gotUser, _ = userIDFromContext(ss.Context())
+				return nil
+			})
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code %v (%v), want %v", code, err, tt.wantCode)
+			}
+			if gotUser != tt.wantUser {
+				t.Fatalf("handler saw user %q, want %q", gotUser, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestUserIDFromContextRequiresAuthentication(t *testing.T) {
+	if _, err := userIDFromContext(context.Background()); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("userIDFromContext = %v, want Unauthenticated", err)
+	}
+}
+
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context { return s.ctx }
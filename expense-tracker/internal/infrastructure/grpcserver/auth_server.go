@@ -0,0 +1,78 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/infrastructure/ratelimit"
+	"expense-tracker/internal/pkg/validation"
+	pb "expense-tracker/proto/expensetracker/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type authServer struct {
+	pb.UnimplementedAuthServiceServer
+	authService *services.AuthService
+	validator   *validation.Validator
+	loginLimits ratelimit.LoginLimits
+	trustProxy  bool
+}
+
+func (s *authServer) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	registerReq := dto.RegisterRequest{
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+		Name:     req.GetName(),
+	}
+	if err := s.validator.Validate(registerReq); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	response, err := s.authService.Register(ctx, registerReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.RegisterResponse{Token: response.Token, User: toUser(response)}, nil
+}
+
+func (s *authServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	loginReq := dto.LoginRequest{
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+		ClientIP: s.clientIP(ctx),
+	}
+	if err := s.validator.Validate(loginReq); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if allowed, retryAfter := s.loginLimits.Allow(ctx, loginReq.ClientIP, loginReq.Email); !allowed {
+		return nil, retryStatus(codes.ResourceExhausted, "too many requests", retryAfter)
+	}
+
+	response, err := s.authService.Login(ctx, loginReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.LoginResponse{Token: response.Token, User: toUser(response)}, nil
+}
+
+// clientIP reads the x-forwarded-for metadata like ClientIP reads the
+// header; it is only trusted behind a proxy.
+func (s *authServer) clientIP(ctx context.Context) string {
+	var remoteAddr string
+	forwardedFor := strings.Join(metadata.ValueFromIncomingContext(ctx, "x-forwarded-for"), ",")
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+	return ratelimit.ClientIP(remoteAddr, forwardedFor, "", s.trustProxy)
+}
+
+func toUser(response *dto.AuthResponse) *pb.User {
+	return &pb.User{Id: response.User.ID, Email: response.User.Email, Name: response.User.Name}
+}
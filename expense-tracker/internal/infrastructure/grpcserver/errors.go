@@ -0,0 +1,51 @@
+package grpcserver
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"expense-tracker/internal/application/services"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// toStatus maps service errors to gRPC status codes. Unknown errors are
+// logged and reported without their details.
+func toStatus(err error) error {
+	var lockedErr *services.AccountLockedError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &lockedErr):
+		return retryStatus(codes.ResourceExhausted, err.Error(), lockedErr.RetryAfter)
+	case errors.Is(err, services.ErrInvalidCredentials):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, services.ErrAccountDisabled):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, services.ErrExpenseNotFound), errors.Is(err, services.ErrRevisionNotFound), errors.Is(err, services.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, services.ErrVersionMismatch):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, services.ErrEmailExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, services.ErrInvalidCategory), errors.Is(err, services.ErrInvalidDate):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		slog.Error("gRPC handler error", "error", err)
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// retryStatus attaches a RetryInfo detail, the counterpart of the
+// Retry-After header.
+func retryStatus(code codes.Code, message string, retryAfter time.Duration) error {
+	st := status.New(code, message)
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,227 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/pkg/validation"
+	pb "expense-tracker/proto/expensetracker/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const dateLayout = "2006-01-02"
+
+type expenseServer struct {
+	pb.UnimplementedExpenseServiceServer
+	expenseService *services.ExpenseService
+	validator      *validation.Validator
+	requireVersion bool
+}
+
+func (s *expenseServer) CreateExpense(ctx context.Context, req *pb.CreateExpenseRequest) (*pb.CreateExpenseResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	createReq := dto.CreateExpenseRequest{
+		Amount:      req.GetAmount(),
+		Category:    req.GetCategory(),
+		Description: req.GetDescription(),
+		Date:        req.GetDate(),
+	}
+	if err := s.validator.Validate(createReq); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	expense, err := s.expenseService.CreateExpense(ctx, userID, createReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.CreateExpenseResponse{Expense: toExpense(expense)}, nil
+}
+
+func (s *expenseServer) GetExpense(ctx context.Context, req *pb.GetExpenseRequest) (*pb.GetExpenseResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expense, err := s.expenseService.GetExpense(ctx, userID, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.GetExpenseResponse{Expense: toExpense(expense)}, nil
+}
+
+func (s *expenseServer) ListExpenses(req *pb.ListExpensesRequest, stream grpc.ServerStreamingServer[pb.ListExpensesResponse]) error {
+	ctx := stream.Context()
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	expenses, err := s.expenseService.GetExpenses(ctx, userID, dto.FilterParams{
+		Period:    req.GetPeriod(),
+		StartDate: req.GetStartDate(),
+		EndDate:   req.GetEndDate(),
+		Category:  req.GetCategory(),
+	})
+	if err != nil {
+		return toStatus(err)
+	}
+
+	for _, expense := range expenses {
+		if err := stream.Send(&pb.ListExpensesResponse{Expense: toExpense(expense)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *expenseServer) UpdateExpense(ctx context.Context, req *pb.UpdateExpenseRequest) (*pb.UpdateExpenseResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkVersion(req.GetExpectedVersion()); err != nil {
+		return nil, err
+	}
+
+	updateReq := dto.UpdateExpenseRequest{
+		Amount:      req.Amount,
+		Category:    req.Category,
+		Description: req.Description,
+		Date:        req.Date,
+	}
+	if err := s.validator.Validate(updateReq); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	expense, err := s.expenseService.UpdateExpense(ctx, userID, req.GetId(), updateReq, int(req.GetExpectedVersion()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.UpdateExpenseResponse{Expense: toExpense(expense)}, nil
+}
+
+func (s *expenseServer) DeleteExpense(ctx context.Context, req *pb.DeleteExpenseRequest) (*pb.DeleteExpenseResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkVersion(req.GetExpectedVersion()); err != nil {
+		return nil, err
+	}
+
+	if err := s.expenseService.DeleteExpense(ctx, userID, req.GetId(), int(req.GetExpectedVersion())); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteExpenseResponse{}, nil
+}
+
+func (s *expenseServer) ListHistory(req *pb.ListHistoryRequest, stream grpc.ServerStreamingServer[pb.ListHistoryResponse]) error {
+	ctx := stream.Context()
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := s.expenseService.GetHistory(ctx, userID, req.GetExpenseId())
+	if err != nil {
+		return toStatus(err)
+	}
+
+	for _, revision := range revisions {
+		message, err := toRevision(revision)
+		if err != nil {
+			return toStatus(err)
+		}
+		if err := stream.Send(&pb.ListHistoryResponse{Revision: message}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *expenseServer) GetCategoryTotals(ctx context.Context, req *pb.GetCategoryTotalsRequest) (*pb.GetCategoryTotalsResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate, err1 := time.Parse(dateLayout, req.GetStartDate())
+	endDate, err2 := time.Parse(dateLayout, req.GetEndDate())
+	if err1 != nil || err2 != nil {
+		return nil, status.Error(codes.InvalidArgument, "start_date and end_date must be YYYY-MM-DD")
+	}
+
+	totals, err := s.expenseService.GetCategoryTotals(ctx, userID, startDate, endDate)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	response := &pb.GetCategoryTotalsResponse{Totals: make([]*pb.CategoryTotal, len(totals))}
+	for i, total := range totals {
+		response.Totals[i] = &pb.CategoryTotal{Category: total.Category, Total: total.Total}
+	}
+	return response, nil
+}
+
+// checkVersion applies EXPENSE_REQUIRE_IF_MATCH to expected_version.
+func (s *expenseServer) checkVersion(version int32) error {
+	if version < 0 {
+		return status.Error(codes.InvalidArgument, "expected_version must be positive")
+	}
+	if version == 0 && s.requireVersion {
+		return status.Error(codes.FailedPrecondition, "expected_version is required")
+	}
+	return nil
+}
+
+func toExpense(expense *dto.ExpenseResponse) *pb.Expense {
+	return &pb.Expense{
+		Id:          expense.ID,
+		Amount:      expense.Amount,
+		Category:    expense.Category,
+		Description: expense.Description,
+		Date:        expense.Date.Format(dateLayout),
+		CreatedAt:   timestamppb.New(expense.CreatedAt),
+		UpdatedAt:   timestamppb.New(expense.UpdatedAt),
+		Version:     int32(expense.Version),
+	}
+}
+
+func toRevision(revision *dto.ExpenseRevisionResponse) (*pb.ExpenseRevision, error) {
+	message := &pb.ExpenseRevision{
+		Revision:  int32(revision.Revision),
+		Action:    revision.Action,
+		ActorId:   revision.ActorID,
+		Changes:   make([]*pb.FieldChange, len(revision.Changes)),
+		CreatedAt: timestamppb.New(revision.CreatedAt),
+	}
+	if revision.RevertedTo != nil {
+		revertedTo := int32(*revision.RevertedTo)
+		message.RevertedTo = &revertedTo
+	}
+
+	for i, change := range revision.Changes {
+		oldValue, err := structpb.NewValue(change.Old)
+		if err != nil {
+			return nil, err
+		}
+		newValue, err := structpb.NewValue(change.New)
+		if err != nil {
+			return nil, err
+		}
+		message.Changes[i] = &pb.FieldChange{Field: change.Field, Old: oldValue, New: newValue}
+	}
+	return message, nil
+}
@@ -0,0 +1,61 @@
+package grpcserver
+
+import (
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/infrastructure/ratelimit"
+	"expense-tracker/internal/pkg/validation"
+	pb "expense-tracker/proto/expensetracker/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+type Options struct {
+	LoginLimits ratelimit.LoginLimits
+	TrustProxy  bool
+	// RequireVersion makes expected_version mandatory on updates and
+	// deletes, like EXPENSE_REQUIRE_IF_MATCH does for If-Match.
+	RequireVersion bool
+}
+
+// NewServer serves the expense and auth services over gRPC, with the
+// standard health service and reflection. Health reports SERVING for the
+// server and each service; use the returned health server to change that.
+func NewServer(expenseService *services.ExpenseService, authService *services.AuthService, validator *validation.Validator, opts Options) (*grpc.Server, *health.Server) {
+	auth := &authenticator{
+		auth: authService,
+		public: map[string]bool{
+			pb.AuthService_ServiceDesc.ServiceName:     true,
+			healthpb.Health_ServiceDesc.ServiceName:    true,
+			"grpc.reflection.v1.ServerReflection":      true,
+			"grpc.reflection.v1alpha.ServerReflection": true,
+		},
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auth.unary),
+		grpc.ChainStreamInterceptor(auth.stream),
+	)
+
+	pb.RegisterExpenseServiceServer(server, &expenseServer{
+		expenseService: expenseService,
+		validator:      validator,
+		requireVersion: opts.RequireVersion,
+	})
+	pb.RegisterAuthServiceServer(server, &authServer{
+		authService: authService,
+		validator:   validator,
+		loginLimits: opts.LoginLimits,
+		trustProxy:  opts.TrustProxy,
+	})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(pb.ExpenseService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(pb.AuthService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+	return server, healthServer
+}
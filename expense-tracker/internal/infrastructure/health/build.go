@@ -0,0 +1,38 @@
+package health
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Version is the release of the build, set with
+//
+//	go build -ldflags "-X expense-tracker/internal/infrastructure/health.Version=1.4.0" ./cmd/api
+var Version = "dev"
+
+type BuildInfo struct {
+	Version    string `json:"version"`
+	Commit     string `json:"commit,omitempty"`
+	CommitTime string `json:"commit_time,omitempty"`
+	Modified   bool   `json:"modified,omitempty"` // built from a tree with uncommitted changes
+	GoVersion  string `json:"go_version"`
+}
+
+// ReadBuildInfo returns Version and the VCS details the Go toolchain
+// stamped into the binary.
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{Version: Version, GoVersion: runtime.Version()}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.time":
+				info.CommitTime = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return info
+}
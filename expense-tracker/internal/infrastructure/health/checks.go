@@ -0,0 +1,83 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"expense-tracker/internal/infrastructure/database"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Database checks that a connection can be used.
+func Database(db *sqlx.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// Migrations checks that the schema is at least at the version this build
+// expects, so a rolled back or pending schema takes the server out of
+// rotation. A newer schema is fine: during a rolling deploy the new build
+// migrates while the old one still serves.
+func Migrations(migrator *database.Migrator) CheckFunc {
+	return func(ctx context.Context) error {
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		if version < migrator.Latest() {
+			return fmt.Errorf("schema is at version %d, expected at least %d", version, migrator.Latest())
+		}
+		return nil
+	}
+}
+
+// Recent checks that a background worker ran within maxAge. last reports
+// when it last did.
+func Recent(last func() time.Time, maxAge time.Duration) CheckFunc {
+	return func(ctx context.Context) error {
+		if since := time.Since(last()); since > maxAge {
+			return fmt.Errorf("last ran %s ago", since.Round(time.Second))
+		}
+		return nil
+	}
+}
+
+// Heartbeat records when a background worker last ran, for Recent.
+type Heartbeat struct {
+	last atomic.Int64 // Unix nanoseconds
+}
+
+// NewHeartbeat returns a Heartbeat that counts as beaten now, so a worker
+// that has not run yet is not reported as stuck.
+func NewHeartbeat() *Heartbeat {
+	h := &Heartbeat{}
+	h.Beat()
+	return h
+}
+
+func (h *Heartbeat) Beat() {
+	h.last.Store(time.Now().UnixNano())
+}
+
+func (h *Heartbeat) Last() time.Time {
+	return time.Unix(0, h.last.Load())
+}
+
+// DiskSpace checks that the file system holding dir has at least minFree
+// bytes available, for databases stored on local disk.
+func DiskSpace(dir string, minFree uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		free, err := freeSpace(dir)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("%d MB free in %s, want at least %d MB", free>>20, dir, minFree>>20)
+		}
+		return nil
+	}
+}
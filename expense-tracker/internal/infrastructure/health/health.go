@@ -0,0 +1,166 @@
+// Package health serves the liveness and readiness probes of the server.
+// Liveness only tells that the process answers; readiness runs the checks
+// of every component and fails while the server drains for shutdown.
+package health
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckFunc reports why a component cannot serve, or nil when it can.
+type CheckFunc func(ctx context.Context) error
+
+type component struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker holds the checks of the components the server depends on.
+type Checker struct {
+	build    BuildInfo
+	started  time.Time
+	timeout  time.Duration
+	mu       sync.RWMutex
+	checks   []component
+	onDrain  []func()
+	draining atomic.Bool
+}
+
+// New returns a Checker that gives every check up to timeout.
+func New(build BuildInfo, timeout time.Duration) *Checker {
+	return &Checker{build: build, started: time.Now(), timeout: timeout}
+}
+
+// Add registers the check of a component. Checks run concurrently, so they
+// must be safe to call from several goroutines.
+func (c *Checker) Add(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, component{name: name, check: check})
+}
+
+// OnDrain registers fn to run on Drain, for other probes of the server
+// such as the gRPC health service.
+func (c *Checker) OnDrain(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onDrain = append(c.onDrain, fn)
+}
+
+// Drain makes readiness fail from now on, so load balancers stop sending
+// requests before the server shuts down.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, fn := range c.onDrain {
+		fn()
+	}
+}
+
+type ComponentStatus struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status        string                     `json:"status"`
+	Build         BuildInfo                  `json:"build"`
+	UptimeSeconds int64                      `json:"uptime_seconds"`
+	Components    map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+// Ready runs every check and reports the status of each component. The
+// server is ready when all are up and it is not draining.
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	statuses := make([]ComponentStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, comp := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = run(ctx, comp.check)
+		}()
+	}
+	wg.Wait()
+
+	report := c.report("up")
+	report.Components = make(map[string]ComponentStatus, len(checks))
+	for i, comp := range checks {
+		report.Components[comp.name] = statuses[i]
+		if statuses[i].Status != "up" {
+			report.Status = "down"
+		}
+	}
+	if c.draining.Load() {
+		report.Status = "draining"
+	}
+	return report
+}
+
+func run(ctx context.Context, check CheckFunc) ComponentStatus {
+	start := time.Now()
+	err := check(ctx)
+	status := ComponentStatus{Status: "up", LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		status.Status = "down"
+		status.Error = err.Error()
+	}
+	return status
+}
+
+func (c *Checker) report(status string) Report {
+	return Report{Status: status, Build: c.build, UptimeSeconds: int64(time.Since(c.started).Seconds())}
+}
+
+// LiveHandler answers 200 while the process can serve HTTP at all. It runs
+// no checks, so a failing dependency does not get the process restarted.
+func (c *Checker) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, http.StatusOK, c.report("up"))
+	})
+}
+
+// ReadyHandler answers 200 when the server is ready for traffic and 503
+// otherwise, with the status of each component. Why a component is down can
+// reveal hosts and paths, so the errors are only included for callers
+// sending detailsToken as a bearer token; with no token they never are.
+func (c *Checker) ReadyHandler(detailsToken string) http.Handler {
+	expected := []byte("Bearer " + detailsToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Ready(r.Context())
+		code := http.StatusOK
+		if report.Status != "up" {
+			code = http.StatusServiceUnavailable
+		}
+		if detailsToken == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			for name, status := range report.Components {
+				status.Error = ""
+				report.Components[name] = status
+			}
+		}
+		writeReport(w, code, report)
+	})
+}
+
+func writeReport(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,56 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadyHandlerHidesErrors(t *testing.T) {
+	checks := New(BuildInfo{}, time.Second)
+	checks.Add("database", func(ctx context.Context) error { return errors.New("dial tcp db.internal:5432: connection refused") })
+	checks.Add("disk", func(ctx context.Context) error { return nil })
+
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		wantError     bool
+	}{
+		{"no token configured", "", "", false},
+		{"no token configured, any sent", "", "Bearer ", false},
+		{"token not sent", "secret", "", false},
+		{"wrong token", "secret", "Bearer other", false},
+		{"token sent", "secret", "Bearer secret", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			checks.ReadyHandler(tt.token).ServeHTTP(w, r)
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Fatalf("status %d, want %d", w.Code, http.StatusServiceUnavailable)
+			}
+			var report Report
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+			database := report.Components["database"]
+			if database.Status != "down" || report.Components["disk"].Status != "up" {
+				t.Fatalf("components %+v, want the database down and the disk up", report.Components)
+			}
+			if (database.Error != "") != tt.wantError {
+				t.Fatalf("database error %q, want shown %v", database.Error, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/infrastructure/http/middleware"
+	"expense-tracker/internal/pkg/logger"
+	"expense-tracker/internal/pkg/validation"
+)
+
+type AccountHandler struct {
+	accountService *services.AccountService
+	validator      *validation.Validator
+}
+
+func NewAccountHandler(accountService *services.AccountService, validator *validation.Validator) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+		validator:      validator,
+	}
+}
+
+func (h *AccountHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	response, err := h.accountService.GetProfile(r.Context(), userID)
+	if err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AccountHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Validate(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.accountService.UpdateProfile(r.Context(), userID, req)
+	if err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AccountHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req dto.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Validate(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.accountService.VerifyEmail(r.Context(), req)
+	if err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AccountHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Validate(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.accountService.ChangePassword(r.Context(), userID, req); err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Validate(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.accountService.DeleteAccount(r.Context(), userID, req); err != nil {
+		writeAccountError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeAccountError maps service errors to status codes. Unknown errors are
+// logged and reported without their details.
+func writeAccountError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrIncorrectPassword):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrEmailExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidVerifyToken), errors.Is(err, services.ErrEmptyName):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		logger.FromContext(r.Context()).Error("account handler error", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
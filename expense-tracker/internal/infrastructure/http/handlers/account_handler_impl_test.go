@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/events"
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/infrastructure/http/middleware"
+	"expense-tracker/internal/infrastructure/jwt"
+	"expense-tracker/internal/infrastructure/repositories/memory"
+	"expense-tracker/internal/pkg/validation"
+)
+
+const testPassword = "secret12"
+
+// failingSender is an EmailVerificationSender that cannot deliver.
+type failingSender struct{}
+
+func (failingSender) SendEmailVerification(ctx context.Context, user *entities.User, email, token string) error {
+	return errors.New("smtp: connection refused")
+}
+
+// newTestAccountHandler registers jane@example.com, whose ID it returns, and
+// taken@example.com, both with testPassword.
+func newTestAccountHandler(t *testing.T) (*AccountHandler, string) {
+	t.Helper()
+	store := memory.NewStore()
+	users := memory.NewUserRepository(store)
+	bus := events.NewBus(memory.NewOutboxRepository(store))
+	auth := services.NewAuthService(users, memory.NewLoginAttemptRepository(store), jwt.NewJWTManager("test-secret", time.Hour), services.LoginPolicy{}, bus)
+
+	var userID string
+	for _, email := range []string{"jane@example.com", "taken@example.com"} {
+		response, err := auth.Register(context.Background(), dto.RegisterRequest{Email: email, Password: testPassword, Name: "Test"})
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		if userID == "" {
+			userID = response.User.ID
+		}
+	}
+
+	service := services.NewAccountService(memory.NewTxManager(store), users, memory.NewEmailVerificationRepository(store), failingSender{}, time.Hour)
+	return NewAccountHandler(service, validation.NewValidator()), userID
+}
+
+func TestAccountHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    func(h *AccountHandler) http.HandlerFunc
+		method     string
+		body       string
+		anonymous  bool
+		wantStatus int
+		wantBody   string
+	}{
+		{"profile", func(h *AccountHandler) http.HandlerFunc { return h.GetProfile }, http.MethodGet, "", false, http.StatusOK, `"email":"jane@example.com"`},
+		{"profile without a user", func(h *AccountHandler) http.HandlerFunc { return h.GetProfile }, http.MethodGet, "", true, http.StatusUnauthorized, ""},
+		{"rename", func(h *AccountHandler) http.HandlerFunc { return h.UpdateProfile }, http.MethodPatch, `{"name":"Jane Doe"}`, false, http.StatusOK, `"name":"Jane Doe"`},
+		{"blank name", func(h *AccountHandler) http.HandlerFunc { return h.UpdateProfile }, http.MethodPatch, `{"name":" "}`, false, http.StatusBadRequest, "name cannot be empty"},
+		{"invalid email", func(h *AccountHandler) http.HandlerFunc { return h.UpdateProfile }, http.MethodPatch, `{"email":"jane"}`, false, http.StatusBadRequest, ""},
+		{"email taken", func(h *AccountHandler) http.HandlerFunc { return h.UpdateProfile }, http.MethodPatch, `{"email":"taken@example.com"}`, false, http.StatusConflict, "email already exists"},
+		{"verification not sent", func(h *AccountHandler) http.HandlerFunc { return h.UpdateProfile }, http.MethodPatch, `{"email":"new@example.com"}`, false, http.StatusInternalServerError, "internal server error"},
+		{"unknown verification token", func(h *AccountHandler) http.HandlerFunc { return h.VerifyEmail }, http.MethodPost, `{"token":"nope"}`, true, http.StatusBadRequest, "invalid or expired verification token"},
+		{"change password", func(h *AccountHandler) http.HandlerFunc { return h.ChangePassword }, http.MethodPost, `{"current_password":"secret12","new_password":"changed12"}`, false, http.StatusNoContent, ""},
+		{"change password with a wrong password", func(h *AccountHandler) http.HandlerFunc { return h.ChangePassword }, http.MethodPost, `{"current_password":"wrong","new_password":"changed12"}`, false, http.StatusForbidden, "current password is incorrect"},
+		{"change to a short password", func(h *AccountHandler) http.HandlerFunc { return h.ChangePassword }, http.MethodPost, `{"current_password":"secret12","new_password":"short"}`, false, http.StatusBadRequest, ""},
+		{"delete", func(h *AccountHandler) http.HandlerFunc { return h.DeleteAccount }, http.MethodDelete, `{"password":"secret12"}`, false, http.StatusNoContent, ""},
+		{"delete with a wrong password", func(h *AccountHandler) http.HandlerFunc { return h.DeleteAccount }, http.MethodDelete, `{"password":"wrong"}`, false, http.StatusForbidden, "current password is incorrect"},
+		{"delete without a body", func(h *AccountHandler) http.HandlerFunc { return h.DeleteAccount }, http.MethodDelete, "", false, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, userID := newTestAccountHandler(t)
+			r := httptest.NewRequest(tt.method, "/api/me", strings.NewReader(tt.body))
+			if !tt.anonymous {
+				r = r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, userID))
+			}
+			w := httptest.NewRecorder()
+			tt.handler(h)(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Fatalf("body %s, want it to contain %q", w.Body, tt.wantBody)
+			}
+			// Unexpected errors are not shown to the client
+			if strings.Contains(w.Body.String(), "smtp") {
+				t.Fatalf("body %s reveals the error", w.Body)
+			}
+		})
+	}
+}
+
+// A deleted account is gone, along with its email.
+func TestDeleteAccountRemovesTheProfile(t *testing.T) {
+	h, userID := newTestAccountHandler(t)
+	as := func(r *http.Request) *http.Request {
+		return r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, userID))
+	}
+
+	w := httptest.NewRecorder()
+	h.DeleteAccount(w, as(httptest.NewRequest(http.MethodDelete, "/api/me", strings.NewReader(`{"password":"secret12"}`))))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete status %d: %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	h.GetProfile(w, as(httptest.NewRequest(http.MethodGet, "/api/me", nil)))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("profile status %d after deletion, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"expense-tracker/internal/application/dto"
 	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/infrastructure/http/middleware"
 	"expense-tracker/internal/pkg/validation"
 	"net/http"
 )
@@ -11,12 +13,14 @@ import (
 type AuthHandler struct {
 	authService *services.AuthService
 	validator   *validation.Validator
+	trustProxy  bool
 }
 
-func NewAuthHandler(authService *services.AuthService, validator *validation.Validator) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, validator *validation.Validator, trustProxy bool) *AuthHandler {
 	return &AuthHandler{
 		authService: authService,
 		validator:   validator,
+		trustProxy:  trustProxy,
 	}
 }
 
@@ -55,12 +59,24 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	req.ClientIP = middleware.ClientIP(r, h.trustProxy)
+
 	response, err := h.authService.Login(r.Context(), req)
 	if err != nil {
+		var lockedErr *services.AccountLockedError
+		if errors.As(err, &lockedErr) {
+			middleware.SetRetryAfter(w, lockedErr.RetryAfter)
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		if errors.Is(err, services.ErrAccountDisabled) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
-}
\ No newline at end of file
+}
@@ -2,7 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"expense-tracker/internal/application/dto"
 	"expense-tracker/internal/application/services"
@@ -15,12 +19,16 @@ import (
 type ExpenseHandler struct {
 	expenseService *services.ExpenseService
 	validator      *validation.Validator
+	requireIfMatch bool
 }
 
-func NewExpenseHandler(expenseService *services.ExpenseService, validator *validation.Validator) *ExpenseHandler {
+// NewExpenseHandler creates the expense handler. With requireIfMatch set,
+// updates and deletes without an If-Match header are rejected with 428.
+func NewExpenseHandler(expenseService *services.ExpenseService, validator *validation.Validator, requireIfMatch bool) *ExpenseHandler {
 	return &ExpenseHandler{
 		expenseService: expenseService,
 		validator:      validator,
+		requireIfMatch: requireIfMatch,
 	}
 }
 
@@ -49,6 +57,7 @@ func (h *ExpenseHandler) CreateExpense(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", expenseETag(response.Version))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
 }
@@ -76,6 +85,33 @@ func (h *ExpenseHandler) GetExpenses(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(expenses)
 }
 
+func (h *ExpenseHandler) GetExpense(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	expenseID := vars["id"]
+
+	response, err := h.expenseService.GetExpense(r.Context(), userID, expenseID)
+	if err != nil {
+		writeExpenseError(w, err)
+		return
+	}
+
+	etag := expenseETag(response.Version)
+	w.Header().Set("ETag", etag)
+	if noneMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 func (h *ExpenseHandler) UpdateExpense(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
@@ -86,6 +122,11 @@ func (h *ExpenseHandler) UpdateExpense(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	expenseID := vars["id"]
 
+	expectedVersion, ok := h.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
 	var req dto.UpdateExpenseRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -97,13 +138,18 @@ func (h *ExpenseHandler) UpdateExpense(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.expenseService.UpdateExpense(r.Context(), userID, expenseID, req)
+	response, err := h.expenseService.UpdateExpense(r.Context(), userID, expenseID, req, expectedVersion)
+	if errors.Is(err, services.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", expenseETag(response.Version))
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -117,10 +163,272 @@ func (h *ExpenseHandler) DeleteExpense(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	expenseID := vars["id"]
 
-	if err := h.expenseService.DeleteExpense(r.Context(), userID, expenseID); err != nil {
+	expectedVersion, ok := h.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.expenseService.DeleteExpense(r.Context(), userID, expenseID, expectedVersion)
+	if errors.Is(err, services.ErrVersionMismatch) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
+
+const maxBatchOperations = 100
+
+func (h *ExpenseHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = "atomic"
+	}
+	if req.Mode != "atomic" && req.Mode != "best_effort" {
+		http.Error(w, "mode must be atomic or best_effort", http.StatusBadRequest)
+		return
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > maxBatchOperations {
+		http.Error(w, fmt.Sprintf("operations must contain 1 to %d items", maxBatchOperations), http.StatusBadRequest)
+		return
+	}
+
+	ops := make([]services.BatchOperation, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = h.parseBatchOperation(op)
+	}
+
+	results, err := h.expenseService.Batch(r.Context(), userID, req.Mode == "atomic", ops)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.BatchResponse{Mode: req.Mode, Results: results}
+	for _, result := range results {
+		if result.Error == "" {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	status := http.StatusOK
+	if req.Mode == "atomic" && response.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseBatchOperation decodes and validates one operation like the single
+// expense endpoints do.
+func (h *ExpenseHandler) parseBatchOperation(req dto.BatchOperationRequest) services.BatchOperation {
+	op := services.BatchOperation{Op: req.Op, ID: req.ID, Version: req.Version}
+
+	var data interface{}
+	switch req.Op {
+	case "create":
+		op.Create = &dto.CreateExpenseRequest{}
+		data = op.Create
+	case "update":
+		op.Update = &dto.UpdateExpenseRequest{}
+		data = op.Update
+	case "delete":
+	default:
+		op.Invalid = errors.New("op must be create, update or delete")
+		return op
+	}
+
+	if req.Op != "create" && req.ID == "" {
+		op.Invalid = errors.New("id is required")
+		return op
+	}
+	if data == nil {
+		return op
+	}
+
+	if len(req.Data) == 0 {
+		op.Invalid = errors.New("data is required")
+		return op
+	}
+	if err := json.Unmarshal(req.Data, data); err != nil {
+		op.Invalid = errors.New("invalid data")
+		return op
+	}
+	if err := h.validator.Validate(data); err != nil {
+		op.Invalid = err
+	}
+	return op
+}
+
+func (h *ExpenseHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	expenses, err := h.expenseService.GetTrash(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(expenses)
+}
+
+func (h *ExpenseHandler) RestoreExpense(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	expenseID := vars["id"]
+
+	response, err := h.expenseService.RestoreExpense(r.Context(), userID, expenseID)
+	if err != nil {
+		writeExpenseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", expenseETag(response.Version))
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ExpenseHandler) PermanentlyDeleteExpense(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	expenseID := vars["id"]
+
+	if err := h.expenseService.PermanentlyDeleteExpense(r.Context(), userID, expenseID); err != nil {
+		writeExpenseError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ExpenseHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	expenseID := vars["id"]
+
+	history, err := h.expenseService.GetHistory(r.Context(), userID, expenseID)
+	if err != nil {
+		writeExpenseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+func (h *ExpenseHandler) RevertExpense(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	expenseID := vars["id"]
+	revision, err := strconv.Atoi(vars["revision"])
+	if err != nil {
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.expenseService.RevertExpense(r.Context(), userID, expenseID, revision)
+	if err != nil {
+		writeExpenseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", expenseETag(response.Version))
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeExpenseError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrExpenseNotFound), errors.Is(err, services.ErrRevisionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrVersionMismatch):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ifMatchVersion returns the version an update or delete is conditional on,
+// or 0 for no condition ("*" or, unless required, a missing header). It
+// writes the error response itself when ok is false.
+func (h *ExpenseHandler) ifMatchVersion(w http.ResponseWriter, r *http.Request) (version int, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch header {
+	case "":
+		if h.requireIfMatch {
+			http.Error(w, "If-Match header is required", http.StatusPreconditionRequired)
+			return 0, false
+		}
+		return 0, true
+	case "*":
+		return 0, true
+	}
+
+	// If-Match uses strong comparison, so weak or malformed tags never match
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version < 1 || header != expenseETag(version) {
+		http.Error(w, services.ErrVersionMismatch.Error(), http.StatusPreconditionFailed)
+		return 0, false
+	}
+	return version, true
+}
+
+func expenseETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// noneMatch reports whether an If-None-Match header matches etag, using
+// weak comparison as required for GET.
+func noneMatch(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
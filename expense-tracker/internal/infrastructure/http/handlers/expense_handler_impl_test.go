@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/events"
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/infrastructure/http/middleware"
+	"expense-tracker/internal/infrastructure/repositories/memory"
+	"expense-tracker/internal/pkg/validation"
+
+	"github.com/gorilla/mux"
+)
+
+const testUserID = "user-1"
+
+func newTestExpenseService() *services.ExpenseService {
+	store := memory.NewStore()
+	bus := events.NewBus(memory.NewOutboxRepository(store))
+	return services.NewExpenseService(bus.Transactional(memory.NewTxManager(store)), memory.NewExpenseRepository(store), memory.NewExpenseRevisionRepository(store), bus)
+}
+
+func createTestExpense(t *testing.T, service *services.ExpenseService) *dto.ExpenseResponse {
+	t.Helper()
+	expense, err := service.CreateExpense(context.Background(), testUserID, dto.CreateExpenseRequest{
+		Amount: 12.5, Category: "groceries", Description: "Bread", Date: "2026-01-15",
+	})
+	if err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	return expense
+}
+
+// serve calls handler as the test user, with the route variables vars.
+func serve(handler http.HandlerFunc, r *http.Request, vars map[string]string) *httptest.ResponseRecorder {
+	r = r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, testUserID))
+	r = mux.SetURLVars(r, vars)
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func TestExpenseConditionalRequests(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		header         string // If-None-Match for GET, If-Match otherwise
+		requireIfMatch bool
+		wantStatus     int
+		wantETag       string
+	}{
+		{"get", http.MethodGet, "", false, http.StatusOK, `"1"`},
+		{"get matching", http.MethodGet, `"1"`, false, http.StatusNotModified, `"1"`},
+		{"get matching weak", http.MethodGet, `W/"1"`, false, http.StatusNotModified, `"1"`},
+		{"get matching in a list", http.MethodGet, `"7", "1"`, false, http.StatusNotModified, `"1"`},
+		{"get matching any", http.MethodGet, `*`, false, http.StatusNotModified, `"1"`},
+		{"get stale", http.MethodGet, `"2"`, false, http.StatusOK, `"1"`},
+
+		{"update current", http.MethodPut, `"1"`, false, http.StatusOK, `"2"`},
+		{"update stale", http.MethodPut, `"2"`, false, http.StatusPreconditionFailed, ""},
+		{"update weak", http.MethodPut, `W/"1"`, false, http.StatusPreconditionFailed, ""},
+		{"update malformed", http.MethodPut, `1`, false, http.StatusPreconditionFailed, ""},
+		{"update any", http.MethodPut, `*`, false, http.StatusOK, `"2"`},
+		{"update unconditional", http.MethodPut, "", false, http.StatusOK, `"2"`},
+		{"update unconditional when required", http.MethodPut, "", true, http.StatusPreconditionRequired, ""},
+
+		{"delete current", http.MethodDelete, `"1"`, true, http.StatusNoContent, ""},
+		{"delete stale", http.MethodDelete, `"3"`, false, http.StatusPreconditionFailed, ""},
+		{"delete unconditional when required", http.MethodDelete, "", true, http.StatusPreconditionRequired, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestExpenseService()
+			expense := createTestExpense(t, service)
+			handler := NewExpenseHandler(service, validation.NewValidator(), tt.requireIfMatch)
+
+			var r *http.Request
+			var fn http.HandlerFunc
+			switch tt.method {
+			case http.MethodGet:
+				r = httptest.NewRequest(tt.method, "/api/expenses/"+expense.ID, nil)
+				r.Header.Set("If-None-Match", tt.header)
+				fn = handler.GetExpense
+			case http.MethodPut:
+				r = httptest.NewRequest(tt.method, "/api/expenses/"+expense.ID, strings.NewReader(`{"amount": 20}`))
+				r.Header.Set("If-Match", tt.header)
+				fn = handler.UpdateExpense
+			case http.MethodDelete:
+				r = httptest.NewRequest(tt.method, "/api/expenses/"+expense.ID, nil)
+				r.Header.Set("If-Match", tt.header)
+				fn = handler.DeleteExpense
+			}
+
+			w := serve(fn, r, map[string]string{"id": expense.ID})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, strings.TrimSpace(w.Body.String()))
+			}
+			if got := w.Header().Get("ETag"); got != tt.wantETag {
+				t.Fatalf("ETag = %q, want %q", got, tt.wantETag)
+			}
+			if w.Code == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Fatalf("304 response has a body: %q", w.Body.String())
+			}
+		})
+	}
+}
+
+// Two clients editing the same version: the second one must not overwrite
+// the first one's change.
+func TestExpenseLostUpdateIsRefused(t *testing.T) {
+	service := newTestExpenseService()
+	expense := createTestExpense(t, service)
+	handler := NewExpenseHandler(service, validation.NewValidator(), true)
+	vars := map[string]string{"id": expense.ID}
+
+	update := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPut, "/api/expenses/"+expense.ID, strings.NewReader(body))
+		r.Header.Set("If-Match", `"1"`)
+		return serve(handler.UpdateExpense, r, vars)
+	}
+
+	if w := update(`{"amount": 20}`); w.Code != http.StatusOK {
+		t.Fatalf("first update: status %d", w.Code)
+	}
+	if w := update(`{"amount": 30}`); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("second update: status %d, want 412", w.Code)
+	}
+
+	current, err := service.GetExpense(context.Background(), testUserID, expense.ID)
+	if err != nil {
+		t.Fatalf("GetExpense: %v", err)
+	}
+	if current.Amount != 20 || current.Version != 2 {
+		t.Fatalf("expense has amount %v at version %d, want 20 at version 2", current.Amount, current.Version)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/infrastructure/http/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+func (h *ExportHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	response, err := h.exportService.RequestExport(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusAccepted
+	if response.Status == string(entities.DataExportCompleted) {
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/me/exports/"+response.ID)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	response, err := h.exportService.GetExport(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, services.ErrExportNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ExportHandler) ListExports(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	responses, err := h.exportService.ListExports(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(responses)
+}
+
+// Download serves an archive through a signed, expiring link so it can be
+// fetched by a browser without an Authorization header.
+func (h *ExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	exportID := mux.Vars(r)["id"]
+	query := r.URL.Query()
+
+	file, err := h.exportService.OpenDownload(r.Context(), exportID, query.Get("expires"), query.Get("signature"))
+	if err != nil {
+		if errors.Is(err, services.ErrExportLinkInvalid) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="expense-tracker-export-`+exportID+`.zip"`)
+	io.Copy(w, file)
+}
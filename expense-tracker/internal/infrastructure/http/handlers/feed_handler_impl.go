@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/infrastructure/http/middleware"
+
+	"github.com/gorilla/websocket"
+)
+
+// feedWriteWait bounds every write to a feed connection, so a client that
+// stops reading cannot hold the handler forever.
+const feedWriteWait = 10 * time.Second
+
+// AccountChecker reports services.ErrAccountDisabled or
+// services.ErrInvalidToken once a user may no longer use their tokens.
+type AccountChecker interface {
+	CheckAccount(ctx context.Context, userID string) error
+}
+
+type FeedHandler struct {
+	feedService *services.FeedService
+	accounts    AccountChecker
+	heartbeat   time.Duration
+	upgrader    websocket.Upgrader
+}
+
+// NewFeedHandler creates the feed handler. Connections are checked against
+// accounts at every heartbeat, so a disabled or deleted user does not keep
+// receiving events on a connection opened before.
+func NewFeedHandler(feedService *services.FeedService, accounts AccountChecker, heartbeat time.Duration) *FeedHandler {
+	return &FeedHandler{
+		feedService: feedService,
+		accounts:    accounts,
+		heartbeat:   heartbeat,
+		upgrader: websocket.Upgrader{
+			// Connections are authenticated with a token, not cookies, so
+			// other origins gain nothing from connecting
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// Stream sends the user's expense events as Server-Sent Events. Clients
+// resume with the Last-Event-ID header or the last_event_id parameter.
+func (h *FeedHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	sub, err := h.connect(w, userID, lastEventID)
+	if err != nil {
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	write := func(message string) error {
+		rc.SetWriteDeadline(time.Now().Add(feedWriteWait))
+		if _, err := io.WriteString(w, message); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	if err := write("retry: 3000\n\n"); err != nil {
+		return
+	}
+	for _, event := range sub.Replay {
+		if err := write(sseMessage(event)); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			// A closed channel means the client fell behind; it reconnects
+			// with its Last-Event-ID and catches up from the history
+			if !ok {
+				return
+			}
+			if err := write(sseMessage(event)); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if h.revoked(r.Context(), userID) != nil {
+				return
+			}
+			if err := write(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// WebSocket sends the same events as Stream, one JSON message each. The
+// resume point is passed as the last_event_id parameter.
+func (h *FeedHandler) WebSocket(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sub, err := h.connect(w, userID, r.URL.Query().Get("last_event_id"))
+	if err != nil {
+		return
+	}
+	defer sub.Close()
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// Clients only send control frames; reading handles pongs and notices
+	// when the connection goes away
+	pongWait := 2 * h.heartbeat
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(event dto.FeedEvent) error {
+		conn.SetWriteDeadline(time.Now().Add(feedWriteWait))
+		return conn.WriteJSON(event)
+	}
+
+	for _, event := range sub.Replay {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+				switch err := sub.Err(); {
+				case errors.Is(err, services.ErrFeedOverflow):
+					message = websocket.FormatCloseMessage(websocket.CloseTryAgainLater, err.Error())
+				case errors.Is(err, services.ErrFeedClosed):
+					message = websocket.FormatCloseMessage(websocket.CloseServiceRestart, err.Error())
+				}
+				conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(feedWriteWait))
+				return
+			}
+			if err := send(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := h.revoked(r.Context(), userID); err != nil {
+				message := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error())
+				conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(feedWriteWait))
+				return
+			}
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(feedWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// revoked returns the reason the user may no longer receive events. A
+// failed lookup keeps the connection open.
+func (h *FeedHandler) revoked(ctx context.Context, userID string) error {
+	err := h.accounts.CheckAccount(ctx, userID)
+	if errors.Is(err, services.ErrAccountDisabled) || errors.Is(err, services.ErrInvalidToken) {
+		return err
+	}
+	return nil
+}
+
+func (h *FeedHandler) connect(w http.ResponseWriter, userID, lastEventID string) (*services.FeedSubscription, error) {
+	sub, err := h.feedService.Connect(userID, lastEventID)
+	if err != nil {
+		if errors.Is(err, services.ErrTooManyFeedConnections) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		} else if errors.Is(err, services.ErrFeedClosed) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return nil, err
+	}
+	return sub, nil
+}
+
+func sseMessage(event dto.FeedEvent) string {
+	data, _ := json.Marshal(event)
+	return fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
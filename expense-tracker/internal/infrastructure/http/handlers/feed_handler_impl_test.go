@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/events"
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/infrastructure/http/middleware"
+	"expense-tracker/internal/infrastructure/repositories/memory"
+
+	"github.com/gorilla/websocket"
+)
+
+// accountStatus is an AccountChecker answering err for every user.
+type accountStatus struct{ err error }
+
+func (a *accountStatus) CheckAccount(ctx context.Context, userID string) error { return a.err }
+
+type feedFixture struct {
+	bus    *events.Bus
+	relay  *events.Relay
+	server *httptest.Server
+}
+
+func newFeedFixture(t *testing.T, accounts AccountChecker, heartbeat time.Duration) *feedFixture {
+	t.Helper()
+	store := memory.NewStore()
+	outbox := memory.NewOutboxRepository(store)
+	bus := events.NewBus(outbox)
+	service := services.NewFeedService(10, 10, 0, time.Hour)
+	service.Subscribe(bus)
+	handler := NewFeedHandler(service, accounts, heartbeat)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stream", handler.Stream)
+	mux.HandleFunc("/ws", handler.WebSocket)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, testUserID)))
+	}))
+	t.Cleanup(func() {
+		service.Close()
+		server.Close()
+	})
+
+	return &feedFixture{
+		bus:    bus,
+		relay:  events.NewRelay(bus, outbox, memory.NewLeaseRepository(store), events.RelayPolicy{BatchSize: 100, MaxAttempts: 1}),
+		server: server,
+	}
+}
+
+// publish sends expense events for the test user through the outbox and
+// waits until the feed has them.
+func (f *feedFixture) publish(t *testing.T, expenseIDs ...string) {
+	t.Helper()
+	for _, id := range expenseIDs {
+		f.bus.Publish(context.Background(), events.ExpenseCreated{Expense: entities.Expense{ID: id, UserID: testUserID}})
+	}
+	if _, _, err := f.relay.ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("ProcessBatch: %v", err)
+	}
+}
+
+// sseEvents reads events from an SSE stream until n have arrived.
+func sseEvents(t *testing.T, body *bufio.Reader, n int) []dto.FeedEvent {
+	t.Helper()
+	var received []dto.FeedEvent
+	var id string
+	for len(received) < n {
+		line, err := body.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading stream after %d events: %v", len(received), err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			var event dto.FeedEvent
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+				t.Fatalf("decoding event: %v", err)
+			}
+			if event.ID != id {
+				t.Fatalf("event data has ID %q, the id field %q", event.ID, id)
+			}
+			received = append(received, event)
+		}
+	}
+	return received
+}
+
+// expenseIDs names events by their expense, and reset events as "reset".
+func expenseIDs(events []dto.FeedEvent) []string {
+	var ids []string
+	for _, event := range events {
+		if event.Expense == nil {
+			ids = append(ids, event.Type)
+			continue
+		}
+		ids = append(ids, event.Expense.ID)
+	}
+	return ids
+}
+
+func openStream(t *testing.T, url, lastEventID string) *bufio.Reader {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("opening stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("stream answered %d with %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+func TestFeedStreamResumesFromLastEventID(t *testing.T) {
+	f := newFeedFixture(t, &accountStatus{}, time.Minute)
+
+	first := openStream(t, f.server.URL+"/stream", "")
+	f.publish(t, "e1", "e2")
+	seen := sseEvents(t, first, 2)
+
+	// Missed while disconnected
+	f.publish(t, "e3", "e4")
+
+	tests := []struct {
+		name string
+		url  string
+		// Last-Event-ID header
+		lastEventID string
+		want        []string
+	}{
+		{"after the first event", "/stream", seen[0].ID, []string{"e2", "e3", "e4"}},
+		{"after the second event", "/stream", seen[1].ID, []string{"e3", "e4"}},
+		{"query parameter", "/stream?last_event_id=" + seen[1].ID, "", []string{"e3", "e4"}},
+		{"unknown position", "/stream", "stale-1", []string{dto.FeedReset}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resumed := openStream(t, f.server.URL+tt.url, tt.lastEventID)
+			got := sseEvents(t, resumed, len(tt.want))
+			if !slices.Equal(expenseIDs(got), tt.want) {
+				t.Fatalf("replayed %q, want %q", expenseIDs(got), tt.want)
+			}
+
+			// Live events follow the replay
+			f.publish(t, "live")
+			if live := sseEvents(t, resumed, 1); live[0].Expense == nil || live[0].Expense.ID != "live" {
+				t.Fatalf("after the replay got %+v, want the live event", live[0])
+			}
+		})
+	}
+}
+
+func TestFeedWebSocketResumesFromLastEventID(t *testing.T) {
+	f := newFeedFixture(t, &accountStatus{}, time.Minute)
+	wsURL := "ws" + strings.TrimPrefix(f.server.URL, "http") + "/ws"
+
+	read := func(conn *websocket.Conn, n int) []dto.FeedEvent {
+		var received []dto.FeedEvent
+		for len(received) < n {
+			var event dto.FeedEvent
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			if err := conn.ReadJSON(&event); err != nil {
+				t.Fatalf("reading after %d events: %v", len(received), err)
+			}
+			received = append(received, event)
+		}
+		return received
+	}
+
+	first, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	f.publish(t, "e1", "e2")
+	seen := read(first, 2)
+	first.Close()
+
+	f.publish(t, "e3")
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?last_event_id="+seen[0].ID, nil)
+	if err != nil {
+		t.Fatalf("dial with last_event_id: %v", err)
+	}
+	defer conn.Close()
+	if got := expenseIDs(read(conn, 2)); !slices.Equal(got, []string{"e2", "e3"}) {
+		t.Fatalf("replayed %q, want e2 and e3", got)
+	}
+}
+
+func TestFeedClosesRevokedConnections(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantClosed bool
+	}{
+		{"active account", nil, false},
+		{"disabled account", services.ErrAccountDisabled, true},
+		{"deleted account", services.ErrInvalidToken, true},
+		{"failed lookup", errors.New("database is down"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" over SSE", func(t *testing.T) {
+			f := newFeedFixture(t, &accountStatus{err: tt.err}, 100*time.Millisecond)
+			stream := openStream(t, f.server.URL+"/stream", "")
+
+			heartbeats := 0
+			for heartbeats < 2 {
+				line, err := stream.ReadString('\n')
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("reading stream: %v", err)
+				}
+				if line == ": heartbeat\n" {
+					heartbeats++
+				}
+			}
+			if closed := heartbeats < 2; closed != tt.wantClosed {
+				t.Fatalf("stream closed = %v after %d heartbeats, want %v", closed, heartbeats, tt.wantClosed)
+			}
+		})
+
+		t.Run(tt.name+" over WebSocket", func(t *testing.T) {
+			f := newFeedFixture(t, &accountStatus{err: tt.err}, 100*time.Millisecond)
+			conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(f.server.URL, "http")+"/ws", nil)
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.Close()
+
+			// Pings are answered while reading; an open connection times out
+			conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+			_, _, err = conn.ReadMessage()
+			if !tt.wantClosed {
+				if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+					t.Fatalf("read = %v, want the connection to stay open", err)
+				}
+				return
+			}
+			if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+				t.Fatalf("read = %v, want a policy violation close", err)
+			}
+		})
+	}
+}
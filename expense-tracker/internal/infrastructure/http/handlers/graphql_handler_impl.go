@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/infrastructure/http/middleware"
+	"expense-tracker/internal/infrastructure/ratelimit"
+	"expense-tracker/internal/pkg/validation"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const maxGraphQLRequestSize = 1 << 20
+
+type GraphQLHandler struct {
+	expenseService *services.ExpenseService
+	accountService *services.AccountService
+	authService    *services.AuthService
+	validator      *validation.Validator
+	loginLimits    ratelimit.LoginLimits
+	trustProxy     bool
+	limits         GraphQLLimits
+	requireVersion bool
+	schema         graphql.Schema
+}
+
+// NewGraphQLHandler builds the schema; it panics if the schema is invalid,
+// which is a programming error. With requireVersion set, updateExpense and
+// deleteExpense need a version, like If-Match on the REST endpoints.
+func NewGraphQLHandler(expenseService *services.ExpenseService, accountService *services.AccountService, authService *services.AuthService, validator *validation.Validator, loginLimits ratelimit.LoginLimits, trustProxy bool, limits GraphQLLimits, requireVersion bool) *GraphQLHandler {
+	h := &GraphQLHandler{
+		expenseService: expenseService,
+		accountService: accountService,
+		authService:    authService,
+		validator:      validator,
+		loginLimits:    loginLimits,
+		trustProxy:     trustProxy,
+		limits:         limits,
+		requireVersion: requireVersion,
+	}
+
+	schema, err := h.buildSchema()
+	if err != nil {
+		panic(fmt.Sprintf("invalid GraphQL schema: %v", err))
+	}
+	h.schema = schema
+	return h
+}
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type graphqlContextKey struct{}
+
+// graphqlContext is the per-request state the resolvers share.
+type graphqlContext struct {
+	request   *http.Request
+	userID    string
+	revisions *revisionLoader
+}
+
+// Query runs a GraphQL request, sent as JSON in a POST body or as query
+// parameters of a GET. Mutations are only accepted over POST.
+func (h *GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	var req graphqlRequest
+	if r.Method == http.MethodGet {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				http.Error(w, "Invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	} else if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLRequestSize)).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Query == "" {
+		http.Error(w, "query is required", http.StatusBadRequest)
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		writeGraphQLErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+		return
+	}
+	if result := graphql.ValidateDocument(&h.schema, doc, nil); !result.IsValid {
+		writeGraphQLErrors(w, http.StatusBadRequest, result.Errors)
+		return
+	}
+	if err := checkGraphQLLimits(&h.schema, doc, h.limits); err != nil {
+		writeGraphQLErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+		return
+	}
+	if r.Method == http.MethodGet && isMutation(doc, req.OperationName) {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Mutations must be sent with POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	ctx := context.WithValue(r.Context(), graphqlContextKey{}, &graphqlContext{
+		request:   r,
+		userID:    userID,
+		revisions: newRevisionLoader(h.expenseService, userID),
+	})
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func writeGraphQLErrors(w http.ResponseWriter, status int, errs []gqlerrors.FormattedError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(graphql.Result{Errors: errs})
+}
+
+func isMutation(doc *ast.Document, operationName string) bool {
+	for _, def := range doc.Definitions {
+		operation, ok := def.(*ast.OperationDefinition)
+		if !ok || (operationName != "" && (operation.Name == nil || operation.Name.Value != operationName)) {
+			continue
+		}
+		if operation.Operation == ast.OperationTypeMutation {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *GraphQLHandler) resolveMe(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := graphqlUserID(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	user, err := h.accountService.GetProfile(p.Context, userID)
+	return user, graphqlResolveError(err)
+}
+
+func (h *GraphQLHandler) resolveExpenses(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := graphqlUserID(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := h.expenseService.FindExpenses(p.Context, userID, expenseFilter(p.Args))
+	return expenses, graphqlResolveError(err)
+}
+
+func (h *GraphQLHandler) resolveExpense(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := graphqlUserID(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	expense, err := h.expenseService.GetExpense(p.Context, userID, p.Args["id"].(string))
+	if errors.Is(err, services.ErrExpenseNotFound) {
+		return nil, nil
+	}
+	return expense, graphqlResolveError(err)
+}
+
+func (h *GraphQLHandler) resolveCategoryTotals(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := graphqlUserID(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := h.expenseService.GetCategoryTotals(p.Context, userID, p.Args["startDate"].(time.Time), p.Args["endDate"].(time.Time))
+	return totals, graphqlResolveError(err)
+}
+
+func (h *GraphQLHandler) resolveHistory(p graphql.ResolveParams) (interface{}, error) {
+	state := p.Context.Value(graphqlContextKey{}).(*graphqlContext)
+	expense := p.Source.(*dto.ExpenseResponse)
+
+	load := state.revisions.load(p.Context, expense.ID)
+	return func() (interface{}, error) {
+		history, err := load()
+		return history, graphqlResolveError(err)
+	}, nil
+}
+
+func (h *GraphQLHandler) resolveRegister(p graphql.ResolveParams) (interface{}, error) {
+	input := p.Args["input"].(map[string]interface{})
+	req := dto.RegisterRequest{
+		Email:    input["email"].(string),
+		Password: input["password"].(string),
+		Name:     input["name"].(string),
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return nil, newGraphQLError(err.Error(), "BAD_USER_INPUT")
+	}
+
+	response, err := h.authService.Register(p.Context, req)
+	return response, graphqlResolveError(err)
+}
+
+func (h *GraphQLHandler) resolveLogin(p graphql.ResolveParams) (interface{}, error) {
+	state := p.Context.Value(graphqlContextKey{}).(*graphqlContext)
+	req := dto.LoginRequest{
+		Email:    p.Args["email"].(string),
+		Password: p.Args["password"].(string),
+		ClientIP: middleware.ClientIP(state.request, h.trustProxy),
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return nil, newGraphQLError(err.Error(), "BAD_USER_INPUT")
+	}
+
+	if allowed, retryAfter := h.loginLimits.Allow(p.Context, req.ClientIP, req.Email); !allowed {
+		return nil, &graphqlError{message: "Too many requests", code: "RATE_LIMITED", retryAfter: retryAfter}
+	}
+
+	response, err := h.authService.Login(p.Context, req)
+	return response, graphqlResolveError(err)
+}
+
+func (h *GraphQLHandler) resolveCreateExpense(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := graphqlUserID(p.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	input := p.Args["input"].(map[string]interface{})
+	req := dto.CreateExpenseRequest{
+		Amount:   input["amount"].(float64),
+		Category: input["category"].(string),
+		Date:     input["date"].(time.Time).Format(graphqlDateLayout),
+	}
+	if description, ok := input["description"].(string); ok {
+		req.Description = description
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return nil, newGraphQLError(err.Error(), "BAD_USER_INPUT")
+	}
+
+	expense, err := h.expenseService.CreateExpense(p.Context, userID, req)
+	return expense, graphqlResolveError(err)
+}
+
+func (h *GraphQLHandler) resolveUpdateExpense(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := graphqlUserID(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	version, err := h.expectedVersion(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	input := p.Args["input"].(map[string]interface{})
+	var req dto.UpdateExpenseRequest
+	if amount, ok := input["amount"].(float64); ok {
+		req.Amount = &amount
+	}
+	if category, ok := input["category"].(string); ok {
+		req.Category = &category
+	}
+	if description, ok := input["description"].(string); ok {
+		req.Description = &description
+	}
+	if date, ok := input["date"].(time.Time); ok {
+		formatted := date.Format(graphqlDateLayout)
+		req.Date = &formatted
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return nil, newGraphQLError(err.Error(), "BAD_USER_INPUT")
+	}
+
+	expense, err := h.expenseService.UpdateExpense(p.Context, userID, p.Args["id"].(string), req, version)
+	p.Context.Value(graphqlContextKey{}).(*graphqlContext).revisions.clear()
+	return expense, graphqlResolveError(err)
+}
+
+func (h *GraphQLHandler) resolveDeleteExpense(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := graphqlUserID(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	version, err := h.expectedVersion(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.expenseService.DeleteExpense(p.Context, userID, p.Args["id"].(string), version)
+	p.Context.Value(graphqlContextKey{}).(*graphqlContext).revisions.clear()
+	if err != nil {
+		return nil, graphqlResolveError(err)
+	}
+	return true, nil
+}
+
+func (h *GraphQLHandler) expectedVersion(args map[string]interface{}) (int, error) {
+	version, _ := args["version"].(int)
+	if version < 0 {
+		return 0, newGraphQLError("version must be positive", "BAD_USER_INPUT")
+	}
+	if version == 0 && h.requireVersion {
+		return 0, newGraphQLError("version is required", "BAD_USER_INPUT")
+	}
+	return version, nil
+}
+
+func graphqlUserID(ctx context.Context) (string, error) {
+	state := ctx.Value(graphqlContextKey{}).(*graphqlContext)
+	if state.userID == "" {
+		return "", newGraphQLError("Unauthorized", "UNAUTHENTICATED")
+	}
+	return state.userID, nil
+}
+
+// graphqlError carries a code for clients in the extensions of the error.
+type graphqlError struct {
+	message    string
+	code       string
+	retryAfter time.Duration
+}
+
+func newGraphQLError(message, code string) *graphqlError {
+	return &graphqlError{message: message, code: code}
+}
+
+func (e *graphqlError) Error() string { return e.message }
+
+func (e *graphqlError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.code}
+	if e.retryAfter > 0 {
+		extensions["retryAfter"] = int(math.Ceil(e.retryAfter.Seconds()))
+	}
+	return extensions
+}
+
+// graphqlResolveError maps service errors to coded GraphQL errors. Other
+// errors are logged and reported without their details.
+func graphqlResolveError(err error) error {
+	var lockedErr *services.AccountLockedError
+	var gqlErr *graphqlError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &gqlErr):
+		return gqlErr
+	case errors.As(err, &lockedErr):
+		return &graphqlError{message: err.Error(), code: "RATE_LIMITED", retryAfter: lockedErr.RetryAfter}
+	case errors.Is(err, services.ErrInvalidCredentials):
+		return newGraphQLError(err.Error(), "UNAUTHENTICATED")
+	case errors.Is(err, services.ErrAccountDisabled):
+		return newGraphQLError(err.Error(), "FORBIDDEN")
+	case errors.Is(err, services.ErrExpenseNotFound), errors.Is(err, services.ErrUserNotFound):
+		return newGraphQLError(err.Error(), "NOT_FOUND")
+	case errors.Is(err, services.ErrVersionMismatch):
+		return newGraphQLError(err.Error(), "VERSION_MISMATCH")
+	case errors.Is(err, services.ErrInvalidCategory), errors.Is(err, services.ErrInvalidDate), errors.Is(err, services.ErrEmailExists):
+		return newGraphQLError(err.Error(), "BAD_USER_INPUT")
+	default:
+		slog.Error("GraphQL resolver error", "error", err)
+		return newGraphQLError("Internal server error", "INTERNAL_SERVER_ERROR")
+	}
+}
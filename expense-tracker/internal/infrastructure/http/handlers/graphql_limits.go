@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// graphqlListFactor is how many elements a list field is assumed to have
+// when estimating the cost of a query.
+const graphqlListFactor = 10
+
+// GraphQLLimits bounds the queries the GraphQL endpoint runs. Zero turns a
+// limit off.
+type GraphQLLimits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// queryCost measures the operations of a validated document. The depth is
+// the deepest nesting of fields; the complexity counts every field once,
+// and the fields below a list field graphqlListFactor times. Introspection
+// fields are free so tools can always load the schema.
+type queryCost struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	visiting  map[string]bool
+}
+
+func checkGraphQLLimits(schema *graphql.Schema, doc *ast.Document, limits GraphQLLimits) error {
+	cost := &queryCost{
+		schema:    schema,
+		fragments: make(map[string]*ast.FragmentDefinition),
+		visiting:  make(map[string]bool),
+	}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			cost.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, def := range doc.Definitions {
+		operation, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		var root graphql.Type = schema.QueryType()
+		if operation.Operation == ast.OperationTypeMutation {
+			root = schema.MutationType()
+		}
+		depth, complexity := cost.selections(operation.SelectionSet, root)
+
+		if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+			return fmt.Errorf("query depth %d exceeds the limit of %d", depth, limits.MaxDepth)
+		}
+		if limits.MaxComplexity > 0 && complexity > limits.MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, limits.MaxComplexity)
+		}
+	}
+	return nil
+}
+
+func (c *queryCost) selections(set *ast.SelectionSet, parent graphql.Type) (depth, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	for _, selection := range set.Selections {
+		var d, n int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			d, n = c.field(selection, parent)
+		case *ast.InlineFragment:
+			typ := parent
+			if selection.TypeCondition != nil {
+				typ = c.schema.Type(selection.TypeCondition.Name.Value)
+			}
+			d, n = c.selections(selection.SelectionSet, typ)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := c.fragments[name]
+			if !ok || c.visiting[name] {
+				continue
+			}
+			c.visiting[name] = true
+			d, n = c.selections(fragment.SelectionSet, c.schema.Type(fragment.TypeCondition.Name.Value))
+			c.visiting[name] = false
+		}
+
+		depth = max(depth, d)
+		complexity += n
+	}
+	return depth, complexity
+}
+
+func (c *queryCost) field(field *ast.Field, parent graphql.Type) (depth, complexity int) {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return 0, 0
+	}
+
+	var fields graphql.FieldDefinitionMap
+	switch parent := parent.(type) {
+	case *graphql.Object:
+		fields = parent.Fields()
+	case *graphql.Interface:
+		fields = parent.Fields()
+	}
+	def, ok := fields[field.Name.Value]
+	if !ok {
+		return 1, 1
+	}
+
+	depth, complexity = c.selections(field.SelectionSet, graphql.GetNamed(def.Type).(graphql.Type))
+	if isGraphQLList(def.Type) {
+		complexity *= graphqlListFactor
+	}
+	return depth + 1, complexity + 1
+}
+
+func isGraphQLList(typ graphql.Type) bool {
+	if nonNull, ok := typ.(*graphql.NonNull); ok {
+		typ = nonNull.OfType
+	}
+	_, ok := typ.(*graphql.List)
+	return ok
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"expense-tracker/internal/infrastructure/ratelimit"
+
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+func TestGraphQLLimits(t *testing.T) {
+	h := NewGraphQLHandler(nil, nil, nil, nil, ratelimit.LoginLimits{}, false, GraphQLLimits{}, false)
+
+	tests := []struct {
+		name           string
+		query          string
+		wantDepth      int
+		wantComplexity int
+	}{
+		{"single field", `{ expense(id: "1") { id } }`, 2, 2},
+		{"list field", `{ expenses { id amount } }`, 2, 21},
+		{"nested lists multiply", `{ expenses { history { changes { field } } } }`, 4, 1111},
+		{"list below an object", `{ me { name expenses { id } } }`, 3, 13},
+		{"fragment spread", `{ ...q } fragment q on Query { expense(id: "1") { id } }`, 2, 2},
+		{"inline fragment", `{ me { ... on User { name } } }`, 2, 2},
+		{"deepest operation counts", `query a { me { name } } query b { expenses { history { action } } }`, 3, 111},
+		{"mutation", `mutation { deleteExpense(id: "1") }`, 1, 1},
+		{"introspection is free", `{ __schema { types { name fields { name } } } }`, 0, 0},
+		{"typename is free", `{ __typename expense(id: "1") { __typename id } }`, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			limits := []struct {
+				limits  GraphQLLimits
+				wantErr bool
+			}{
+				{GraphQLLimits{}, false},
+				{GraphQLLimits{MaxDepth: tt.wantDepth, MaxComplexity: tt.wantComplexity}, false},
+				{GraphQLLimits{MaxDepth: tt.wantDepth - 1}, tt.wantDepth > 1},
+				{GraphQLLimits{MaxComplexity: tt.wantComplexity - 1}, tt.wantComplexity > 1},
+			}
+			for _, l := range limits {
+				if err := checkGraphQLLimits(&h.schema, doc, l.limits); (err != nil) != l.wantErr {
+					t.Errorf("with %+v: %v, want error %v", l.limits, err, l.wantErr)
+				}
+			}
+		})
+	}
+}
+
+// Queries over a limit are refused before any resolver runs.
+func TestGraphQLLimitsAreEnforced(t *testing.T) {
+	h := NewGraphQLHandler(nil, nil, nil, nil, ratelimit.LoginLimits{}, false, GraphQLLimits{MaxDepth: 3, MaxComplexity: 100}, false)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantError  string
+	}{
+		{"within the limits", `{ __typename }`, http.StatusOK, ""},
+		{"too deep", `{ me { expenses { history { action } } } }`, http.StatusBadRequest, "query depth 4 exceeds the limit of 3"},
+		{"too complex", `{ expenses { history { action } } }`, http.StatusBadRequest, "query complexity 111 exceeds the limit of 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(graphqlRequest{Query: tt.query})
+			w := httptest.NewRecorder()
+			h.Query(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantError != "" && !strings.Contains(w.Body.String(), tt.wantError) {
+				t.Fatalf("body %s, want the error %q", w.Body, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+
+	"expense-tracker/internal/application/dto"
+	"expense-tracker/internal/application/services"
+)
+
+// revisionLoader batches the history lookups of one GraphQL request. The
+// history resolver of every expense registers its ID and returns a thunk;
+// the executor runs the thunks only once all expenses at that level are
+// resolved, so the first thunk loads the histories of all of them at once.
+type revisionLoader struct {
+	expenseService *services.ExpenseService
+	userID         string
+	pending        []string
+	loaded         map[string][]*dto.ExpenseRevisionResponse
+	failed         map[string]error
+}
+
+func newRevisionLoader(expenseService *services.ExpenseService, userID string) *revisionLoader {
+	return &revisionLoader{
+		expenseService: expenseService,
+		userID:         userID,
+		loaded:         make(map[string][]*dto.ExpenseRevisionResponse),
+		failed:         make(map[string]error),
+	}
+}
+
+func (l *revisionLoader) load(ctx context.Context, expenseID string) func() (interface{}, error) {
+	if _, ok := l.loaded[expenseID]; !ok {
+		l.pending = append(l.pending, expenseID)
+	}
+
+	return func() (interface{}, error) {
+		if len(l.pending) > 0 {
+			l.flush(ctx)
+		}
+		if err := l.failed[expenseID]; err != nil {
+			return nil, err
+		}
+		return l.loaded[expenseID], nil
+	}
+}
+
+func (l *revisionLoader) flush(ctx context.Context) {
+	ids := l.pending
+	l.pending = nil
+
+	histories, err := l.expenseService.GetHistories(ctx, l.userID, ids)
+	for _, id := range ids {
+		if err != nil {
+			l.failed[id] = err
+			continue
+		}
+		history := histories[id]
+		if history == nil {
+			history = []*dto.ExpenseRevisionResponse{}
+		}
+		l.loaded[id] = history
+	}
+}
+
+// clear forgets loaded histories after a mutation may have changed them.
+func (l *revisionLoader) clear() {
+	l.loaded = make(map[string][]*dto.ExpenseRevisionResponse)
+	l.failed = make(map[string]error)
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"expense-tracker/internal/domain/repositories"
+	"expense-tracker/internal/domain/valueobjects"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const graphqlDateLayout = "2006-01-02"
+
+// Fields of the dto types resolve by name, so only fields that compute
+// something have a Resolve function.
+func (h *GraphQLHandler) buildSchema() (graphql.Schema, error) {
+	date := graphql.NewScalar(graphql.ScalarConfig{
+		Name:        "Date",
+		Description: "A calendar date in YYYY-MM-DD format.",
+		Serialize: func(value interface{}) interface{} {
+			if t, ok := value.(time.Time); ok {
+				return t.Format(graphqlDateLayout)
+			}
+			return nil
+		},
+		ParseValue: parseGraphQLDate,
+		ParseLiteral: func(value ast.Value) interface{} {
+			if s, ok := value.(*ast.StringValue); ok {
+				return parseGraphQLDate(s.Value)
+			}
+			return nil
+		},
+	})
+
+	anyValue := graphql.NewScalar(graphql.ScalarConfig{
+		Name:         "Value",
+		Description:  "An expense field value: a number, a string or null.",
+		Serialize:    func(value interface{}) interface{} { return value },
+		ParseValue:   func(value interface{}) interface{} { return value },
+		ParseLiteral: func(value ast.Value) interface{} { return nil },
+	})
+
+	categoryValues := graphql.EnumValueConfigMap{}
+	for _, category := range valueobjects.GetAllCategories() {
+		categoryValues[strings.ToUpper(string(category))] = &graphql.EnumValueConfig{Value: string(category)}
+	}
+	category := graphql.NewEnum(graphql.EnumConfig{Name: "Category", Values: categoryValues})
+
+	fieldChange := graphql.NewObject(graphql.ObjectConfig{
+		Name: "FieldChange",
+		Fields: graphql.Fields{
+			"field": {Type: graphql.NewNonNull(graphql.String)},
+			"old":   {Type: anyValue},
+			"new":   {Type: anyValue},
+		},
+	})
+
+	revision := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Revision",
+		Fields: graphql.Fields{
+			"revision":   {Type: graphql.NewNonNull(graphql.Int)},
+			"action":     {Type: graphql.NewNonNull(graphql.String)},
+			"actorId":    {Type: graphql.NewNonNull(graphql.ID)},
+			"changes":    {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(fieldChange)))},
+			"revertedTo": {Type: graphql.Int},
+			"createdAt":  {Type: graphql.NewNonNull(graphql.DateTime)},
+		},
+	})
+
+	expense := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Expense",
+		Fields: graphql.Fields{
+			"id":          {Type: graphql.NewNonNull(graphql.ID)},
+			"amount":      {Type: graphql.NewNonNull(graphql.Float)},
+			"category":    {Type: graphql.NewNonNull(category)},
+			"description": {Type: graphql.NewNonNull(graphql.String)},
+			"date":        {Type: graphql.NewNonNull(date)},
+			"createdAt":   {Type: graphql.NewNonNull(graphql.DateTime)},
+			"updatedAt":   {Type: graphql.NewNonNull(graphql.DateTime)},
+			"version":     {Type: graphql.NewNonNull(graphql.Int)},
+			"history": {
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(revision))),
+				Description: "Every revision of the expense, oldest first.",
+				Resolve:     h.resolveHistory,
+			},
+		},
+	})
+
+	categoryTotal := graphql.NewObject(graphql.ObjectConfig{
+		Name: "CategoryTotal",
+		Fields: graphql.Fields{
+			"category": {Type: graphql.NewNonNull(category)},
+			"total":    {Type: graphql.NewNonNull(graphql.Float)},
+		},
+	})
+
+	// The arguments of expenses mirror repositories.ExpenseFilter
+	expensesField := &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(expense))),
+		Args: graphql.FieldConfigArgument{
+			"startDate": {Type: date},
+			"endDate":   {Type: date},
+			"category":  {Type: category},
+		},
+		Resolve: h.resolveExpenses,
+	}
+	categoryTotalsField := &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(categoryTotal))),
+		Args: graphql.FieldConfigArgument{
+			"startDate": {Type: graphql.NewNonNull(date)},
+			"endDate":   {Type: graphql.NewNonNull(date)},
+		},
+		Resolve: h.resolveCategoryTotals,
+	}
+
+	user := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":             {Type: graphql.NewNonNull(graphql.ID)},
+			"email":          {Type: graphql.NewNonNull(graphql.String)},
+			"name":           {Type: graphql.NewNonNull(graphql.String)},
+			"pendingEmail":   {Type: graphql.String},
+			"createdAt":      {Type: graphql.NewNonNull(graphql.DateTime)},
+			"expenses":       expensesField,
+			"categoryTotals": categoryTotalsField,
+		},
+	})
+
+	authUser := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AuthUser",
+		Fields: graphql.Fields{
+			"id":    {Type: graphql.NewNonNull(graphql.ID)},
+			"email": {Type: graphql.NewNonNull(graphql.String)},
+			"name":  {Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	authPayload := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AuthPayload",
+		Fields: graphql.Fields{
+			"token": {Type: graphql.NewNonNull(graphql.String)},
+			"user":  {Type: graphql.NewNonNull(authUser)},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": {
+				Type:    graphql.NewNonNull(user),
+				Resolve: h.resolveMe,
+			},
+			"expenses": expensesField,
+			"expense": {
+				Type:    expense,
+				Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: h.resolveExpense,
+			},
+			"categoryTotals": categoryTotalsField,
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"register": {
+				Type: graphql.NewNonNull(authPayload),
+				Args: graphql.FieldConfigArgument{
+					"input": {Type: graphql.NewNonNull(graphql.NewInputObject(graphql.InputObjectConfig{
+						Name: "RegisterInput",
+						Fields: graphql.InputObjectConfigFieldMap{
+							"email":    {Type: graphql.NewNonNull(graphql.String)},
+							"password": {Type: graphql.NewNonNull(graphql.String)},
+							"name":     {Type: graphql.NewNonNull(graphql.String)},
+						},
+					}))},
+				},
+				Resolve: h.resolveRegister,
+			},
+			"login": {
+				Type: graphql.NewNonNull(authPayload),
+				Args: graphql.FieldConfigArgument{
+					"email":    {Type: graphql.NewNonNull(graphql.String)},
+					"password": {Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: h.resolveLogin,
+			},
+			"createExpense": {
+				Type: graphql.NewNonNull(expense),
+				Args: graphql.FieldConfigArgument{
+					"input": {Type: graphql.NewNonNull(graphql.NewInputObject(graphql.InputObjectConfig{
+						Name: "CreateExpenseInput",
+						Fields: graphql.InputObjectConfigFieldMap{
+							"amount":      {Type: graphql.NewNonNull(graphql.Float)},
+							"category":    {Type: graphql.NewNonNull(category)},
+							"description": {Type: graphql.String},
+							"date":        {Type: graphql.NewNonNull(date)},
+						},
+					}))},
+				},
+				Resolve: h.resolveCreateExpense,
+			},
+			"updateExpense": {
+				Type:        graphql.NewNonNull(expense),
+				Description: "Updates the given fields. With a version the update only applies while the expense is still at that version.",
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.ID)},
+					"input": {Type: graphql.NewNonNull(graphql.NewInputObject(graphql.InputObjectConfig{
+						Name: "UpdateExpenseInput",
+						Fields: graphql.InputObjectConfigFieldMap{
+							"amount":      {Type: graphql.Float},
+							"category":    {Type: category},
+							"description": {Type: graphql.String},
+							"date":        {Type: date},
+						},
+					}))},
+					"version": {Type: graphql.Int},
+				},
+				Resolve: h.resolveUpdateExpense,
+			},
+			"deleteExpense": {
+				Type:        graphql.NewNonNull(graphql.Boolean),
+				Description: "Moves the expense to the trash.",
+				Args: graphql.FieldConfigArgument{
+					"id":      {Type: graphql.NewNonNull(graphql.ID)},
+					"version": {Type: graphql.Int},
+				},
+				Resolve: h.resolveDeleteExpense,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func parseGraphQLDate(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	t, err := time.Parse(graphqlDateLayout, s)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// expenseFilter reads the filter arguments of an expenses field.
+func expenseFilter(args map[string]interface{}) repositories.ExpenseFilter {
+	var filter repositories.ExpenseFilter
+	if startDate, ok := args["startDate"].(time.Time); ok {
+		filter.StartDate = &startDate
+	}
+	if endDate, ok := args["endDate"].(time.Time); ok {
+		filter.EndDate = &endDate
+	}
+	if category, ok := args["category"].(string); ok {
+		filter.Category = &category
+	}
+	return filter
+}
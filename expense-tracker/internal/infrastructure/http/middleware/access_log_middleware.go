@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"expense-tracker/internal/pkg/logger"
+)
+
+// AccessLogMiddleware logs every request once it has been served. It must
+// run inside RouteMiddleware and RequestIDMiddleware so the route is known
+// and the record carries the request ID. Server errors are logged as errors and
+// client errors as warnings.
+func AccessLogMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r)
+
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			level := slog.LevelInfo
+			switch {
+			case recorder.statusCode >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case recorder.statusCode >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("route", route),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", recorder.statusCode),
+				slog.Int64("size", recorder.size),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok && info.userID != "" {
+				attrs = append(attrs, slog.String("user_id", info.userID))
+			}
+			logger.FromContext(r.Context()).LogAttrs(r.Context(), level, "request", attrs...)
+		})
+	}
+}
@@ -2,17 +2,25 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
-	"expense-tracker/internal/infrastructure/jwt"
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/pkg/logger"
 )
 
 type contextKey string
 
 const UserIDKey contextKey = "user_id"
 
-func AuthMiddleware(jwtManager *jwt.JWTManager) func(http.Handler) http.Handler {
+// Authenticator returns the user a bearer token was issued to, failing with
+// services.ErrInvalidToken or services.ErrAccountDisabled.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (string, error)
+}
+
+func AuthMiddleware(auth Authenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -27,13 +35,21 @@ func AuthMiddleware(jwtManager *jwt.JWTManager) func(http.Handler) http.Handler
 				return
 			}
 
-			userID, err := jwtManager.ValidateToken(parts[1])
-			if err != nil {
+			userID, err := auth.Authenticate(r.Context(), parts[1])
+			switch {
+			case errors.Is(err, services.ErrInvalidToken):
 				http.Error(w, "Invalid token", http.StatusUnauthorized)
 				return
+			case errors.Is(err, services.ErrAccountDisabled):
+				http.Error(w, "Account is disabled", http.StatusForbidden)
+				return
+			case err != nil:
+				logger.Error(r.Context(), "failed to authenticate request", "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
 			}
 
-			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			ctx := context.WithValue(setRequestUser(r.Context(), userID), UserIDKey, userID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -42,4 +58,38 @@ func AuthMiddleware(jwtManager *jwt.JWTManager) func(http.Handler) http.Handler
 func GetUserIDFromContext(ctx context.Context) (string, bool) {
 	userID, ok := ctx.Value(UserIDKey).(string)
 	return userID, ok
-}
\ No newline at end of file
+}
+
+// QueryTokenAuthMiddleware is AuthMiddleware for clients that cannot set
+// headers, such as EventSource and browser WebSockets: the token may also
+// be passed as the access_token query parameter.
+func QueryTokenAuthMiddleware(auth Authenticator) func(http.Handler) http.Handler {
+	authMiddleware := AuthMiddleware(auth)
+	return func(next http.Handler) http.Handler {
+		authenticated := authMiddleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+				r = r.Clone(r.Context())
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
+
+// OptionalAuthMiddleware authenticates requests that carry a token and lets
+// the others through anonymously, for endpoints that serve both. An invalid
+// token is still rejected.
+func OptionalAuthMiddleware(auth Authenticator) func(http.Handler) http.Handler {
+	authMiddleware := AuthMiddleware(auth)
+	return func(next http.Handler) http.Handler {
+		authenticated := authMiddleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"expense-tracker/internal/application/services"
+	"expense-tracker/internal/infrastructure/http/middleware"
+)
+
+// tokens is an Authenticator mapping tokens to users; other tokens are
+// answered with err.
+type tokens struct {
+	users map[string]string
+	err   error
+}
+
+func (a tokens) Authenticate(ctx context.Context, token string) (string, error) {
+	if userID, ok := a.users[token]; ok {
+		return userID, nil
+	}
+	return "", a.err
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		query         string
+		err           error
+		// wrap picks the middleware under test
+		wrap       func(middleware.Authenticator) func(http.Handler) http.Handler
+		wantStatus int
+		wantUser   string
+	}{
+		{"valid token", "Bearer good", "", nil, middleware.AuthMiddleware, http.StatusOK, "user-1"},
+		{"missing header", "", "", nil, middleware.AuthMiddleware, http.StatusUnauthorized, ""},
+		{"not a bearer token", "Basic good", "", nil, middleware.AuthMiddleware, http.StatusUnauthorized, ""},
+		{"invalid token", "Bearer bad", "", services.ErrInvalidToken, middleware.AuthMiddleware, http.StatusUnauthorized, ""},
+		{"disabled account", "Bearer bad", "", services.ErrAccountDisabled, middleware.AuthMiddleware, http.StatusForbidden, ""},
+		{"lookup failure", "Bearer bad", "", errors.New("database is down"), middleware.AuthMiddleware, http.StatusInternalServerError, ""},
+		{"query token", "", "?access_token=good", nil, middleware.QueryTokenAuthMiddleware, http.StatusOK, "user-1"},
+		{"header wins over the query", "Bearer bad", "?access_token=good", services.ErrInvalidToken, middleware.QueryTokenAuthMiddleware, http.StatusUnauthorized, ""},
+		{"query token ignored", "", "?access_token=good", nil, middleware.AuthMiddleware, http.StatusUnauthorized, ""},
+		{"optional without a token", "", "", nil, middleware.OptionalAuthMiddleware, http.StatusOK, ""},
+		{"optional with a token", "Bearer good", "", nil, middleware.OptionalAuthMiddleware, http.StatusOK, "user-1"},
+		{"optional with a disabled account", "Bearer bad", "", services.ErrAccountDisabled, middleware.OptionalAuthMiddleware, http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUser string
+			handler := tt.wrap(tokens{users: map[string]string{"good": "user-1"}, err: tt.err})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUser, _ = middleware.GetUserIDFromContext(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/api/feed"+tt.query, nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+			if gotUser != tt.wantUser {
+				t.Fatalf("handler saw user %q, want %q", gotUser, tt.wantUser)
+			}
+		})
+	}
+}
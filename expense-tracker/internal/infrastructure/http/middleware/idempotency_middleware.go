@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"expense-tracker/internal/infrastructure/idempotency"
+	"expense-tracker/internal/pkg/logger"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyMiddleware replays the first response of a request carrying an
+// Idempotency-Key header when the client retries it. Keys are scoped to the
+// authenticated user, so it must run after AuthMiddleware. Reusing a key for
+// a different request is rejected with 422, and a retry that arrives while
+// the first request is still running gets 409. Server errors are not cached.
+func IdempotencyMiddleware(store idempotency.Store, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			userID, ok := GetUserIDFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+			r.Body.Close()
+			if err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			storeKey := userID + ":" + key
+			requestHash := hashRequest(r, body)
+
+			existing, err := store.Reserve(r.Context(), storeKey, requestHash, ttl)
+			if err != nil {
+				// Fail open: without the store the request is simply not deduplicated.
+				logger.Error(r.Context(), "idempotency store error", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if existing != nil {
+				switch {
+				case existing.RequestHash != requestHash:
+					http.Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+				case !existing.Completed:
+					SetRetryAfter(w, time.Second)
+					http.Error(w, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+				default:
+					replay(w, existing)
+				}
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			completed := false
+			defer func() {
+				// Also runs when the handler panics, so the key is not stuck
+				if !completed {
+					if err := store.Release(context.Background(), storeKey); err != nil {
+						logger.Error(r.Context(), "idempotency store error", "error", err)
+					}
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+
+			if recorder.statusCode >= http.StatusInternalServerError {
+				return
+			}
+			err = store.Complete(context.Background(), storeKey, &idempotency.Response{
+				RequestHash: requestHash,
+				Completed:   true,
+				StatusCode:  recorder.statusCode,
+				Header:      responseHeader(w.Header()),
+				Body:        recorder.body.Bytes(),
+			})
+			if err != nil {
+				logger.Error(r.Context(), "idempotency store error", "error", err)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// hashRequest fingerprints the method, path and body, so a key reused on
+// another endpoint also counts as a different request.
+func hashRequest(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Headers describing the request being served rather than the response; a
+// replay keeps the values of the retry
+var perRequestHeaders = []string{RequestIDHeader}
+
+func responseHeader(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range perRequestHeaders {
+		header.Del(name)
+	}
+	return header
+}
+
+func replay(w http.ResponseWriter, response *idempotency.Response) {
+	for name, values := range response.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(response.StatusCode)
+	w.Write(response.Body)
+}
+
+// responseRecorder passes the response through while keeping a copy.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,229 @@
+package middleware_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/infrastructure/http/middleware"
+	"expense-tracker/internal/infrastructure/idempotency"
+)
+
+type idempotentRequest struct {
+	user      string
+	key       string
+	path      string
+	body      string
+	requestID string
+}
+
+// newIdempotentServer serves requests with a handler answering the statuses
+// in order, 201 once they run out, and counts how often it ran.
+func newIdempotentServer(statuses ...int) (http.Handler, *int) {
+	calls := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		status := http.StatusCreated
+		if calls <= len(statuses) {
+			status = statuses[calls-1]
+		}
+		w.Header().Set("Location", fmt.Sprintf("/api/expenses/%d", calls))
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"call": %d}`, calls)
+	})
+
+	var h http.Handler = middleware.IdempotencyMiddleware(idempotency.NewMemoryStore(), time.Hour)(handler)
+	h = asUser(h)
+	h = middleware.RequestIDMiddleware()(h)
+	return h, &calls
+}
+
+// asUser authenticates requests as the user named in the X-Test-User header.
+func asUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), middleware.UserIDKey, r.Header.Get("X-Test-User"))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func send(h http.Handler, req idempotentRequest) *httptest.ResponseRecorder {
+	if req.user == "" {
+		req.user = "user-1"
+	}
+	if req.path == "" {
+		req.path = "/api/expenses"
+	}
+	r := httptest.NewRequest(http.MethodPost, req.path, strings.NewReader(req.body))
+	r.Header.Set("X-Test-User", req.user)
+	r.Header.Set("Idempotency-Key", req.key)
+	r.Header.Set(middleware.RequestIDHeader, req.requestID)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	type step struct {
+		request      idempotentRequest
+		wantStatus   int
+		wantBody     string
+		wantReplayed bool
+	}
+
+	tests := []struct {
+		name      string
+		statuses  []int
+		steps     []step
+		wantCalls int
+	}{
+		{
+			name: "retry is replayed",
+			steps: []step{
+				{idempotentRequest{key: "k1", body: `{"amount": 1}`, requestID: "first"}, 201, `{"call": 1}`, false},
+				{idempotentRequest{key: "k1", body: `{"amount": 1}`, requestID: "retry"}, 201, `{"call": 1}`, true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "changed body is refused",
+			steps: []step{
+				{idempotentRequest{key: "k1", body: `{"amount": 1}`}, 201, `{"call": 1}`, false},
+				{idempotentRequest{key: "k1", body: `{"amount": 2}`}, 422, "", false},
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "other endpoint is refused",
+			steps: []step{
+				{idempotentRequest{key: "k1", body: `{}`}, 201, `{"call": 1}`, false},
+				{idempotentRequest{key: "k1", body: `{}`, path: "/api/expenses/batch"}, 422, "", false},
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "keys are scoped to the user",
+			steps: []step{
+				{idempotentRequest{key: "k1", body: `{}`, user: "user-1"}, 201, `{"call": 1}`, false},
+				{idempotentRequest{key: "k1", body: `{}`, user: "user-2"}, 201, `{"call": 2}`, false},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "requests without a key are not deduplicated",
+			steps: []step{
+				{idempotentRequest{body: `{}`}, 201, `{"call": 1}`, false},
+				{idempotentRequest{body: `{}`}, 201, `{"call": 2}`, false},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:     "client errors are replayed",
+			statuses: []int{400},
+			steps: []step{
+				{idempotentRequest{key: "k1", body: `{}`}, 400, `{"call": 1}`, false},
+				{idempotentRequest{key: "k1", body: `{}`}, 400, `{"call": 1}`, true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:     "server errors are not cached",
+			statuses: []int{500},
+			steps: []step{
+				{idempotentRequest{key: "k1", body: `{}`}, 500, `{"call": 1}`, false},
+				{idempotentRequest{key: "k1", body: `{}`}, 201, `{"call": 2}`, false},
+				{idempotentRequest{key: "k1", body: `{}`}, 201, `{"call": 2}`, true},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "key too long",
+			steps: []step{
+				{idempotentRequest{key: strings.Repeat("k", 256), body: `{}`}, 400, "", false},
+			},
+			wantCalls: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, calls := newIdempotentServer(tt.statuses...)
+			var location string
+			for i, s := range tt.steps {
+				w := send(h, s.request)
+				if w.Code != s.wantStatus {
+					t.Fatalf("step %d: status %d, want %d (%s)", i+1, w.Code, s.wantStatus, strings.TrimSpace(w.Body.String()))
+				}
+				if s.wantBody != "" && w.Body.String() != s.wantBody {
+					t.Fatalf("step %d: body %q, want %q", i+1, w.Body.String(), s.wantBody)
+				}
+				if replayed := w.Header().Get("Idempotent-Replayed") == "true"; replayed != s.wantReplayed {
+					t.Fatalf("step %d: replayed = %v, want %v", i+1, replayed, s.wantReplayed)
+				}
+				if s.request.requestID != "" {
+					if got := w.Header().Get(middleware.RequestIDHeader); got != s.request.requestID {
+						t.Fatalf("step %d: %s = %q, want the request's %q", i+1, middleware.RequestIDHeader, got, s.request.requestID)
+					}
+				}
+				if s.wantReplayed && w.Header().Get("Location") != location {
+					t.Fatalf("step %d: replay has Location %q, want the original %q", i+1, w.Header().Get("Location"), location)
+				}
+				if !s.wantReplayed {
+					location = w.Header().Get("Location")
+				}
+			}
+			if *calls != tt.wantCalls {
+				t.Fatalf("handler ran %d times, want %d", *calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareRefusesConcurrentRetry(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	})
+	h := asUser(middleware.IdempotencyMiddleware(idempotency.NewMemoryStore(), time.Hour)(handler))
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- send(h, idempotentRequest{key: "k1", body: `{}`}) }()
+	<-started
+
+	w := send(h, idempotentRequest{key: "k1", body: `{}`})
+	if w.Code != http.StatusConflict || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("retry during the first request: status %d, Retry-After %q; want 409 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	close(release)
+	if w := <-first; w.Code != http.StatusCreated {
+		t.Fatalf("first request: status %d", w.Code)
+	}
+}
+
+func TestIdempotencyMiddlewareReleasesKeyAfterPanic(t *testing.T) {
+	panicked := false
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !panicked {
+			panicked = true
+			panic("handler failed")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	h := asUser(middleware.IdempotencyMiddleware(idempotency.NewMemoryStore(), time.Hour)(handler))
+
+	func() {
+		defer func() { recover() }()
+		send(h, idempotentRequest{key: "k1", body: `{}`})
+	}()
+
+	if w := send(h, idempotentRequest{key: "k1", body: `{}`}); w.Code != http.StatusCreated {
+		t.Fatalf("retry after a panic: status %d, want 201", w.Code)
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/infrastructure/ratelimit"
+)
+
+// KeyFunc extracts the bucket key from a request. An empty key skips limiting.
+type KeyFunc func(r *http.Request) string
+
+func RateLimitMiddleware(store ratelimit.Store, limit ratelimit.Limit, keyFunc KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if allowed, retryAfter := ratelimit.Allow(r.Context(), store, limit, keyFunc(r)); !allowed {
+				SetRetryAfter(w, retryAfter)
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SetRetryAfter writes the Retry-After header in whole seconds, rounding up.
+func SetRetryAfter(w http.ResponseWriter, d time.Duration) {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
+// ClientIPKey keys buckets by the client IP address.
+func ClientIPKey(trustProxy bool) KeyFunc {
+	return func(r *http.Request) string {
+		return ratelimit.IPKey(ClientIP(r, trustProxy))
+	}
+}
+
+// LoginAccountKey keys buckets by the email in a JSON login body. The body is
+// restored so the handler can decode it again.
+func LoginAccountKey(r *http.Request) string {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return ratelimit.AccountKey(req.Email)
+}
+
+// ClientIP is ratelimit.ClientIP for an HTTP request. A proxy may add its
+// X-Forwarded-For entry as another header line, so all lines are read.
+func ClientIP(r *http.Request, trustProxy bool) string {
+	forwardedFor := strings.Join(r.Header.Values("X-Forwarded-For"), ",")
+	return ratelimit.ClientIP(r.RemoteAddr, forwardedFor, r.Header.Get("X-Real-IP"), trustProxy)
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"expense-tracker/internal/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+type requestInfoKey struct{}
+
+// requestInfo is shared by the middleware of one request, so the access log
+// learns the user the auth middleware further in identified.
+type requestInfo struct {
+	id     string
+	userID string
+}
+
+// RequestIDMiddleware keeps the caller's X-Request-ID, or generates one,
+// and echoes it on the response. Handlers log through logger.FromContext to
+// have it, and the trace ID when traced, on every record.
+func RequestIDMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = uuid.NewString()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			ctx := context.WithValue(r.Context(), requestInfoKey{}, &requestInfo{id: id})
+			ctx = logger.With(ctx, "request_id", id)
+			if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+				ctx = logger.With(ctx, "trace_id", span.TraceID().String())
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetRequestIDFromContext returns the ID RequestIDMiddleware gave the request.
+func GetRequestIDFromContext(ctx context.Context) (string, bool) {
+	info, ok := ctx.Value(requestInfoKey{}).(*requestInfo)
+	if !ok {
+		return "", false
+	}
+	return info.id, true
+}
+
+// setRequestUser records the authenticated user for the access log and the
+// request's logger.
+func setRequestUser(ctx context.Context, userID string) context.Context {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.userID = userID
+	}
+	return logger.With(ctx, "user_id", userID)
+}
+
+// validRequestID accepts IDs of up to 128 printable ASCII characters, so
+// callers cannot break up log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type routeKey struct{}
+
+// RouteMiddleware matches requests against router and keeps the template of
+// the route they match for the middleware inside it. The request middleware
+// wraps the whole router instead of being added with Router.Use, which only
+// runs for matched routes: requests answered with 404 or 405 are traced,
+// logged and measured too, under the route "unknown".
+func RouteMiddleware(router *mux.Router) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := "unknown"
+			var match mux.RouteMatch
+			if router.Match(r, &match) && match.Route != nil {
+				if template, err := match.Route.GetPathTemplate(); err == nil {
+					route = template
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeKey{}, route)))
+		})
+	}
+}
+
+// routeTemplate returns the route RouteMiddleware matched the request to.
+func routeTemplate(r *http.Request) string {
+	if route, ok := r.Context().Value(routeKey{}).(string); ok {
+		return route
+	}
+	return "unknown"
+}
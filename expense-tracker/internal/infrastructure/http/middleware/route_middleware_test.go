@@ -0,0 +1,51 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"expense-tracker/internal/infrastructure/http/middleware"
+	"expense-tracker/internal/infrastructure/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// Requests no route matches are measured too, under the route "unknown".
+func TestRouteMiddleware(t *testing.T) {
+	collector := metrics.New()
+	router := mux.NewRouter()
+	router.HandleFunc("/api/expenses/{id}", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	handler := middleware.RouteMiddleware(router)(middleware.MetricsMiddleware(collector)(router))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantSeries string
+	}{
+		{"matched route", http.MethodGet, "/api/expenses/42", http.StatusOK, `method="GET",route="/api/expenses/{id}",status="200"`},
+		{"no route", http.MethodGet, "/api/nothing", http.StatusNotFound, `method="GET",route="unknown",status="404"`},
+		{"wrong method", http.MethodPost, "/api/expenses/42", http.StatusMethodNotAllowed, `method="POST",route="unknown",status="405"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			scrape := httptest.NewRecorder()
+			collector.Handler("").ServeHTTP(scrape, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			body, _ := io.ReadAll(scrape.Body)
+			if !strings.Contains(string(body), "expense_tracker_http_requests_total{"+tt.wantSeries+"} 1") {
+				t.Fatalf("no request counted with %s", tt.wantSeries)
+			}
+		})
+	}
+}
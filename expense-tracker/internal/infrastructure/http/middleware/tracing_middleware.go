@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("expense-tracker/internal/infrastructure/http")
+
+// TracingMiddleware starts a server span for every request, continuing the
+// trace of an incoming traceparent header. It must run inside
+// RouteMiddleware so the span is named after the route template.
+func TracingMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			route := routeTemplate(r)
+
+			ctx, span := tracer.Start(ctx, r.Method+" "+route, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			))
+			defer span.End()
+
+			recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.statusCode))
+			// Client errors are the caller's problem, not the server's
+			if recorder.statusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(recorder.statusCode))
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	response  *Response
+	expiresAt time.Time
+}
+
+// MemoryStore is a process-local idempotency store. Expired keys are
+// dropped on the next cleanup.
+type MemoryStore struct {
+	mu          sync.Mutex
+	entries     map[string]*entry
+	lastCleanup time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries:     make(map[string]*entry),
+		lastCleanup: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.cleanup(now)
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expiresAt) {
+		found := *e.response
+		return &found, nil
+	}
+
+	s.entries[key] = &entry{
+		response:  &Response{RequestHash: requestHash},
+		expiresAt: now.Add(ttl),
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key string, response *Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		stored := *response
+		e.response = &stored
+	}
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryStore) cleanup(now time.Time) {
+	if now.Sub(s.lastCleanup) < time.Minute {
+		return
+	}
+	s.lastCleanup = now
+
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
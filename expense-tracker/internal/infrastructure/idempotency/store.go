@@ -0,0 +1,31 @@
+// Package idempotency stores the responses replayed for requests that carry
+// an Idempotency-Key header.
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Response is a response cached under an Idempotency-Key. Until the first
+// request finishes, Completed is false and only RequestHash is set.
+type Response struct {
+	RequestHash string
+	Completed   bool
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+}
+
+// Store keeps responses by key. The in-memory store is enough for a single
+// instance; a shared store lets retries reach any instance.
+type Store interface {
+	// Reserve claims key for a new request. If the key is already taken it
+	// returns the existing entry instead and claims nothing.
+	Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*Response, error)
+	// Complete stores the response of the request that reserved key.
+	Complete(ctx context.Context, key string, response *Response) error
+	// Release drops a reservation so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,21 @@
+package mailer
+
+import (
+	"context"
+
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/pkg/logger"
+)
+
+// LogMailer writes outgoing messages to the log instead of sending them.
+// It stands in until an SMTP or provider-backed mailer is configured.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) SendEmailVerification(ctx context.Context, user *entities.User, email, token string) error {
+	logger.Info(ctx, "email verification", "user_id", user.ID, "email", email, "token", token)
+	return nil
+}
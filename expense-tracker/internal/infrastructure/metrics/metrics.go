@@ -0,0 +1,118 @@
+// Package metrics exposes the server's Prometheus metrics.
+package metrics
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"expense-tracker/internal/application/events"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "expense_tracker"
+
+// Metrics owns a registry with the Go runtime and process collectors and the
+// application's own metrics.
+type Metrics struct {
+	registry        *prometheus.Registry
+	httpRequests    *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+	logins          *prometheus.CounterVec
+	expensesCreated *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time to serve HTTP requests by method, route template and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts over every API by result: success, invalid_credentials, locked or disabled.",
+		}, []string{"result"}),
+		expensesCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "expenses_created_total",
+			Help:      "Expenses created by category.",
+		}, []string{"category"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.logins,
+		m.expensesCreated,
+	)
+	return m
+}
+
+// RegisterDB adds the connection pool statistics of db.
+func (m *Metrics) RegisterDB(db *sqlx.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db.DB, namespace))
+}
+
+// Subscribe counts logins and created expenses. Logins are counted as they
+// happen; created expenses once their transaction has committed, and only
+// once, unlike with the at-least-once delivery of asynchronous subscribers.
+func (m *Metrics) Subscribe(bus *events.Bus) {
+	events.Subscribe(bus, func(ctx context.Context, e events.UserLoggedIn) error {
+		m.logins.WithLabelValues("success").Inc()
+		return nil
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.LoginFailed) error {
+		m.logins.WithLabelValues(e.Reason).Inc()
+		return nil
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.ExpenseCreated) error {
+		category := string(e.Expense.Category)
+		events.AfterCommit(ctx, func() {
+			m.expensesCreated.WithLabelValues(category).Inc()
+		})
+		return nil
+	})
+}
+
+// ObserveRequest records a served HTTP request. route is the template the
+// request matched, so IDs in the path do not create new series.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, code).Inc()
+	m.httpDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// Handler serves the metrics in the Prometheus text format. With a token,
+// scrapers must send it as a bearer token.
+func (m *Metrics) Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return handler
+	}
+
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
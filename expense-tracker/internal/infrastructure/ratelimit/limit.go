@@ -0,0 +1,13 @@
+package ratelimit
+
+// Limit describes a token bucket: Rate tokens are added per second up to
+// Burst tokens.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute builds a Limit refilling n tokens per minute.
+func PerMinute(n, burst int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: burst}
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/pkg/logger"
+)
+
+// Store keeps token buckets by key. The in-memory store is enough for a
+// single instance; shared stores (e.g. Redis) let several instances enforce
+// one limit.
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+// Allow takes a token for key from the bucket. An empty key is always
+// allowed.
+func Allow(ctx context.Context, store Store, limit Limit, key string) (bool, time.Duration) {
+	if key == "" || limit.Rate <= 0 {
+		return true, 0
+	}
+
+	allowed, retryAfter, err := store.Allow(ctx, key, limit)
+	if err != nil {
+		// Fail open: an unavailable store must not take login down.
+		logger.Error(ctx, "rate limit store error", "error", err)
+		return true, 0
+	}
+	return allowed, retryAfter
+}
+
+// LoginLimits are the login rate limits, for logins that do not go through
+// the login endpoint, such as the GraphQL mutation and the gRPC call. They
+// take tokens from the endpoint's buckets, so they cannot be used to get
+// around its limits.
+type LoginLimits struct {
+	Store      Store
+	PerIP      Limit
+	PerAccount Limit
+}
+
+// Allow checks a login attempt for the account from the client IP.
+func (l LoginLimits) Allow(ctx context.Context, clientIP, email string) (bool, time.Duration) {
+	if l.Store == nil {
+		return true, 0
+	}
+	if allowed, retryAfter := Allow(ctx, l.Store, l.PerIP, IPKey(clientIP)); !allowed {
+		return false, retryAfter
+	}
+	return Allow(ctx, l.Store, l.PerAccount, AccountKey(email))
+}
+
+// IPKey is the bucket key of a client IP address.
+func IPKey(clientIP string) string {
+	return "ip:" + clientIP
+}
+
+// AccountKey is the bucket key of the login account with this email.
+func AccountKey(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return ""
+	}
+	return "account:" + email
+}
+
+// ClientIP is the address of the client that made a request over a
+// connection from remoteAddr, host:port or a bare host. With trustProxy
+// set, the connection comes from one proxy and the address it appended to
+// forwardedFor (X-Forwarded-For), or else realIP (X-Real-IP), is used
+// instead. Earlier X-Forwarded-For entries are sent by the client and can
+// be anything.
+func ClientIP(remoteAddr, forwardedFor, realIP string, trustProxy bool) string {
+	if trustProxy {
+		if forwardedFor != "" {
+			entries := strings.Split(forwardedFor, ",")
+			return strings.TrimSpace(entries[len(entries)-1])
+		}
+		if realIP != "" {
+			return strings.TrimSpace(realIP)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,42 @@
+package ratelimit
+
+import "testing"
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		realIP       string
+		trustProxy   bool
+		want         string
+	}{
+		{"connection address", "192.0.2.1:5000", "", "", false, "192.0.2.1"},
+		{"bare host", "192.0.2.1", "", "", false, "192.0.2.1"},
+		{"headers ignored without a proxy", "192.0.2.1:5000", "198.51.100.7", "198.51.100.8", false, "192.0.2.1"},
+		{"entry appended by the proxy", "10.0.0.1:5000", "198.51.100.7", "", true, "198.51.100.7"},
+		{"spoofed entries before it", "10.0.0.1:5000", "203.0.113.9, 203.0.113.10,198.51.100.7", "", true, "198.51.100.7"},
+		{"real IP header", "10.0.0.1:5000", "", " 198.51.100.8 ", true, "198.51.100.8"},
+		{"forwarded for wins", "10.0.0.1:5000", "198.51.100.7", "198.51.100.8", true, "198.51.100.7"},
+		{"proxy without headers", "10.0.0.1:5000", "", "", true, "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClientIP(tt.remoteAddr, tt.forwardedFor, tt.realIP, tt.trustProxy); got != tt.want {
+				t.Fatalf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// A client cannot pick its bucket by sending its own X-Forwarded-For.
+func TestSpoofedForwardedForKeepsTheKey(t *testing.T) {
+	const proxy, client = "10.0.0.1:5000", "198.51.100.7"
+	want := IPKey(ClientIP(proxy, client, "", true))
+	for _, spoofed := range []string{"203.0.113.9", "203.0.113.10, 192.0.2.44"} {
+		if got := IPKey(ClientIP(proxy, spoofed+", "+client, "", true)); got != want {
+			t.Fatalf("key with %q spoofed = %q, want %q", spoofed, got, want)
+		}
+	}
+}
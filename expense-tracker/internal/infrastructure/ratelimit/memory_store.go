@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// MemoryStore is a process-local token bucket store. Buckets idle for more
+// than ten minutes are dropped on the next cleanup.
+type MemoryStore struct {
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:     make(map[string]*bucket),
+		lastCleanup: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.cleanup(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), lastSeen: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.lastSeen).Seconds() * limit.Rate
+	if b.tokens > float64(limit.Burst) {
+		b.tokens = float64(limit.Burst)
+	}
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return false, wait, nil
+}
+
+func (s *MemoryStore) cleanup(now time.Time) {
+	if now.Sub(s.lastCleanup) < time.Minute {
+		return
+	}
+	s.lastCleanup = now
+
+	for key, b := range s.buckets {
+		if now.Sub(b.lastSeen) > 10*time.Minute {
+			delete(s.buckets, key)
+		}
+	}
+}
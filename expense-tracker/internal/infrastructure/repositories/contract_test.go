@@ -0,0 +1,37 @@
+package repositories_test
+
+import (
+	"testing"
+
+	"expense-tracker/internal/infrastructure/database"
+	"expense-tracker/internal/infrastructure/repositories"
+	"expense-tracker/internal/infrastructure/repositories/repositorytest"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func sqlRepositories(db *sqlx.DB) repositorytest.Repositories {
+	return repositorytest.Repositories{
+		Tx:                database.NewTxManager(db),
+		Users:             repositories.NewUserRepository(db),
+		Expenses:          repositories.NewExpenseRepository(db),
+		Revisions:         repositories.NewExpenseRevisionRepository(db),
+		WebhookEndpoints:  repositories.NewWebhookEndpointRepository(db),
+		WebhookDeliveries: repositories.NewWebhookDeliveryRepository(db),
+		Outbox:            repositories.NewOutboxRepository(db),
+		Leases:            repositories.NewLeaseRepository(db),
+	}
+}
+
+func TestSQLiteRepositories(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		return sqlRepositories(repositorytest.SQLite(t))
+	})
+}
+
+// Runs against the database of docker-compose.yml with TEST_DB_HOST=localhost
+func TestPostgresRepositories(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		return sqlRepositories(repositorytest.Postgres(t))
+	})
+}
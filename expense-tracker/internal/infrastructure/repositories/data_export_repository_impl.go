@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/infrastructure/database"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type DataExportRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewDataExportRepository(db *sqlx.DB) *DataExportRepositoryImpl {
+	return &DataExportRepositoryImpl{db: db}
+}
+
+func (r *DataExportRepositoryImpl) conn(ctx context.Context) sqlx.ExtContext {
+	return database.Conn(ctx, r.db)
+}
+
+func (r *DataExportRepositoryImpl) Create(ctx context.Context, export *entities.DataExport) error {
+	export.ID = uuid.New().String()
+	export.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO data_exports (id, user_id, status, file_name, error, expires_at, created_at, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.conn(ctx).ExecContext(ctx, query,
+		export.ID, export.UserID, export.Status, export.FileName, export.Error,
+		export.ExpiresAt, export.CreatedAt, export.CompletedAt)
+
+	return err
+}
+
+func (r *DataExportRepositoryImpl) Update(ctx context.Context, export *entities.DataExport) error {
+	query := `
+		UPDATE data_exports
+		SET status = $1, file_name = $2, error = $3, expires_at = $4, completed_at = $5
+		WHERE id = $6
+	`
+
+	_, err := r.conn(ctx).ExecContext(ctx, query,
+		export.Status, export.FileName, export.Error, export.ExpiresAt,
+		export.CompletedAt, export.ID)
+
+	return err
+}
+
+func (r *DataExportRepositoryImpl) FindByID(ctx context.Context, id string) (*entities.DataExport, error) {
+	query := `
+		SELECT id, user_id, status, file_name, error, expires_at, created_at, completed_at
+		FROM data_exports WHERE id = $1
+	`
+
+	var export entities.DataExport
+	err := sqlx.GetContext(ctx, r.conn(ctx), &export, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &export, err
+}
+
+func (r *DataExportRepositoryImpl) FindByUserID(ctx context.Context, userID string) ([]*entities.DataExport, error) {
+	query := `
+		SELECT id, user_id, status, file_name, error, expires_at, created_at, completed_at
+		FROM data_exports WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	exports := []*entities.DataExport{}
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &exports, query, userID)
+	return exports, err
+}
+
+func (r *DataExportRepositoryImpl) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM data_exports WHERE expires_at IS NOT NULL AND expires_at < $1`
+	result, err := r.conn(ctx).ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/infrastructure/database"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type EmailVerificationRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewEmailVerificationRepository(db *sqlx.DB) *EmailVerificationRepositoryImpl {
+	return &EmailVerificationRepositoryImpl{db: db}
+}
+
+func (r *EmailVerificationRepositoryImpl) conn(ctx context.Context) sqlx.ExtContext {
+	return database.Conn(ctx, r.db)
+}
+
+func (r *EmailVerificationRepositoryImpl) Save(ctx context.Context, verification *entities.EmailVerification) error {
+	verification.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO email_verifications (user_id, email, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			email = excluded.email, token_hash = excluded.token_hash,
+			expires_at = excluded.expires_at, created_at = excluded.created_at
+	`
+
+	_, err := r.conn(ctx).ExecContext(ctx, query,
+		verification.UserID, verification.Email, verification.TokenHash,
+		verification.ExpiresAt, verification.CreatedAt)
+	return err
+}
+
+func (r *EmailVerificationRepositoryImpl) FindByUserID(ctx context.Context, userID string) (*entities.EmailVerification, error) {
+	query := `
+		SELECT user_id, email, token_hash, expires_at, created_at
+		FROM email_verifications WHERE user_id = $1
+	`
+
+	var verification entities.EmailVerification
+	err := sqlx.GetContext(ctx, r.conn(ctx), &verification, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &verification, err
+}
+
+func (r *EmailVerificationRepositoryImpl) FindByTokenHash(ctx context.Context, tokenHash string) (*entities.EmailVerification, error) {
+	query := `
+		SELECT user_id, email, token_hash, expires_at, created_at
+		FROM email_verifications WHERE token_hash = $1
+	`
+
+	var verification entities.EmailVerification
+	err := sqlx.GetContext(ctx, r.conn(ctx), &verification, query, tokenHash)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &verification, err
+}
+
+func (r *EmailVerificationRepositoryImpl) DeleteByUserID(ctx context.Context, userID string) error {
+	query := `DELETE FROM email_verifications WHERE user_id = $1`
+	_, err := r.conn(ctx).ExecContext(ctx, query, userID)
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"expense-tracker/internal/domain/entities"
 	"expense-tracker/internal/domain/repositories"
+	"expense-tracker/internal/infrastructure/database"
 	"fmt"
 	"time"
 
@@ -20,31 +21,36 @@ func NewExpenseRepository(db *sqlx.DB) *ExpenseRepositoryImpl {
 	return &ExpenseRepositoryImpl{db: db}
 }
 
+func (r *ExpenseRepositoryImpl) conn(ctx context.Context) sqlx.ExtContext {
+	return database.Conn(ctx, r.db)
+}
+
 func (r *ExpenseRepositoryImpl) Create(ctx context.Context, expense *entities.Expense) error {
 	expense.ID = uuid.New().String()
 	expense.CreatedAt = time.Now()
 	expense.UpdatedAt = time.Now()
+	expense.Version = 1
 
 	query := `
-		INSERT INTO expenses (id, user_id, amount, category, description, date, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO expenses (id, user_id, amount, category, description, date, created_at, updated_at, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := r.conn(ctx).ExecContext(ctx, query,
 		expense.ID, expense.UserID, expense.Amount, expense.Category,
-		expense.Description, expense.Date, expense.CreatedAt, expense.UpdatedAt)
+		expense.Description, expense.Date, expense.CreatedAt, expense.UpdatedAt, expense.Version)
 
 	return err
 }
 
 func (r *ExpenseRepositoryImpl) FindByID(ctx context.Context, id string) (*entities.Expense, error) {
 	query := `
-		SELECT id, user_id, amount, category, description, date, created_at, updated_at
-		FROM expenses WHERE id = $1
+		SELECT id, user_id, amount, category, description, date, created_at, updated_at, deleted_at, version
+		FROM expenses WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	var expense entities.Expense
-	err := r.db.GetContext(ctx, &expense, query, id)
+	err := sqlx.GetContext(ctx, r.conn(ctx), &expense, query, id)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -52,7 +58,7 @@ func (r *ExpenseRepositoryImpl) FindByID(ctx context.Context, id string) (*entit
 }
 
 func (r *ExpenseRepositoryImpl) FindByUserID(ctx context.Context, userID string, filter repositories.ExpenseFilter) ([]*entities.Expense, error) {
-	query := `SELECT id, user_id, amount, category, description, date, created_at, updated_at FROM expenses WHERE user_id = $1`
+	query := `SELECT id, user_id, amount, category, description, date, created_at, updated_at, deleted_at, version FROM expenses WHERE user_id = $1 AND deleted_at IS NULL`
 	args := []interface{}{userID}
 	argIndex := 2
 
@@ -77,7 +83,7 @@ func (r *ExpenseRepositoryImpl) FindByUserID(ctx context.Context, userID string,
 	query += ` ORDER BY date DESC`
 
 	var expenses []*entities.Expense
-	err := r.db.SelectContext(ctx, &expenses, query, args...)
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &expenses, query, args...)
 	if err == sql.ErrNoRows {
 		return []*entities.Expense{}, nil
 	}
@@ -85,36 +91,52 @@ func (r *ExpenseRepositoryImpl) FindByUserID(ctx context.Context, userID string,
 }
 
 func (r *ExpenseRepositoryImpl) Update(ctx context.Context, expense *entities.Expense) error {
-	expense.UpdatedAt = time.Now()
+	updatedAt := time.Now()
 
 	query := `
 		UPDATE expenses 
-		SET amount = $1, category = $2, description = $3, date = $4, updated_at = $5
-		WHERE id = $6
+		SET amount = $1, category = $2, description = $3, date = $4, updated_at = $5, version = version + 1
+		WHERE id = $6 AND version = $7 AND deleted_at IS NULL
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	err := r.execVersioned(ctx, query,
 		expense.Amount, expense.Category, expense.Description,
-		expense.Date, expense.UpdatedAt, expense.ID)
+		expense.Date, updatedAt, expense.ID, expense.Version)
+	if err != nil {
+		return err
+	}
 
-	return err
+	expense.UpdatedAt = updatedAt
+	expense.Version++
+	return nil
 }
 
-func (r *ExpenseRepositoryImpl) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM expenses WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
-	return err
+func (r *ExpenseRepositoryImpl) Delete(ctx context.Context, expense *entities.Expense) error {
+	deletedAt := time.Now()
+
+	query := `
+		UPDATE expenses SET deleted_at = $1, updated_at = $1, version = version + 1
+		WHERE id = $2 AND version = $3 AND deleted_at IS NULL
+	`
+	if err := r.execVersioned(ctx, query, deletedAt, expense.ID, expense.Version); err != nil {
+		return err
+	}
+
+	expense.DeletedAt = &deletedAt
+	expense.UpdatedAt = deletedAt
+	expense.Version++
+	return nil
 }
 
 func (r *ExpenseRepositoryImpl) GetTotalByCategory(ctx context.Context, userID string, startDate, endDate time.Time) (map[string]float64, error) {
 	query := `
 		SELECT category, SUM(amount) as total
 		FROM expenses 
-		WHERE user_id = $1 AND date >= $2 AND date <= $3
+		WHERE user_id = $1 AND date >= $2 AND date <= $3 AND deleted_at IS NULL
 		GROUP BY category
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID, startDate, endDate)
+	rows, err := r.conn(ctx).QueryContext(ctx, query, userID, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
@@ -131,4 +153,80 @@ func (r *ExpenseRepositoryImpl) GetTotalByCategory(ctx context.Context, userID s
 	}
 
 	return result, nil
-}
\ No newline at end of file
+}
+
+func (r *ExpenseRepositoryImpl) FindTrashedByID(ctx context.Context, id string) (*entities.Expense, error) {
+	query := `
+		SELECT id, user_id, amount, category, description, date, created_at, updated_at, deleted_at, version
+		FROM expenses WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	var expense entities.Expense
+	err := sqlx.GetContext(ctx, r.conn(ctx), &expense, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &expense, err
+}
+
+func (r *ExpenseRepositoryImpl) FindTrashed(ctx context.Context, userID string) ([]*entities.Expense, error) {
+	query := `
+		SELECT id, user_id, amount, category, description, date, created_at, updated_at, deleted_at, version
+		FROM expenses WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`
+
+	expenses := []*entities.Expense{}
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &expenses, query, userID)
+	return expenses, err
+}
+
+func (r *ExpenseRepositoryImpl) Restore(ctx context.Context, expense *entities.Expense) error {
+	updatedAt := time.Now()
+
+	query := `
+		UPDATE expenses SET deleted_at = NULL, updated_at = $1, version = version + 1
+		WHERE id = $2 AND version = $3 AND deleted_at IS NOT NULL
+	`
+	if err := r.execVersioned(ctx, query, updatedAt, expense.ID, expense.Version); err != nil {
+		return err
+	}
+
+	expense.DeletedAt = nil
+	expense.UpdatedAt = updatedAt
+	expense.Version++
+	return nil
+}
+
+// execVersioned runs a write guarded by the expense version and reports
+// ErrStaleVersion when it matched no row.
+func (r *ExpenseRepositoryImpl) execVersioned(ctx context.Context, query string, args ...interface{}) error {
+	result, err := r.conn(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return repositories.ErrStaleVersion
+	}
+	return nil
+}
+
+func (r *ExpenseRepositoryImpl) PermanentDelete(ctx context.Context, id string) error {
+	query := `DELETE FROM expenses WHERE id = $1`
+	_, err := r.conn(ctx).ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *ExpenseRepositoryImpl) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM expenses WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+	result, err := r.conn(ctx).ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
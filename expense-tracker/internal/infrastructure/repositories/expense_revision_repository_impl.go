@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/infrastructure/database"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ExpenseRevisionRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewExpenseRevisionRepository(db *sqlx.DB) *ExpenseRevisionRepositoryImpl {
+	return &ExpenseRevisionRepositoryImpl{db: db}
+}
+
+func (r *ExpenseRevisionRepositoryImpl) conn(ctx context.Context) sqlx.ExtContext {
+	return database.Conn(ctx, r.db)
+}
+
+// revisionRow is the stored form of a revision; changes and values are
+// kept as JSON text.
+type revisionRow struct {
+	ID         string        `db:"id"`
+	ExpenseID  string        `db:"expense_id"`
+	UserID     string        `db:"user_id"`
+	ActorID    string        `db:"actor_id"`
+	Revision   int           `db:"revision"`
+	Action     string        `db:"action"`
+	Changes    string        `db:"changes"`
+	Snapshot   string        `db:"snapshot"`
+	RevertedTo sql.NullInt64 `db:"reverted_to"`
+	CreatedAt  time.Time     `db:"created_at"`
+}
+
+func (row *revisionRow) toEntity() (*entities.ExpenseRevision, error) {
+	revision := &entities.ExpenseRevision{
+		ID:        row.ID,
+		ExpenseID: row.ExpenseID,
+		UserID:    row.UserID,
+		ActorID:   row.ActorID,
+		Revision:  row.Revision,
+		Action:    entities.ExpenseRevisionAction(row.Action),
+		CreatedAt: row.CreatedAt,
+	}
+	if err := json.Unmarshal([]byte(row.Changes), &revision.Changes); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(row.Snapshot), &revision.Values); err != nil {
+		return nil, err
+	}
+	if row.RevertedTo.Valid {
+		revertedTo := int(row.RevertedTo.Int64)
+		revision.RevertedTo = &revertedTo
+	}
+	return revision, nil
+}
+
+const revisionColumns = `id, expense_id, user_id, actor_id, revision, action, changes, snapshot, reverted_to, created_at`
+
+func (r *ExpenseRevisionRepositoryImpl) Create(ctx context.Context, revision *entities.ExpenseRevision) error {
+	if revision.Changes == nil {
+		revision.Changes = []entities.FieldChange{}
+	}
+	changes, err := json.Marshal(revision.Changes)
+	if err != nil {
+		return err
+	}
+	snapshot, err := json.Marshal(revision.Values)
+	if err != nil {
+		return err
+	}
+
+	var last int
+	query := `SELECT COALESCE(MAX(revision), 0) FROM expense_revisions WHERE expense_id = $1`
+	if err := sqlx.GetContext(ctx, r.conn(ctx), &last, query, revision.ExpenseID); err != nil {
+		return err
+	}
+
+	revision.ID = uuid.New().String()
+	revision.Revision = last + 1
+	revision.CreatedAt = time.Now()
+
+	query = `
+		INSERT INTO expense_revisions (` + revisionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err = r.conn(ctx).ExecContext(ctx, query,
+		revision.ID, revision.ExpenseID, revision.UserID, revision.ActorID, revision.Revision,
+		revision.Action, string(changes), string(snapshot), revision.RevertedTo, revision.CreatedAt)
+
+	return err
+}
+
+func (r *ExpenseRevisionRepositoryImpl) FindByExpenseID(ctx context.Context, expenseID string) ([]*entities.ExpenseRevision, error) {
+	query := `SELECT ` + revisionColumns + ` FROM expense_revisions WHERE expense_id = $1 ORDER BY revision`
+	return r.find(ctx, query, expenseID)
+}
+
+func (r *ExpenseRevisionRepositoryImpl) FindByExpenseIDs(ctx context.Context, userID string, expenseIDs []string) ([]*entities.ExpenseRevision, error) {
+	if len(expenseIDs) == 0 {
+		return []*entities.ExpenseRevision{}, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT `+revisionColumns+` FROM expense_revisions WHERE user_id = ? AND expense_id IN (?) ORDER BY expense_id, revision`, userID, expenseIDs)
+	if err != nil {
+		return nil, err
+	}
+	return r.find(ctx, r.db.Rebind(query), args...)
+}
+
+func (r *ExpenseRevisionRepositoryImpl) FindByRevision(ctx context.Context, expenseID string, revision int) (*entities.ExpenseRevision, error) {
+	query := `SELECT ` + revisionColumns + ` FROM expense_revisions WHERE expense_id = $1 AND revision = $2`
+
+	var row revisionRow
+	err := sqlx.GetContext(ctx, r.conn(ctx), &row, query, expenseID, revision)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return row.toEntity()
+}
+
+func (r *ExpenseRevisionRepositoryImpl) FindByUserID(ctx context.Context, userID string) ([]*entities.ExpenseRevision, error) {
+	query := `SELECT ` + revisionColumns + ` FROM expense_revisions WHERE user_id = $1 ORDER BY expense_id, revision`
+	return r.find(ctx, query, userID)
+}
+
+func (r *ExpenseRevisionRepositoryImpl) DeleteByExpenseID(ctx context.Context, expenseID string) error {
+	query := `DELETE FROM expense_revisions WHERE expense_id = $1`
+	_, err := r.conn(ctx).ExecContext(ctx, query, expenseID)
+	return err
+}
+
+func (r *ExpenseRevisionRepositoryImpl) DeleteTrashedBefore(ctx context.Context, before time.Time) error {
+	query := `
+		DELETE FROM expense_revisions WHERE expense_id IN (
+			SELECT id FROM expenses WHERE deleted_at IS NOT NULL AND deleted_at < $1
+		)
+	`
+	_, err := r.conn(ctx).ExecContext(ctx, query, before)
+	return err
+}
+
+func (r *ExpenseRevisionRepositoryImpl) find(ctx context.Context, query string, args ...interface{}) ([]*entities.ExpenseRevision, error) {
+	var rows []revisionRow
+	if err := sqlx.SelectContext(ctx, r.conn(ctx), &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	revisions := make([]*entities.ExpenseRevision, 0, len(rows))
+	for i := range rows {
+		revision, err := rows[i].toEntity()
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+	"expense-tracker/internal/infrastructure/database"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type LeaseRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewLeaseRepository(db *sqlx.DB) *LeaseRepositoryImpl {
+	return &LeaseRepositoryImpl{db: db}
+}
+
+func (r *LeaseRepositoryImpl) conn(ctx context.Context) sqlx.ExtContext {
+	return database.Conn(ctx, r.db)
+}
+
+func (r *LeaseRepositoryImpl) Acquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	// One statement, so two processes cannot both see the lease as free
+	query := `
+		INSERT INTO leases (name, holder, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+		WHERE leases.holder = excluded.holder OR leases.expires_at <= $4
+	`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, name, holder, now.Add(ttl), now)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+func (r *LeaseRepositoryImpl) Release(ctx context.Context, name, holder string) error {
+	query := `DELETE FROM leases WHERE name = $1 AND holder = $2`
+
+	_, err := r.conn(ctx).ExecContext(ctx, query, name, holder)
+	return err
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/infrastructure/database"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type LoginAttemptRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewLoginAttemptRepository(db *sqlx.DB) *LoginAttemptRepositoryImpl {
+	return &LoginAttemptRepositoryImpl{db: db}
+}
+
+func (r *LoginAttemptRepositoryImpl) conn(ctx context.Context) sqlx.ExtContext {
+	return database.Conn(ctx, r.db)
+}
+
+func (r *LoginAttemptRepositoryImpl) Record(ctx context.Context, attempt *entities.LoginAttempt) error {
+	attempt.ID = uuid.New().String()
+	attempt.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO login_attempts (id, email, ip_address, success, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.conn(ctx).ExecContext(ctx, query,
+		attempt.ID, attempt.Email, attempt.IPAddress, attempt.Success, attempt.CreatedAt)
+
+	return err
+}
+
+func (r *LoginAttemptRepositoryImpl) FindFailuresSince(ctx context.Context, email string, since time.Time) ([]*entities.LoginAttempt, error) {
+	query := `
+		SELECT id, email, ip_address, success, created_at
+		FROM login_attempts
+		WHERE email = $1 AND success = $2 AND created_at > $3
+		AND created_at > COALESCE(
+			(SELECT MAX(created_at) FROM login_attempts WHERE email = $1 AND success = $4),
+			$3
+		)
+		ORDER BY created_at ASC
+	`
+
+	attempts := []*entities.LoginAttempt{}
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &attempts, query, email, false, since, true)
+	return attempts, err
+}
+
+func (r *LoginAttemptRepositoryImpl) DeleteFailures(ctx context.Context, email string) error {
+	query := `DELETE FROM login_attempts WHERE email = $1 AND success = $2`
+	_, err := r.conn(ctx).ExecContext(ctx, query, email, false)
+	return err
+}
+
+func (r *LoginAttemptRepositoryImpl) FindByEmail(ctx context.Context, email string, limit int) ([]*entities.LoginAttempt, error) {
+	query := `
+		SELECT id, email, ip_address, success, created_at
+		FROM login_attempts WHERE email = $1
+		ORDER BY created_at DESC
+	`
+	args := []interface{}{email}
+
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
+
+	attempts := []*entities.LoginAttempt{}
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &attempts, query, args...)
+	return attempts, err
+}
@@ -0,0 +1,24 @@
+package memory_test
+
+import (
+	"testing"
+
+	"expense-tracker/internal/infrastructure/repositories/memory"
+	"expense-tracker/internal/infrastructure/repositories/repositorytest"
+)
+
+func TestMemoryRepositories(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		store := memory.NewStore()
+		return repositorytest.Repositories{
+			Tx:                memory.NewTxManager(store),
+			Users:             memory.NewUserRepository(store),
+			Expenses:          memory.NewExpenseRepository(store),
+			Revisions:         memory.NewExpenseRevisionRepository(store),
+			WebhookEndpoints:  memory.NewWebhookEndpointRepository(store),
+			WebhookDeliveries: memory.NewWebhookDeliveryRepository(store),
+			Outbox:            memory.NewOutboxRepository(store),
+			Leases:            memory.NewLeaseRepository(store),
+		}
+	})
+}
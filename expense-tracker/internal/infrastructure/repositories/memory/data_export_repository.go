@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+type DataExportRepository struct {
+	store *Store
+}
+
+func NewDataExportRepository(store *Store) *DataExportRepository {
+	return &DataExportRepository{store: store}
+}
+
+func (r *DataExportRepository) Create(ctx context.Context, export *entities.DataExport) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	export.ID = uuid.New().String()
+	export.CreatedAt = time.Now()
+
+	stored := *export
+	r.store.exports[export.ID] = &stored
+	return nil
+}
+
+func (r *DataExportRepository) Update(ctx context.Context, export *entities.DataExport) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.exports[export.ID]; !ok {
+		return nil
+	}
+	stored := *export
+	r.store.exports[export.ID] = &stored
+	return nil
+}
+
+func (r *DataExportRepository) FindByID(ctx context.Context, id string) (*entities.DataExport, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	export, ok := r.store.exports[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *export
+	return &found, nil
+}
+
+func (r *DataExportRepository) FindByUserID(ctx context.Context, userID string) ([]*entities.DataExport, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	exports := []*entities.DataExport{}
+	for _, export := range r.store.exports {
+		if export.UserID == userID {
+			found := *export
+			exports = append(exports, &found)
+		}
+	}
+	sort.Slice(exports, func(i, j int) bool {
+		return exports[i].CreatedAt.After(exports[j].CreatedAt)
+	})
+	return exports, nil
+}
+
+func (r *DataExportRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var deleted int64
+	for id, export := range r.store.exports {
+		if export.ExpiresAt != nil && export.ExpiresAt.Before(before) {
+			delete(r.store.exports, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+)
+
+type EmailVerificationRepository struct {
+	store *Store
+}
+
+func NewEmailVerificationRepository(store *Store) *EmailVerificationRepository {
+	return &EmailVerificationRepository{store: store}
+}
+
+func (r *EmailVerificationRepository) Save(ctx context.Context, verification *entities.EmailVerification) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	verification.CreatedAt = time.Now()
+
+	stored := *verification
+	r.store.verifications[verification.UserID] = &stored
+	return nil
+}
+
+func (r *EmailVerificationRepository) FindByUserID(ctx context.Context, userID string) (*entities.EmailVerification, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	verification, ok := r.store.verifications[userID]
+	if !ok {
+		return nil, nil
+	}
+	found := *verification
+	return &found, nil
+}
+
+func (r *EmailVerificationRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*entities.EmailVerification, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, verification := range r.store.verifications {
+		if verification.TokenHash == tokenHash {
+			found := *verification
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *EmailVerificationRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.verifications, userID)
+	return nil
+}
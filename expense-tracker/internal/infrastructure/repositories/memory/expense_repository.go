@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/domain/repositories"
+
+	"github.com/google/uuid"
+)
+
+type ExpenseRepository struct {
+	store *Store
+}
+
+func NewExpenseRepository(store *Store) *ExpenseRepository {
+	return &ExpenseRepository{store: store}
+}
+
+func (r *ExpenseRepository) Create(ctx context.Context, expense *entities.Expense) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	expense.ID = uuid.New().String()
+	expense.CreatedAt = time.Now()
+	expense.UpdatedAt = time.Now()
+	expense.Version = 1
+
+	stored := *expense
+	r.store.expenses[expense.ID] = &stored
+	return nil
+}
+
+func (r *ExpenseRepository) FindByID(ctx context.Context, id string) (*entities.Expense, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	expense, ok := r.store.expenses[id]
+	if !ok || expense.DeletedAt != nil {
+		return nil, nil
+	}
+	found := *expense
+	return &found, nil
+}
+
+func (r *ExpenseRepository) FindByUserID(ctx context.Context, userID string, filter repositories.ExpenseFilter) ([]*entities.Expense, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	expenses := []*entities.Expense{}
+	for _, expense := range r.store.expenses {
+		if expense.UserID != userID || expense.DeletedAt != nil {
+			continue
+		}
+		if filter.StartDate != nil && expense.Date.Before(*filter.StartDate) {
+			continue
+		}
+		if filter.EndDate != nil && expense.Date.After(*filter.EndDate) {
+			continue
+		}
+		if filter.Category != nil && string(expense.Category) != *filter.Category {
+			continue
+		}
+
+		found := *expense
+		expenses = append(expenses, &found)
+	}
+
+	sort.SliceStable(expenses, func(i, j int) bool {
+		return expenses[i].Date.After(expenses[j].Date)
+	})
+	return expenses, nil
+}
+
+func (r *ExpenseRepository) Update(ctx context.Context, expense *entities.Expense) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.expenses[expense.ID]
+	if !ok || stored.DeletedAt != nil || stored.Version != expense.Version {
+		return repositories.ErrStaleVersion
+	}
+
+	expense.UpdatedAt = time.Now()
+	expense.Version++
+	updated := *stored
+	updated.Amount = expense.Amount
+	updated.Category = expense.Category
+	updated.Description = expense.Description
+	updated.Date = expense.Date
+	updated.UpdatedAt = expense.UpdatedAt
+	updated.Version = expense.Version
+	r.store.expenses[expense.ID] = &updated
+	return nil
+}
+
+func (r *ExpenseRepository) Delete(ctx context.Context, expense *entities.Expense) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.expenses[expense.ID]
+	if !ok || stored.DeletedAt != nil || stored.Version != expense.Version {
+		return repositories.ErrStaleVersion
+	}
+
+	deletedAt := time.Now()
+	expense.DeletedAt = &deletedAt
+	expense.UpdatedAt = deletedAt
+	expense.Version++
+	trashed := *stored
+	trashed.DeletedAt = expense.DeletedAt
+	trashed.UpdatedAt = expense.UpdatedAt
+	trashed.Version = expense.Version
+	r.store.expenses[expense.ID] = &trashed
+	return nil
+}
+
+func (r *ExpenseRepository) GetTotalByCategory(ctx context.Context, userID string, startDate, endDate time.Time) (map[string]float64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	result := make(map[string]float64)
+	for _, expense := range r.store.expenses {
+		if expense.UserID != userID || expense.DeletedAt != nil || expense.Date.Before(startDate) || expense.Date.After(endDate) {
+			continue
+		}
+		result[string(expense.Category)] += expense.Amount
+	}
+	return result, nil
+}
+
+func (r *ExpenseRepository) FindTrashedByID(ctx context.Context, id string) (*entities.Expense, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	expense, ok := r.store.expenses[id]
+	if !ok || expense.DeletedAt == nil {
+		return nil, nil
+	}
+	found := *expense
+	return &found, nil
+}
+
+func (r *ExpenseRepository) FindTrashed(ctx context.Context, userID string) ([]*entities.Expense, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	expenses := []*entities.Expense{}
+	for _, expense := range r.store.expenses {
+		if expense.UserID != userID || expense.DeletedAt == nil {
+			continue
+		}
+		found := *expense
+		expenses = append(expenses, &found)
+	}
+
+	sort.SliceStable(expenses, func(i, j int) bool {
+		return expenses[i].DeletedAt.After(*expenses[j].DeletedAt)
+	})
+	return expenses, nil
+}
+
+func (r *ExpenseRepository) Restore(ctx context.Context, expense *entities.Expense) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.expenses[expense.ID]
+	if !ok || stored.DeletedAt == nil || stored.Version != expense.Version {
+		return repositories.ErrStaleVersion
+	}
+
+	expense.DeletedAt = nil
+	expense.UpdatedAt = time.Now()
+	expense.Version++
+	restored := *stored
+	restored.DeletedAt = nil
+	restored.UpdatedAt = expense.UpdatedAt
+	restored.Version = expense.Version
+	r.store.expenses[expense.ID] = &restored
+	return nil
+}
+
+func (r *ExpenseRepository) PermanentDelete(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.expenses, id)
+	return nil
+}
+
+func (r *ExpenseRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var purged int64
+	for id, expense := range r.store.expenses {
+		if expense.DeletedAt != nil && expense.DeletedAt.Before(before) {
+			delete(r.store.expenses, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+type ExpenseRevisionRepository struct {
+	store *Store
+}
+
+func NewExpenseRevisionRepository(store *Store) *ExpenseRevisionRepository {
+	return &ExpenseRevisionRepository{store: store}
+}
+
+func (r *ExpenseRevisionRepository) Create(ctx context.Context, revision *entities.ExpenseRevision) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if revision.Changes == nil {
+		revision.Changes = []entities.FieldChange{}
+	}
+
+	existing := r.store.revisions[revision.ExpenseID]
+	revision.ID = uuid.New().String()
+	revision.Revision = len(existing) + 1
+	revision.CreatedAt = time.Now()
+
+	// Append to a copy so a transaction snapshot keeps the old slice intact
+	r.store.revisions[revision.ExpenseID] = append(existing[:len(existing):len(existing)], copyRevision(revision))
+	return nil
+}
+
+func (r *ExpenseRevisionRepository) FindByExpenseID(ctx context.Context, expenseID string) ([]*entities.ExpenseRevision, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	revisions := []*entities.ExpenseRevision{}
+	for _, revision := range r.store.revisions[expenseID] {
+		revisions = append(revisions, copyRevision(revision))
+	}
+	return revisions, nil
+}
+
+func (r *ExpenseRevisionRepository) FindByExpenseIDs(ctx context.Context, userID string, expenseIDs []string) ([]*entities.ExpenseRevision, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	ids := append([]string{}, expenseIDs...)
+	sort.Strings(ids)
+
+	revisions := []*entities.ExpenseRevision{}
+	for i, expenseID := range ids {
+		if i > 0 && ids[i-1] == expenseID {
+			continue
+		}
+		for _, revision := range r.store.revisions[expenseID] {
+			if revision.UserID == userID {
+				revisions = append(revisions, copyRevision(revision))
+			}
+		}
+	}
+	return revisions, nil
+}
+
+func (r *ExpenseRevisionRepository) FindByRevision(ctx context.Context, expenseID string, revision int) (*entities.ExpenseRevision, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	revisions := r.store.revisions[expenseID]
+	if revision < 1 || revision > len(revisions) {
+		return nil, nil
+	}
+	return copyRevision(revisions[revision-1]), nil
+}
+
+func (r *ExpenseRevisionRepository) FindByUserID(ctx context.Context, userID string) ([]*entities.ExpenseRevision, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	revisions := []*entities.ExpenseRevision{}
+	for _, expenseRevisions := range r.store.revisions {
+		for _, revision := range expenseRevisions {
+			if revision.UserID == userID {
+				revisions = append(revisions, copyRevision(revision))
+			}
+		}
+	}
+
+	sort.SliceStable(revisions, func(i, j int) bool {
+		if revisions[i].ExpenseID != revisions[j].ExpenseID {
+			return revisions[i].ExpenseID < revisions[j].ExpenseID
+		}
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+func (r *ExpenseRevisionRepository) DeleteByExpenseID(ctx context.Context, expenseID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.revisions, expenseID)
+	return nil
+}
+
+func (r *ExpenseRevisionRepository) DeleteTrashedBefore(ctx context.Context, before time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, expense := range r.store.expenses {
+		if expense.DeletedAt != nil && expense.DeletedAt.Before(before) {
+			delete(r.store.revisions, id)
+		}
+	}
+	return nil
+}
+
+func copyRevision(revision *entities.ExpenseRevision) *entities.ExpenseRevision {
+	copied := *revision
+	copied.Changes = append([]entities.FieldChange{}, revision.Changes...)
+	if revision.RevertedTo != nil {
+		revertedTo := *revision.RevertedTo
+		copied.RevertedTo = &revertedTo
+	}
+	return &copied
+}
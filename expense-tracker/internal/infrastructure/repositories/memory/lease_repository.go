@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+type lease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+type LeaseRepository struct {
+	store *Store
+}
+
+func NewLeaseRepository(store *Store) *LeaseRepository {
+	return &LeaseRepository{store: store}
+}
+
+func (r *LeaseRepository) Acquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if current, ok := r.store.leases[name]; ok && current.holder != holder && current.expiresAt.After(now) {
+		return false, nil
+	}
+	r.store.leases[name] = lease{holder: holder, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (r *LeaseRepository) Release(ctx context.Context, name, holder string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if current, ok := r.store.leases[name]; ok && current.holder == holder {
+		delete(r.store.leases, name)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+type LoginAttemptRepository struct {
+	store *Store
+}
+
+func NewLoginAttemptRepository(store *Store) *LoginAttemptRepository {
+	return &LoginAttemptRepository{store: store}
+}
+
+func (r *LoginAttemptRepository) Record(ctx context.Context, attempt *entities.LoginAttempt) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	attempt.ID = uuid.New().String()
+	attempt.CreatedAt = time.Now()
+
+	stored := *attempt
+	r.store.loginAttempts = append(r.store.loginAttempts, &stored)
+	return nil
+}
+
+func (r *LoginAttemptRepository) FindFailuresSince(ctx context.Context, email string, since time.Time) ([]*entities.LoginAttempt, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	// Attempts are appended in order, so everything after the last success
+	// is a run of failures.
+	failures := []*entities.LoginAttempt{}
+	for _, attempt := range r.store.loginAttempts {
+		if attempt.Email != email {
+			continue
+		}
+		if attempt.Success {
+			failures = failures[:0]
+			continue
+		}
+		if attempt.CreatedAt.After(since) {
+			found := *attempt
+			failures = append(failures, &found)
+		}
+	}
+	return failures, nil
+}
+
+func (r *LoginAttemptRepository) DeleteFailures(ctx context.Context, email string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// A new slice, so a transaction snapshot keeps the old one intact
+	kept := []*entities.LoginAttempt{}
+	for _, attempt := range r.store.loginAttempts {
+		if attempt.Email != email || attempt.Success {
+			kept = append(kept, attempt)
+		}
+	}
+	r.store.loginAttempts = kept
+	return nil
+}
+
+func (r *LoginAttemptRepository) FindByEmail(ctx context.Context, email string, limit int) ([]*entities.LoginAttempt, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	attempts := []*entities.LoginAttempt{}
+	for i := len(r.store.loginAttempts) - 1; i >= 0; i-- {
+		attempt := r.store.loginAttempts[i]
+		if attempt.Email != email {
+			continue
+		}
+		found := *attempt
+		attempts = append(attempts, &found)
+		if limit > 0 && len(attempts) == limit {
+			break
+		}
+	}
+	return attempts, nil
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+)
+
+type OutboxRepository struct {
+	store *Store
+}
+
+func NewOutboxRepository(store *Store) *OutboxRepository {
+	return &OutboxRepository{store: store}
+}
+
+func (r *OutboxRepository) Append(ctx context.Context, event *entities.OutboxEvent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// Like a database sequence, IDs are not reused after a rollback
+	r.store.outboxSeq++
+	event.ID = r.store.outboxSeq
+	event.CreatedAt = time.Now()
+
+	stored := *event
+	r.store.outbox = append(r.store.outbox, &stored)
+	return nil
+}
+
+func (r *OutboxRepository) FindUnprocessed(ctx context.Context, limit int) ([]*entities.OutboxEvent, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	events := []*entities.OutboxEvent{}
+	for _, event := range r.store.outbox {
+		if event.ProcessedAt != nil {
+			continue
+		}
+		found := *event
+		events = append(events, &found)
+		if len(events) == limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+func (r *OutboxRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*entities.OutboxEvent, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	waiting := make(map[string]bool)
+	events := []*entities.OutboxEvent{}
+	for _, event := range r.store.outbox {
+		if event.ProcessedAt != nil || waiting[event.UserID] {
+			continue
+		}
+		if event.NextAttemptAt != nil && event.NextAttemptAt.After(now) {
+			waiting[event.UserID] = true
+			continue
+		}
+		found := *event
+		events = append(events, &found)
+		if len(events) == limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+func (r *OutboxRepository) FindByUserID(ctx context.Context, userID string) ([]*entities.OutboxEvent, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	events := []*entities.OutboxEvent{}
+	for _, event := range r.store.outbox {
+		if event.UserID == userID {
+			found := *event
+			events = append(events, &found)
+		}
+	}
+	return events, nil
+}
+
+func (r *OutboxRepository) Update(ctx context.Context, event *entities.OutboxEvent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i, stored := range r.store.outbox {
+		if stored.ID == event.ID {
+			updated := *stored
+			updated.Attempts = event.Attempts
+			updated.LastError = event.LastError
+			updated.NextAttemptAt = event.NextAttemptAt
+			updated.ProcessedAt = event.ProcessedAt
+			r.store.outbox[i] = &updated
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *OutboxRepository) DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	kept := make([]*entities.OutboxEvent, 0, len(r.store.outbox))
+	var deleted int64
+	for _, event := range r.store.outbox {
+		if event.ProcessedAt != nil && event.ProcessedAt.Before(before) {
+			deleted++
+			continue
+		}
+		kept = append(kept, event)
+	}
+	r.store.outbox = kept
+	return deleted, nil
+}
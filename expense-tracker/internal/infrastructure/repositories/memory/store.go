@@ -0,0 +1,84 @@
+// Package memory implements the domain repositories on top of in-process
+// maps. It backs demo mode and unit tests and follows the same contract as
+// the SQL implementations, including returning nil, nil when a record does
+// not exist.
+package memory
+
+import (
+	"sync"
+
+	"expense-tracker/internal/domain/entities"
+)
+
+// Store holds every table. Repositories created from the same Store see each
+// other's data, so deleting a user also removes the rows that belong to them.
+type Store struct {
+	mu            sync.RWMutex
+	txMu          sync.Mutex
+	users         map[string]*entities.User
+	expenses      map[string]*entities.Expense
+	revisions     map[string][]*entities.ExpenseRevision // by expense ID
+	loginAttempts []*entities.LoginAttempt
+	verifications map[string]*entities.EmailVerification
+	exports       map[string]*entities.DataExport
+	endpoints     map[string]*entities.WebhookEndpoint
+	deliveries    map[string]*entities.WebhookDelivery
+	outbox        []*entities.OutboxEvent // in ID order
+	outboxSeq     int64
+	leases        map[string]lease // not transactional, like the process locks they stand for
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:         make(map[string]*entities.User),
+		expenses:      make(map[string]*entities.Expense),
+		revisions:     make(map[string][]*entities.ExpenseRevision),
+		verifications: make(map[string]*entities.EmailVerification),
+		exports:       make(map[string]*entities.DataExport),
+		endpoints:     make(map[string]*entities.WebhookEndpoint),
+		deliveries:    make(map[string]*entities.WebhookDelivery),
+		leases:        make(map[string]lease),
+	}
+}
+
+// snapshot copies the table maps. Stored records are never modified in
+// place, so copying the pointers is enough to restore the previous state.
+func (s *Store) snapshot() *Store {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return &Store{
+		users:         copyMap(s.users),
+		expenses:      copyMap(s.expenses),
+		revisions:     copyMap(s.revisions),
+		loginAttempts: append([]*entities.LoginAttempt(nil), s.loginAttempts...),
+		verifications: copyMap(s.verifications),
+		exports:       copyMap(s.exports),
+		endpoints:     copyMap(s.endpoints),
+		deliveries:    copyMap(s.deliveries),
+		outbox:        append([]*entities.OutboxEvent(nil), s.outbox...),
+	}
+}
+
+func (s *Store) restore(snapshot *Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users = snapshot.users
+	s.expenses = snapshot.expenses
+	s.revisions = snapshot.revisions
+	s.loginAttempts = snapshot.loginAttempts
+	s.verifications = snapshot.verifications
+	s.exports = snapshot.exports
+	s.endpoints = snapshot.endpoints
+	s.deliveries = snapshot.deliveries
+	s.outbox = snapshot.outbox
+}
+
+func copyMap[V any](m map[string]V) map[string]V {
+	copied := make(map[string]V, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
@@ -0,0 +1,34 @@
+package memory
+
+import (
+	"context"
+)
+
+type txKey struct{}
+
+// TxManager gives the in-memory repositories transaction semantics:
+// transactions are serialized and a failing fn restores the store to the
+// state it had when the transaction (or nested savepoint) began. Writes made
+// outside a transaction are not isolated from it.
+type TxManager struct {
+	store *Store
+}
+
+func NewTxManager(store *Store) *TxManager {
+	return &TxManager{store: store}
+}
+
+func (m *TxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) == nil {
+		m.store.txMu.Lock()
+		defer m.store.txMu.Unlock()
+		ctx = context.WithValue(ctx, txKey{}, true)
+	}
+
+	snapshot := m.store.snapshot()
+	if err := fn(ctx); err != nil {
+		m.store.restore(snapshot)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+type UserRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) *UserRepository {
+	return &UserRepository{store: store}
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *entities.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.findByEmail(user.Email) != nil {
+		return errDuplicate("users.email")
+	}
+
+	user.ID = uuid.New().String()
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+
+	stored := *user
+	r.store.users[user.ID] = &stored
+	return nil
+}
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*entities.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user := r.findByEmail(email)
+	if user == nil {
+		return nil, nil
+	}
+	found := *user
+	return &found, nil
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id string) (*entities.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *user
+	return &found, nil
+}
+
+func (r *UserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.findByEmail(email) != nil, nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, user *entities.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[user.ID]; !ok {
+		return nil
+	}
+	if existing := r.findByEmail(user.Email); existing != nil && existing.ID != user.ID {
+		return errDuplicate("users.email")
+	}
+
+	user.UpdatedAt = time.Now()
+	stored := *user
+	r.store.users[user.ID] = &stored
+	return nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil
+	}
+
+	for expenseID, expense := range r.store.expenses {
+		if expense.UserID == id {
+			delete(r.store.expenses, expenseID)
+			delete(r.store.revisions, expenseID)
+		}
+	}
+	for exportID, export := range r.store.exports {
+		if export.UserID == id {
+			delete(r.store.exports, exportID)
+		}
+	}
+	for endpointID, endpoint := range r.store.endpoints {
+		if endpoint.UserID == id {
+			delete(r.store.endpoints, endpointID)
+		}
+	}
+	for deliveryID, delivery := range r.store.deliveries {
+		if delivery.UserID == id {
+			delete(r.store.deliveries, deliveryID)
+		}
+	}
+	outbox := make([]*entities.OutboxEvent, 0, len(r.store.outbox))
+	for _, event := range r.store.outbox {
+		if event.UserID != id {
+			outbox = append(outbox, event)
+		}
+	}
+	r.store.outbox = outbox
+	delete(r.store.verifications, id)
+
+	attempts := r.store.loginAttempts[:0]
+	for _, attempt := range r.store.loginAttempts {
+		if attempt.Email != user.Email {
+			attempts = append(attempts, attempt)
+		}
+	}
+	r.store.loginAttempts = attempts
+
+	delete(r.store.users, id)
+	return nil
+}
+
+// findByEmail matches exactly, like the UNIQUE column in SQL. Callers must
+// hold the lock.
+func (r *UserRepository) findByEmail(email string) *entities.User {
+	for _, user := range r.store.users {
+		if user.Email == email {
+			return user
+		}
+	}
+	return nil
+}
+
+func errDuplicate(column string) error {
+	return fmt.Errorf("UNIQUE constraint failed: %s", column)
+}
@@ -0,0 +1,211 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+type WebhookEndpointRepository struct {
+	store *Store
+}
+
+func NewWebhookEndpointRepository(store *Store) *WebhookEndpointRepository {
+	return &WebhookEndpointRepository{store: store}
+}
+
+func (r *WebhookEndpointRepository) Create(ctx context.Context, endpoint *entities.WebhookEndpoint) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	endpoint.ID = uuid.New().String()
+	endpoint.CreatedAt = time.Now()
+	endpoint.UpdatedAt = time.Now()
+
+	r.store.endpoints[endpoint.ID] = copyEndpoint(endpoint)
+	return nil
+}
+
+func (r *WebhookEndpointRepository) Update(ctx context.Context, endpoint *entities.WebhookEndpoint) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.endpoints[endpoint.ID]; !ok {
+		return nil
+	}
+	endpoint.UpdatedAt = time.Now()
+	r.store.endpoints[endpoint.ID] = copyEndpoint(endpoint)
+	return nil
+}
+
+func (r *WebhookEndpointRepository) FindByID(ctx context.Context, id string) (*entities.WebhookEndpoint, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	endpoint, ok := r.store.endpoints[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyEndpoint(endpoint), nil
+}
+
+func (r *WebhookEndpointRepository) FindByUserID(ctx context.Context, userID string) ([]*entities.WebhookEndpoint, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	endpoints := []*entities.WebhookEndpoint{}
+	for _, endpoint := range r.store.endpoints {
+		if endpoint.UserID == userID {
+			endpoints = append(endpoints, copyEndpoint(endpoint))
+		}
+	}
+
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		return endpoints[i].CreatedAt.Before(endpoints[j].CreatedAt)
+	})
+	return endpoints, nil
+}
+
+func (r *WebhookEndpointRepository) Delete(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.endpoints, id)
+	return nil
+}
+
+func copyEndpoint(endpoint *entities.WebhookEndpoint) *entities.WebhookEndpoint {
+	copied := *endpoint
+	copied.Events = append([]string{}, endpoint.Events...)
+	return &copied
+}
+
+type WebhookDeliveryRepository struct {
+	store *Store
+}
+
+func NewWebhookDeliveryRepository(store *Store) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{store: store}
+}
+
+func (r *WebhookDeliveryRepository) Create(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delivery.ID = uuid.New().String()
+	delivery.CreatedAt = time.Now()
+
+	stored := *delivery
+	r.store.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) Update(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.deliveries[delivery.ID]; !ok {
+		return nil
+	}
+	delivery.LockedUntil = nil
+	stored := *delivery
+	r.store.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) FindByID(ctx context.Context, id string) (*entities.WebhookDelivery, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	delivery, ok := r.store.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *delivery
+	return &found, nil
+}
+
+func (r *WebhookDeliveryRepository) FindByEndpointID(ctx context.Context, endpointID string, limit int) ([]*entities.WebhookDelivery, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	deliveries := []*entities.WebhookDelivery{}
+	for _, delivery := range r.store.deliveries {
+		if delivery.EndpointID == endpointID {
+			found := *delivery
+			deliveries = append(deliveries, &found)
+		}
+	}
+
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookDeliveryRepository) ClaimDue(ctx context.Context, now, lockedUntil time.Time, limit int) ([]*entities.WebhookDelivery, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	deliveries := []*entities.WebhookDelivery{}
+	for _, delivery := range r.store.deliveries {
+		if delivery.Status != entities.WebhookDeliveryPending || delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		if delivery.LockedUntil != nil && delivery.LockedUntil.After(now) {
+			continue
+		}
+		found := *delivery
+		deliveries = append(deliveries, &found)
+	}
+
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].NextAttemptAt.Before(*deliveries[j].NextAttemptAt)
+	})
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	for _, delivery := range deliveries {
+		claimed := lockedUntil
+		delivery.LockedUntil = &claimed
+		stored := *delivery
+		r.store.deliveries[delivery.ID] = &stored
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookDeliveryRepository) FailPending(ctx context.Context, endpointID, reason string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, delivery := range r.store.deliveries {
+		if delivery.EndpointID == endpointID && delivery.Status == entities.WebhookDeliveryPending {
+			failed := *delivery
+			failed.Status = entities.WebhookDeliveryFailed
+			failed.NextAttemptAt = nil
+			failed.Error = reason
+			r.store.deliveries[id] = &failed
+		}
+	}
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) DeleteByEndpointID(ctx context.Context, endpointID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, delivery := range r.store.deliveries {
+		if delivery.EndpointID == endpointID {
+			delete(r.store.deliveries, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/infrastructure/database"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type OutboxRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewOutboxRepository(db *sqlx.DB) *OutboxRepositoryImpl {
+	return &OutboxRepositoryImpl{db: db}
+}
+
+func (r *OutboxRepositoryImpl) conn(ctx context.Context) sqlx.ExtContext {
+	return database.Conn(ctx, r.db)
+}
+
+const outboxColumns = `id, user_id, event_type, payload, attempts, last_error, next_attempt_at, processed_at, created_at`
+
+func (r *OutboxRepositoryImpl) Append(ctx context.Context, event *entities.OutboxEvent) error {
+	event.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO outbox_events (user_id, event_type, payload, attempts, last_error, next_attempt_at, processed_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	return r.conn(ctx).QueryRowxContext(ctx, query,
+		event.UserID, event.EventType, event.Payload, event.Attempts, event.LastError,
+		event.NextAttemptAt, event.ProcessedAt, event.CreatedAt).Scan(&event.ID)
+}
+
+func (r *OutboxRepositoryImpl) FindUnprocessed(ctx context.Context, limit int) ([]*entities.OutboxEvent, error) {
+	query := `
+		SELECT ` + outboxColumns + ` FROM outbox_events
+		WHERE processed_at IS NULL
+		ORDER BY id
+		LIMIT $1
+	`
+
+	events := []*entities.OutboxEvent{}
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &events, query, limit)
+	return events, err
+}
+
+func (r *OutboxRepositoryImpl) FindDue(ctx context.Context, now time.Time, limit int) ([]*entities.OutboxEvent, error) {
+	query := `
+		SELECT ` + outboxColumns + ` FROM outbox_events e
+		WHERE processed_at IS NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM outbox_events waiting
+			WHERE waiting.user_id = e.user_id
+			AND waiting.id <= e.id
+			AND waiting.processed_at IS NULL
+			AND waiting.next_attempt_at > $1
+		)
+		ORDER BY id
+		LIMIT $2
+	`
+
+	events := []*entities.OutboxEvent{}
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &events, query, now, limit)
+	return events, err
+}
+
+func (r *OutboxRepositoryImpl) FindByUserID(ctx context.Context, userID string) ([]*entities.OutboxEvent, error) {
+	query := `SELECT ` + outboxColumns + ` FROM outbox_events WHERE user_id = $1 ORDER BY id`
+
+	events := []*entities.OutboxEvent{}
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &events, query, userID)
+	return events, err
+}
+
+func (r *OutboxRepositoryImpl) Update(ctx context.Context, event *entities.OutboxEvent) error {
+	query := `
+		UPDATE outbox_events
+		SET attempts = $1, last_error = $2, next_attempt_at = $3, processed_at = $4
+		WHERE id = $5
+	`
+
+	_, err := r.conn(ctx).ExecContext(ctx, query,
+		event.Attempts, event.LastError, event.NextAttemptAt, event.ProcessedAt, event.ID)
+
+	return err
+}
+
+func (r *OutboxRepositoryImpl) DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM outbox_events WHERE processed_at IS NOT NULL AND processed_at < $1`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,996 @@
+// Package repositorytest is a contract test suite for implementations of the
+// domain repositories. Every implementation (in-memory, SQLite, Postgres)
+// runs the same suite from its own _test.go file, so behaviour such as
+// returning nil, nil for a missing record cannot drift between them:
+//
+//	func TestMemoryRepositories(t *testing.T) {
+//		repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+//			store := memory.NewStore()
+//			return repositorytest.Repositories{
+//				Tx:                memory.NewTxManager(store),
+//				Users:             memory.NewUserRepository(store),
+//				Expenses:          memory.NewExpenseRepository(store),
+//				Revisions:         memory.NewExpenseRevisionRepository(store),
+//				WebhookEndpoints:  memory.NewWebhookEndpointRepository(store),
+//				WebhookDeliveries: memory.NewWebhookDeliveryRepository(store),
+//				Outbox:            memory.NewOutboxRepository(store),
+//				Leases:            memory.NewLeaseRepository(store),
+//			}
+//		})
+//	}
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/domain/repositories"
+	"expense-tracker/internal/domain/valueobjects"
+)
+
+// Repositories is one set of implementations sharing the same backing store.
+type Repositories struct {
+	Tx                repositories.TxManager
+	Users             repositories.UserRepository
+	Expenses          repositories.ExpenseRepository
+	Revisions         repositories.ExpenseRevisionRepository
+	WebhookEndpoints  repositories.WebhookEndpointRepository
+	WebhookDeliveries repositories.WebhookDeliveryRepository
+	Outbox            repositories.OutboxRepository
+	Leases            repositories.LeaseRepository
+}
+
+// Factory returns repositories backed by a fresh, empty store.
+type Factory func(t *testing.T) Repositories
+
+// Run executes the whole contract suite.
+func Run(t *testing.T, newRepos Factory) {
+	t.Run("UserRepository", func(t *testing.T) { RunUserRepository(t, newRepos) })
+	t.Run("ExpenseRepository", func(t *testing.T) { RunExpenseRepository(t, newRepos) })
+	t.Run("ExpenseRevisionRepository", func(t *testing.T) { RunExpenseRevisionRepository(t, newRepos) })
+	t.Run("WebhookRepositories", func(t *testing.T) { RunWebhookRepositories(t, newRepos) })
+	t.Run("OutboxRepository", func(t *testing.T) { RunOutboxRepository(t, newRepos) })
+	t.Run("LeaseRepository", func(t *testing.T) { RunLeaseRepository(t, newRepos) })
+	t.Run("TxManager", func(t *testing.T) { RunTxManager(t, newRepos) })
+}
+
+func RunUserRepository(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	t.Run("CreateAssignsIDAndTimestamps", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "create@example.com")
+
+		if user.ID == "" {
+			t.Fatal("Create did not assign an ID")
+		}
+		if user.CreatedAt.IsZero() || user.UpdatedAt.IsZero() {
+			t.Fatal("Create did not set timestamps")
+		}
+
+		found, err := repos.Users.FindByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found == nil || found.Email != user.Email || found.Name != user.Name || found.Password != user.Password {
+			t.Fatalf("FindByID returned %+v, want %+v", found, user)
+		}
+	})
+
+	t.Run("CreateRejectsDuplicateEmail", func(t *testing.T) {
+		repos := newRepos(t)
+		createUser(t, repos, "dup@example.com")
+
+		err := repos.Users.Create(ctx, &entities.User{Email: "dup@example.com", Password: "hash", Name: "Other"})
+		if err == nil {
+			t.Fatal("Create accepted a duplicate email")
+		}
+	})
+
+	t.Run("NotFoundReturnsNilNil", func(t *testing.T) {
+		repos := newRepos(t)
+
+		byID, err := repos.Users.FindByID(ctx, "missing")
+		if err != nil || byID != nil {
+			t.Fatalf("FindByID = %v, %v; want nil, nil", byID, err)
+		}
+
+		byEmail, err := repos.Users.FindByEmail(ctx, "missing@example.com")
+		if err != nil || byEmail != nil {
+			t.Fatalf("FindByEmail = %v, %v; want nil, nil", byEmail, err)
+		}
+	})
+
+	t.Run("ExistsByEmail", func(t *testing.T) {
+		repos := newRepos(t)
+		createUser(t, repos, "exists@example.com")
+
+		exists, err := repos.Users.ExistsByEmail(ctx, "exists@example.com")
+		if err != nil || !exists {
+			t.Fatalf("ExistsByEmail(existing) = %v, %v; want true, nil", exists, err)
+		}
+
+		exists, err = repos.Users.ExistsByEmail(ctx, "nobody@example.com")
+		if err != nil || exists {
+			t.Fatalf("ExistsByEmail(missing) = %v, %v; want false, nil", exists, err)
+		}
+	})
+
+	t.Run("UpdatePersistsChanges", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "before@example.com")
+
+		user.Email = "after@example.com"
+		user.Name = "After"
+		user.Password = "new-hash"
+		disabledAt := time.Now()
+		user.DisabledAt = &disabledAt
+		if err := repos.Users.Update(ctx, user); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		found, err := repos.Users.FindByEmail(ctx, "after@example.com")
+		if err != nil || found == nil {
+			t.Fatalf("FindByEmail after update = %v, %v", found, err)
+		}
+		if found.ID != user.ID || found.Name != "After" || found.Password != "new-hash" || found.DisabledAt == nil {
+			t.Fatalf("Update stored %+v", found)
+		}
+
+		old, err := repos.Users.FindByEmail(ctx, "before@example.com")
+		if err != nil || old != nil {
+			t.Fatalf("old email still resolves: %v, %v", old, err)
+		}
+	})
+
+	t.Run("DeleteCascadesToExpenses", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "delete@example.com")
+		other := createUser(t, repos, "keep@example.com")
+		expense := createExpense(t, repos, user.ID, 10, valueobjects.Groceries, day(2024, 1, 10))
+		kept := createExpense(t, repos, other.ID, 20, valueobjects.Health, day(2024, 1, 10))
+
+		if err := repos.Users.Delete(ctx, user.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+
+		if found, err := repos.Users.FindByID(ctx, user.ID); err != nil || found != nil {
+			t.Fatalf("deleted user still found: %v, %v", found, err)
+		}
+		if found, err := repos.Expenses.FindByID(ctx, expense.ID); err != nil || found != nil {
+			t.Fatalf("expense of deleted user still found: %v, %v", found, err)
+		}
+		if found, err := repos.Expenses.FindByID(ctx, kept.ID); err != nil || found == nil {
+			t.Fatalf("expense of another user was removed: %v, %v", found, err)
+		}
+	})
+}
+
+func RunExpenseRepository(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	t.Run("CreateAndFindByID", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "owner@example.com")
+		expense := createExpense(t, repos, user.ID, 12.5, valueobjects.Leisure, day(2024, 2, 3))
+
+		if expense.ID == "" || expense.CreatedAt.IsZero() || expense.UpdatedAt.IsZero() {
+			t.Fatalf("Create did not assign ID and timestamps: %+v", expense)
+		}
+
+		found, err := repos.Expenses.FindByID(ctx, expense.ID)
+		if err != nil || found == nil {
+			t.Fatalf("FindByID = %v, %v", found, err)
+		}
+		assertSameExpense(t, found, expense)
+	})
+
+	t.Run("NotFoundReturnsNilNil", func(t *testing.T) {
+		repos := newRepos(t)
+
+		found, err := repos.Expenses.FindByID(ctx, "missing")
+		if err != nil || found != nil {
+			t.Fatalf("FindByID = %v, %v; want nil, nil", found, err)
+		}
+
+		expenses, err := repos.Expenses.FindByUserID(ctx, "missing", repositories.ExpenseFilter{UserID: "missing"})
+		if err != nil || len(expenses) != 0 {
+			t.Fatalf("FindByUserID = %v, %v; want empty, nil", expenses, err)
+		}
+	})
+
+	t.Run("FindByUserIDFiltersAndOrders", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "filter@example.com")
+		other := createUser(t, repos, "other@example.com")
+
+		jan := createExpense(t, repos, user.ID, 1, valueobjects.Groceries, day(2024, 1, 15))
+		feb := createExpense(t, repos, user.ID, 2, valueobjects.Health, day(2024, 2, 15))
+		mar := createExpense(t, repos, user.ID, 3, valueobjects.Groceries, day(2024, 3, 15))
+		createExpense(t, repos, other.ID, 4, valueobjects.Groceries, day(2024, 2, 15))
+
+		all, err := repos.Expenses.FindByUserID(ctx, user.ID, repositories.ExpenseFilter{UserID: user.ID})
+		if err != nil {
+			t.Fatalf("FindByUserID: %v", err)
+		}
+		assertIDs(t, "newest first", all, mar.ID, feb.ID, jan.ID)
+
+		start, end := day(2024, 2, 15), day(2024, 3, 15)
+		ranged, err := repos.Expenses.FindByUserID(ctx, user.ID, repositories.ExpenseFilter{UserID: user.ID, StartDate: &start, EndDate: &end})
+		if err != nil {
+			t.Fatalf("FindByUserID with dates: %v", err)
+		}
+		assertIDs(t, "inclusive date range", ranged, mar.ID, feb.ID)
+
+		category := string(valueobjects.Groceries)
+		byCategory, err := repos.Expenses.FindByUserID(ctx, user.ID, repositories.ExpenseFilter{UserID: user.ID, Category: &category})
+		if err != nil {
+			t.Fatalf("FindByUserID with category: %v", err)
+		}
+		assertIDs(t, "category", byCategory, mar.ID, jan.ID)
+	})
+
+	t.Run("UpdatePersistsChanges", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "update@example.com")
+		expense := createExpense(t, repos, user.ID, 5, valueobjects.Others, day(2024, 4, 1))
+		createdAt := expense.UpdatedAt
+
+		expense.Amount = 7.25
+		expense.Category = valueobjects.Utilities
+		expense.Description = "changed"
+		expense.Date = day(2024, 4, 2)
+		if err := repos.Expenses.Update(ctx, expense); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if expense.UpdatedAt.Before(createdAt) {
+			t.Fatal("Update did not advance UpdatedAt")
+		}
+
+		found, err := repos.Expenses.FindByID(ctx, expense.ID)
+		if err != nil || found == nil {
+			t.Fatalf("FindByID after update = %v, %v", found, err)
+		}
+		assertSameExpense(t, found, expense)
+	})
+
+	t.Run("UpdateRejectsStaleVersion", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "version@example.com")
+		expense := createExpense(t, repos, user.ID, 5, valueobjects.Others, day(2024, 4, 1))
+		if expense.Version != 1 {
+			t.Fatalf("Create set version %d, want 1", expense.Version)
+		}
+
+		stale := *expense
+		expense.Amount = 6
+		if err := repos.Expenses.Update(ctx, expense); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if expense.Version != 2 {
+			t.Fatalf("Update set version %d, want 2", expense.Version)
+		}
+
+		stale.Amount = 7
+		if err := repos.Expenses.Update(ctx, &stale); !errors.Is(err, repositories.ErrStaleVersion) {
+			t.Fatalf("Update with a stale version = %v, want ErrStaleVersion", err)
+		}
+
+		if err := repos.Expenses.Delete(ctx, expense); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		trashed, err := repos.Expenses.FindTrashedByID(ctx, expense.ID)
+		if err != nil || trashed == nil || trashed.Version != 3 || trashed.Amount != 6 {
+			t.Fatalf("FindTrashedByID after delete = %+v, %v; want version 3", trashed, err)
+		}
+		if expense.Version != 3 || expense.DeletedAt == nil || !sameInstant(*trashed.DeletedAt, *expense.DeletedAt) || !sameInstant(trashed.UpdatedAt, expense.UpdatedAt) {
+			t.Fatalf("Delete set %+v, want the stored %+v", expense, trashed)
+		}
+		if !sameInstant(trashed.UpdatedAt, *trashed.DeletedAt) {
+			t.Fatalf("Delete stored updated_at %v and deleted_at %v, want them equal", trashed.UpdatedAt, *trashed.DeletedAt)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "remove@example.com")
+		expense := createExpense(t, repos, user.ID, 5, valueobjects.Others, day(2024, 4, 1))
+
+		if err := repos.Expenses.Delete(ctx, expense); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if found, err := repos.Expenses.FindByID(ctx, expense.ID); err != nil || found != nil {
+			t.Fatalf("deleted expense still found: %v, %v", found, err)
+		}
+		if err := repos.Expenses.Delete(ctx, expense); !errors.Is(err, repositories.ErrStaleVersion) {
+			t.Fatalf("Delete of a trashed expense = %v, want ErrStaleVersion", err)
+		}
+	})
+
+	t.Run("TrashRestoreAndPurge", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "trash@example.com")
+		trashed := createExpense(t, repos, user.ID, 5, valueobjects.Others, day(2024, 4, 1))
+		kept := createExpense(t, repos, user.ID, 6, valueobjects.Others, day(2024, 4, 2))
+
+		if err := repos.Expenses.Delete(ctx, trashed); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+
+		all, err := repos.Expenses.FindByUserID(ctx, user.ID, repositories.ExpenseFilter{UserID: user.ID})
+		if err != nil {
+			t.Fatalf("FindByUserID: %v", err)
+		}
+		assertIDs(t, "trashed expenses are excluded", all, kept.ID)
+
+		totals, err := repos.Expenses.GetTotalByCategory(ctx, user.ID, day(2024, 4, 1), day(2024, 4, 30))
+		if err != nil || totals["others"] != 6 {
+			t.Fatalf("GetTotalByCategory = %v, %v; want trashed expense excluded", totals, err)
+		}
+
+		trash, err := repos.Expenses.FindTrashed(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("FindTrashed: %v", err)
+		}
+		assertIDs(t, "trash", trash, trashed.ID)
+		if trash[0].DeletedAt == nil {
+			t.Fatal("trashed expense has no DeletedAt")
+		}
+		if found, err := repos.Expenses.FindTrashedByID(ctx, kept.ID); err != nil || found != nil {
+			t.Fatalf("FindTrashedByID of a live expense = %v, %v; want nil, nil", found, err)
+		}
+
+		if err := repos.Expenses.Restore(ctx, trashed); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		found, err := repos.Expenses.FindByID(ctx, trashed.ID)
+		if err != nil || found == nil || found.DeletedAt != nil {
+			t.Fatalf("FindByID after restore = %v, %v", found, err)
+		}
+		if found.Version != trashed.Version || !sameInstant(found.UpdatedAt, trashed.UpdatedAt) || trashed.DeletedAt != nil {
+			t.Fatalf("Restore set %+v, want the stored %+v", trashed, found)
+		}
+		if err := repos.Expenses.Restore(ctx, trashed); !errors.Is(err, repositories.ErrStaleVersion) {
+			t.Fatalf("Restore of a live expense = %v, want ErrStaleVersion", err)
+		}
+
+		if err := repos.Expenses.Delete(ctx, trashed); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		purged, err := repos.Expenses.PurgeDeletedBefore(ctx, time.Now().Add(-time.Hour))
+		if err != nil || purged != 0 {
+			t.Fatalf("PurgeDeletedBefore(past) = %d, %v; want 0", purged, err)
+		}
+		purged, err = repos.Expenses.PurgeDeletedBefore(ctx, time.Now().Add(time.Hour))
+		if err != nil || purged != 1 {
+			t.Fatalf("PurgeDeletedBefore(future) = %d, %v; want 1", purged, err)
+		}
+		if found, err := repos.Expenses.FindTrashedByID(ctx, trashed.ID); err != nil || found != nil {
+			t.Fatalf("purged expense still in trash: %v, %v", found, err)
+		}
+
+		if err := repos.Expenses.PermanentDelete(ctx, kept.ID); err != nil {
+			t.Fatalf("PermanentDelete: %v", err)
+		}
+		if found, err := repos.Expenses.FindByID(ctx, kept.ID); err != nil || found != nil {
+			t.Fatalf("permanently deleted expense still found: %v, %v", found, err)
+		}
+	})
+
+	t.Run("GetTotalByCategory", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "totals@example.com")
+		createExpense(t, repos, user.ID, 10, valueobjects.Groceries, day(2024, 5, 1))
+		createExpense(t, repos, user.ID, 5.5, valueobjects.Groceries, day(2024, 5, 31))
+		createExpense(t, repos, user.ID, 20, valueobjects.Health, day(2024, 5, 10))
+		createExpense(t, repos, user.ID, 99, valueobjects.Health, day(2024, 6, 1))
+
+		totals, err := repos.Expenses.GetTotalByCategory(ctx, user.ID, day(2024, 5, 1), day(2024, 5, 31))
+		if err != nil {
+			t.Fatalf("GetTotalByCategory: %v", err)
+		}
+		if len(totals) != 2 || totals["groceries"] != 15.5 || totals["health"] != 20 {
+			t.Fatalf("GetTotalByCategory = %v", totals)
+		}
+	})
+}
+
+func RunExpenseRevisionRepository(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	t.Run("NumbersRevisionsPerExpense", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "history@example.com")
+		first := createExpense(t, repos, user.ID, 5, valueobjects.Others, day(2024, 4, 1))
+		second := createExpense(t, repos, user.ID, 6, valueobjects.Health, day(2024, 4, 2))
+
+		created := createRevision(t, repos, first, entities.ExpenseCreated, nil)
+		if created.ID == "" || created.Revision != 1 || created.CreatedAt.IsZero() {
+			t.Fatalf("Create did not assign ID, revision and timestamp: %+v", created)
+		}
+		createRevision(t, repos, second, entities.ExpenseCreated, nil)
+
+		revertedTo := 1
+		updated := createRevision(t, repos, first, entities.ExpenseReverted, &revertedTo)
+		if updated.Revision != 2 {
+			t.Fatalf("second revision of an expense = %d, want 2", updated.Revision)
+		}
+
+		history, err := repos.Revisions.FindByExpenseID(ctx, first.ID)
+		if err != nil || len(history) != 2 || history[0].Revision != 1 || history[1].Revision != 2 {
+			t.Fatalf("FindByExpenseID = %+v, %v", history, err)
+		}
+
+		found, err := repos.Revisions.FindByRevision(ctx, first.ID, 2)
+		if err != nil || found == nil {
+			t.Fatalf("FindByRevision = %v, %v", found, err)
+		}
+		if found.Action != entities.ExpenseReverted || found.RevertedTo == nil || *found.RevertedTo != 1 ||
+			found.ActorID != user.ID || found.Values.Amount != first.Amount || !sameDay(found.Values.Date, first.Date) {
+			t.Fatalf("FindByRevision = %+v", found)
+		}
+		if len(found.Changes) != 1 || found.Changes[0].Field != "amount" {
+			t.Fatalf("changes = %+v", found.Changes)
+		}
+
+		if found, err := repos.Revisions.FindByRevision(ctx, first.ID, 3); err != nil || found != nil {
+			t.Fatalf("FindByRevision of a missing revision = %v, %v; want nil, nil", found, err)
+		}
+
+		all, err := repos.Revisions.FindByUserID(ctx, user.ID)
+		if err != nil || len(all) != 3 {
+			t.Fatalf("FindByUserID = %d revisions, %v; want 3", len(all), err)
+		}
+
+		batch, err := repos.Revisions.FindByExpenseIDs(ctx, user.ID, []string{second.ID, first.ID, first.ID})
+		if err != nil || len(batch) != 3 {
+			t.Fatalf("FindByExpenseIDs = %d revisions, %v; want 3", len(batch), err)
+		}
+		for i := 1; i < len(batch); i++ {
+			prev, cur := batch[i-1], batch[i]
+			if prev.ExpenseID > cur.ExpenseID || (prev.ExpenseID == cur.ExpenseID && prev.Revision >= cur.Revision) {
+				t.Fatalf("FindByExpenseIDs not ordered by expense and revision: %+v", batch)
+			}
+		}
+		if others, err := repos.Revisions.FindByExpenseIDs(ctx, "another-user", []string{first.ID}); err != nil || len(others) != 0 {
+			t.Fatalf("FindByExpenseIDs of another user = %+v, %v; want empty", others, err)
+		}
+		if empty, err := repos.Revisions.FindByExpenseIDs(ctx, user.ID, nil); err != nil || len(empty) != 0 {
+			t.Fatalf("FindByExpenseIDs without IDs = %+v, %v; want empty", empty, err)
+		}
+	})
+
+	t.Run("RemovedWithExpense", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "history-purge@example.com")
+		expense := createExpense(t, repos, user.ID, 5, valueobjects.Others, day(2024, 4, 1))
+		createRevision(t, repos, expense, entities.ExpenseCreated, nil)
+
+		trashed := createExpense(t, repos, user.ID, 6, valueobjects.Others, day(2024, 4, 2))
+		createRevision(t, repos, trashed, entities.ExpenseCreated, nil)
+		kept := createExpense(t, repos, user.ID, 7, valueobjects.Others, day(2024, 4, 3))
+		createRevision(t, repos, kept, entities.ExpenseCreated, nil)
+
+		if err := repos.Revisions.DeleteByExpenseID(ctx, expense.ID); err != nil {
+			t.Fatalf("DeleteByExpenseID: %v", err)
+		}
+		if err := repos.Expenses.PermanentDelete(ctx, expense.ID); err != nil {
+			t.Fatalf("PermanentDelete: %v", err)
+		}
+		history, err := repos.Revisions.FindByExpenseID(ctx, expense.ID)
+		if err != nil || len(history) != 0 {
+			t.Fatalf("history after PermanentDelete = %+v, %v; want empty", history, err)
+		}
+
+		if err := repos.Expenses.Delete(ctx, trashed); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if err := repos.Revisions.DeleteTrashedBefore(ctx, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("DeleteTrashedBefore: %v", err)
+		}
+		if history, err := repos.Revisions.FindByExpenseID(ctx, trashed.ID); err != nil || len(history) != 0 {
+			t.Fatalf("history of a trashed expense = %+v, %v; want empty", history, err)
+		}
+		if history, err := repos.Revisions.FindByExpenseID(ctx, kept.ID); err != nil || len(history) != 1 {
+			t.Fatalf("history of a kept expense = %+v, %v; want it kept", history, err)
+		}
+	})
+}
+
+func RunWebhookRepositories(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	t.Run("EndpointRoundTrip", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "hooks@example.com")
+		endpoint := createEndpoint(t, repos, user.ID)
+		if endpoint.ID == "" || endpoint.CreatedAt.IsZero() {
+			t.Fatalf("Create did not assign ID and timestamps: %+v", endpoint)
+		}
+
+		found, err := repos.WebhookEndpoints.FindByID(ctx, endpoint.ID)
+		if err != nil || found == nil {
+			t.Fatalf("FindByID = %v, %v", found, err)
+		}
+		if found.Secret != endpoint.Secret || !found.Active || len(found.Events) != 2 || !found.Subscribes(entities.EventExpenseDeleted) {
+			t.Fatalf("FindByID = %+v", found)
+		}
+
+		disabledAt := time.Now()
+		found.Active = false
+		found.ConsecutiveFailures = 3
+		found.DisabledAt = &disabledAt
+		found.Events = []string{entities.EventBudgetExceeded}
+		if err := repos.WebhookEndpoints.Update(ctx, found); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		updated, err := repos.WebhookEndpoints.FindByID(ctx, endpoint.ID)
+		if err != nil || updated.Active || updated.ConsecutiveFailures != 3 || updated.DisabledAt == nil ||
+			len(updated.Events) != 1 || updated.Events[0] != entities.EventBudgetExceeded {
+			t.Fatalf("after Update = %+v, %v", updated, err)
+		}
+
+		if found, err := repos.WebhookEndpoints.FindByID(ctx, "missing"); err != nil || found != nil {
+			t.Fatalf("FindByID of a missing endpoint = %v, %v; want nil, nil", found, err)
+		}
+		if all, err := repos.WebhookEndpoints.FindByUserID(ctx, user.ID); err != nil || len(all) != 1 {
+			t.Fatalf("FindByUserID = %d endpoints, %v; want 1", len(all), err)
+		}
+	})
+
+	t.Run("ClaimDue", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "claims@example.com")
+		endpoint := createEndpoint(t, repos, user.ID)
+
+		now := time.Now()
+		earlier := now.Add(-time.Minute)
+		second := createDelivery(t, repos, endpoint, &now)
+		first := createDelivery(t, repos, endpoint, &earlier)
+		lockedUntil := now.Add(time.Minute)
+
+		claimed, err := repos.WebhookDeliveries.ClaimDue(ctx, now, lockedUntil, 10)
+		if err != nil || len(claimed) != 2 || claimed[0].ID != first.ID || claimed[1].ID != second.ID {
+			t.Fatalf("ClaimDue = %+v, %v; want %s then %s", claimed, err, first.ID, second.ID)
+		}
+		if again, err := repos.WebhookDeliveries.ClaimDue(ctx, now, lockedUntil, 10); err != nil || len(again) != 0 {
+			t.Fatalf("ClaimDue while claimed = %+v, %v; want none", again, err)
+		}
+
+		// Update releases the claim of a delivery that is due again
+		if err := repos.WebhookDeliveries.Update(ctx, claimed[0]); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if again, err := repos.WebhookDeliveries.ClaimDue(ctx, now, lockedUntil, 10); err != nil || len(again) != 1 || again[0].ID != first.ID {
+			t.Fatalf("ClaimDue after Update = %+v, %v; want only %s", again, err, first.ID)
+		}
+
+		// An expired claim is taken over
+		expired, err := repos.WebhookDeliveries.ClaimDue(ctx, lockedUntil, lockedUntil.Add(time.Minute), 1)
+		if err != nil || len(expired) != 1 || expired[0].ID != first.ID {
+			t.Fatalf("ClaimDue after the claims expired = %+v, %v; want only %s", expired, err, first.ID)
+		}
+	})
+
+	t.Run("DeliveriesDueAndFailPending", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "deliveries@example.com")
+		endpoint := createEndpoint(t, repos, user.ID)
+
+		now := time.Now()
+		later := now.Add(time.Hour)
+		due := createDelivery(t, repos, endpoint, &now)
+		notDue := createDelivery(t, repos, endpoint, &later)
+
+		found, err := repos.WebhookDeliveries.ClaimDue(ctx, now.Add(time.Second), now.Add(time.Minute), 10)
+		if err != nil || len(found) != 1 || found[0].ID != due.ID {
+			t.Fatalf("ClaimDue = %+v, %v; want only %s", found, err, due.ID)
+		}
+
+		due.Status = entities.WebhookDeliverySucceeded
+		due.Attempts = 1
+		due.ResponseCode = 204
+		due.LastAttemptAt = &now
+		due.NextAttemptAt = nil
+		if err := repos.WebhookDeliveries.Update(ctx, due); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if found, err := repos.WebhookDeliveries.FindByID(ctx, due.ID); err != nil || found.Status != entities.WebhookDeliverySucceeded ||
+			found.ResponseCode != 204 || found.NextAttemptAt != nil || found.LastAttemptAt == nil {
+			t.Fatalf("after Update = %+v, %v", found, err)
+		}
+
+		if err := repos.WebhookDeliveries.FailPending(ctx, endpoint.ID, "disabled"); err != nil {
+			t.Fatalf("FailPending: %v", err)
+		}
+		if found, err := repos.WebhookDeliveries.FindByID(ctx, notDue.ID); err != nil || found.Status != entities.WebhookDeliveryFailed || found.Error != "disabled" {
+			t.Fatalf("pending delivery after FailPending = %+v, %v", found, err)
+		}
+		if found, err := repos.WebhookDeliveries.FindByID(ctx, due.ID); err != nil || found.Status != entities.WebhookDeliverySucceeded {
+			t.Fatalf("FailPending changed a finished delivery: %+v, %v", found, err)
+		}
+
+		log, err := repos.WebhookDeliveries.FindByEndpointID(ctx, endpoint.ID, 1)
+		if err != nil || len(log) != 1 || log[0].ID != notDue.ID {
+			t.Fatalf("FindByEndpointID with limit 1 = %+v, %v; want the newest", log, err)
+		}
+
+		if err := repos.WebhookDeliveries.DeleteByEndpointID(ctx, endpoint.ID); err != nil {
+			t.Fatalf("DeleteByEndpointID: %v", err)
+		}
+		if err := repos.WebhookEndpoints.Delete(ctx, endpoint.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if log, err := repos.WebhookDeliveries.FindByEndpointID(ctx, endpoint.ID, 0); err != nil || len(log) != 0 {
+			t.Fatalf("deliveries after Delete = %d, %v; want none", len(log), err)
+		}
+	})
+}
+
+func RunOutboxRepository(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	t.Run("AppendsInOrderAndProcesses", func(t *testing.T) {
+		repos := newRepos(t)
+
+		first := appendOutboxEvent(t, repos, "user-1")
+		second := appendOutboxEvent(t, repos, "user-2")
+		if first.ID == 0 || second.ID <= first.ID || first.CreatedAt.IsZero() {
+			t.Fatalf("Append did not assign increasing IDs: %d, %d", first.ID, second.ID)
+		}
+
+		pending, err := repos.Outbox.FindUnprocessed(ctx, 10)
+		if err != nil || len(pending) != 2 || pending[0].ID != first.ID || pending[1].ID != second.ID {
+			t.Fatalf("FindUnprocessed = %+v, %v", pending, err)
+		}
+		if pending[0].UserID != "user-1" || pending[0].EventType != "expense.created" || pending[0].Payload != `{"amount":1}` {
+			t.Fatalf("FindUnprocessed returned %+v", pending[0])
+		}
+
+		next := time.Now().Add(time.Minute)
+		first.Attempts = 1
+		first.LastError = "subscriber failed"
+		first.NextAttemptAt = &next
+		if err := repos.Outbox.Update(ctx, first); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		processedAt := time.Now().Add(-time.Hour)
+		second.Attempts = 1
+		second.ProcessedAt = &processedAt
+		if err := repos.Outbox.Update(ctx, second); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		pending, err = repos.Outbox.FindUnprocessed(ctx, 10)
+		if err != nil || len(pending) != 1 || pending[0].ID != first.ID {
+			t.Fatalf("FindUnprocessed after processing = %+v, %v", pending, err)
+		}
+		if pending[0].Attempts != 1 || pending[0].LastError != "subscriber failed" || pending[0].NextAttemptAt == nil {
+			t.Fatalf("Update was not persisted: %+v", pending[0])
+		}
+
+		if limited, err := repos.Outbox.FindUnprocessed(ctx, 1); err != nil || len(limited) != 1 {
+			t.Fatalf("FindUnprocessed with limit 1 = %d, %v", len(limited), err)
+		}
+
+		deleted, err := repos.Outbox.DeleteProcessedBefore(ctx, time.Now().Add(-time.Minute))
+		if err != nil || deleted != 1 {
+			t.Fatalf("DeleteProcessedBefore = %d, %v; want 1", deleted, err)
+		}
+	})
+
+	t.Run("FindByUserID", func(t *testing.T) {
+		repos := newRepos(t)
+
+		first := appendOutboxEvent(t, repos, "user-1")
+		appendOutboxEvent(t, repos, "user-2")
+		second := appendOutboxEvent(t, repos, "user-1")
+		processedAt := time.Now()
+		first.ProcessedAt = &processedAt
+		if err := repos.Outbox.Update(ctx, first); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		events, err := repos.Outbox.FindByUserID(ctx, "user-1")
+		if err != nil || len(events) != 2 || events[0].ID != first.ID || events[1].ID != second.ID {
+			t.Fatalf("FindByUserID = %+v, %v; want both events of the user in order", events, err)
+		}
+		if events[0].ProcessedAt == nil {
+			t.Fatalf("FindByUserID returned %+v, want it processed", events[0])
+		}
+	})
+
+	t.Run("FindDueSkipsWaitingUsers", func(t *testing.T) {
+		repos := newRepos(t)
+
+		waiting := appendOutboxEvent(t, repos, "user-1")
+		appendOutboxEvent(t, repos, "user-1")
+		other := appendOutboxEvent(t, repos, "user-2")
+		retried := appendOutboxEvent(t, repos, "user-3")
+
+		now := time.Now()
+		later := now.Add(time.Minute)
+		waiting.Attempts = 1
+		waiting.NextAttemptAt = &later
+		if err := repos.Outbox.Update(ctx, waiting); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		earlier := now.Add(-time.Minute)
+		retried.Attempts = 1
+		retried.NextAttemptAt = &earlier
+		if err := repos.Outbox.Update(ctx, retried); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		due, err := repos.Outbox.FindDue(ctx, now, 10)
+		if err != nil || len(due) != 2 || due[0].ID != other.ID || due[1].ID != retried.ID {
+			t.Fatalf("FindDue = %+v, %v; want the events of user-2 and user-3", due, err)
+		}
+		if limited, err := repos.Outbox.FindDue(ctx, now, 1); err != nil || len(limited) != 1 || limited[0].ID != other.ID {
+			t.Fatalf("FindDue with limit 1 = %+v, %v", limited, err)
+		}
+		if due, err := repos.Outbox.FindDue(ctx, later.Add(time.Second), 10); err != nil || len(due) != 4 {
+			t.Fatalf("FindDue after the retry time = %d events, %v; want 4", len(due), err)
+		}
+	})
+
+	t.Run("RolledBackWithTransaction", func(t *testing.T) {
+		repos := newRepos(t)
+
+		err := repos.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := repos.Outbox.Append(ctx, &entities.OutboxEvent{UserID: "user-1", EventType: "expense.created", Payload: "{}"}); err != nil {
+				return err
+			}
+			return errors.New("rollback")
+		})
+		if err == nil {
+			t.Fatal("WithinTransaction did not return the error")
+		}
+
+		pending, err := repos.Outbox.FindUnprocessed(ctx, 10)
+		if err != nil || len(pending) != 0 {
+			t.Fatalf("events after rollback = %+v, %v; want none", pending, err)
+		}
+	})
+}
+
+func RunLeaseRepository(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+	now := time.Now()
+	tests := []struct {
+		name   string
+		holder string
+		now    time.Time
+		want   bool
+	}{
+		{"free lease", "a", now, true},
+		{"held by another", "b", now.Add(30 * time.Second), false},
+		{"renewed by its holder", "a", now.Add(30 * time.Second), true},
+		{"still held after the first expiry", "b", now.Add(time.Minute), false},
+		{"taken over once expired", "b", now.Add(2 * time.Minute), true},
+		{"lost by the previous holder", "a", now.Add(2 * time.Minute), false},
+	}
+
+	repos := newRepos(t)
+	for _, tt := range tests {
+		got, err := repos.Leases.Acquire(ctx, "relay", tt.holder, tt.now, time.Minute)
+		if err != nil || got != tt.want {
+			t.Fatalf("%s: Acquire(%s) = %v, %v; want %v", tt.name, tt.holder, got, err, tt.want)
+		}
+	}
+
+	if ok, err := repos.Leases.Acquire(ctx, "other", "a", now, time.Minute); err != nil || !ok {
+		t.Fatalf("Acquire of another lease = %v, %v; want it taken", ok, err)
+	}
+
+	// Only the holder can release
+	if err := repos.Leases.Release(ctx, "relay", "a"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if ok, _ := repos.Leases.Acquire(ctx, "relay", "c", now.Add(2*time.Minute), time.Minute); ok {
+		t.Fatal("a release by a former holder freed the lease")
+	}
+	if err := repos.Leases.Release(ctx, "relay", "b"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if ok, err := repos.Leases.Acquire(ctx, "relay", "c", now.Add(2*time.Minute), time.Minute); err != nil || !ok {
+		t.Fatalf("Acquire after Release = %v, %v; want it taken", ok, err)
+	}
+}
+
+func RunTxManager(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	t.Run("CommitPersists", func(t *testing.T) {
+		repos := newRepos(t)
+		var user *entities.User
+
+		err := repos.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			user = &entities.User{Email: "commit@example.com", Password: "hash", Name: "Commit"}
+			if err := repos.Users.Create(ctx, user); err != nil {
+				return err
+			}
+			expense := &entities.Expense{UserID: user.ID, Amount: 1, Category: valueobjects.Others, Date: day(2024, 1, 1)}
+			return repos.Expenses.Create(ctx, expense)
+		})
+		if err != nil {
+			t.Fatalf("WithinTransaction: %v", err)
+		}
+
+		expenses, err := repos.Expenses.FindByUserID(ctx, user.ID, repositories.ExpenseFilter{UserID: user.ID})
+		if err != nil || len(expenses) != 1 {
+			t.Fatalf("committed expenses = %v, %v; want 1", expenses, err)
+		}
+	})
+
+	t.Run("ErrorRollsBack", func(t *testing.T) {
+		repos := newRepos(t)
+
+		err := repos.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			user := &entities.User{Email: "rollback@example.com", Password: "hash", Name: "Rollback"}
+			if err := repos.Users.Create(ctx, user); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("WithinTransaction = %v, want the error returned by fn", err)
+		}
+
+		if found, err := repos.Users.FindByEmail(ctx, "rollback@example.com"); err != nil || found != nil {
+			t.Fatalf("rolled back user still found: %v, %v", found, err)
+		}
+	})
+
+	t.Run("NestedRollsBackToSavepoint", func(t *testing.T) {
+		repos := newRepos(t)
+
+		err := repos.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			outer := &entities.User{Email: "outer@example.com", Password: "hash", Name: "Outer"}
+			if err := repos.Users.Create(ctx, outer); err != nil {
+				return err
+			}
+
+			err := repos.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+				inner := &entities.User{Email: "inner@example.com", Password: "hash", Name: "Inner"}
+				if err := repos.Users.Create(ctx, inner); err != nil {
+					return err
+				}
+				return errAbort
+			})
+			if !errors.Is(err, errAbort) {
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("WithinTransaction: %v", err)
+		}
+
+		if found, err := repos.Users.FindByEmail(ctx, "outer@example.com"); err != nil || found == nil {
+			t.Fatalf("outer write lost: %v, %v", found, err)
+		}
+		if found, err := repos.Users.FindByEmail(ctx, "inner@example.com"); err != nil || found != nil {
+			t.Fatalf("inner write kept after savepoint rollback: %v, %v", found, err)
+		}
+	})
+}
+
+func createUser(t *testing.T, repos Repositories, email string) *entities.User {
+	t.Helper()
+	user := &entities.User{Email: email, Password: "hash", Name: "Test User"}
+	if err := repos.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("creating user %s: %v", email, err)
+	}
+	return user
+}
+
+func createExpense(t *testing.T, repos Repositories, userID string, amount float64, category valueobjects.Category, date time.Time) *entities.Expense {
+	t.Helper()
+	expense := &entities.Expense{
+		UserID:      userID,
+		Amount:      amount,
+		Category:    category,
+		Description: "contract test",
+		Date:        date,
+	}
+	if err := repos.Expenses.Create(context.Background(), expense); err != nil {
+		t.Fatalf("creating expense: %v", err)
+	}
+	return expense
+}
+
+func createRevision(t *testing.T, repos Repositories, expense *entities.Expense, action entities.ExpenseRevisionAction, revertedTo *int) *entities.ExpenseRevision {
+	t.Helper()
+	revision := &entities.ExpenseRevision{
+		ExpenseID:  expense.ID,
+		UserID:     expense.UserID,
+		ActorID:    expense.UserID,
+		Action:     action,
+		Changes:    []entities.FieldChange{{Field: "amount", Old: 1.0, New: expense.Amount}},
+		Values:     entities.ValuesOf(expense),
+		RevertedTo: revertedTo,
+	}
+	if err := repos.Revisions.Create(context.Background(), revision); err != nil {
+		t.Fatalf("Create revision: %v", err)
+	}
+	return revision
+}
+
+func createEndpoint(t *testing.T, repos Repositories, userID string) *entities.WebhookEndpoint {
+	t.Helper()
+	endpoint := &entities.WebhookEndpoint{
+		UserID: userID,
+		URL:    "http://localhost:9000/hook",
+		Secret: "whsec_test",
+		Events: []string{entities.EventExpenseCreated, entities.EventExpenseDeleted},
+		Active: true,
+	}
+	if err := repos.WebhookEndpoints.Create(context.Background(), endpoint); err != nil {
+		t.Fatalf("create endpoint: %v", err)
+	}
+	return endpoint
+}
+
+func createDelivery(t *testing.T, repos Repositories, endpoint *entities.WebhookEndpoint, nextAttemptAt *time.Time) *entities.WebhookDelivery {
+	t.Helper()
+	delivery := &entities.WebhookDelivery{
+		EndpointID:    endpoint.ID,
+		UserID:        endpoint.UserID,
+		Event:         entities.EventExpenseCreated,
+		Payload:       `{"event":"expense.created"}`,
+		Status:        entities.WebhookDeliveryPending,
+		NextAttemptAt: nextAttemptAt,
+	}
+	if err := repos.WebhookDeliveries.Create(context.Background(), delivery); err != nil {
+		t.Fatalf("create delivery: %v", err)
+	}
+	return delivery
+}
+
+func appendOutboxEvent(t *testing.T, repos Repositories, userID string) *entities.OutboxEvent {
+	t.Helper()
+	event := &entities.OutboxEvent{UserID: userID, EventType: "expense.created", Payload: `{"amount":1}`}
+	if err := repos.Outbox.Append(context.Background(), event); err != nil {
+		t.Fatalf("append outbox event: %v", err)
+	}
+	return event
+}
+
+func assertSameExpense(t *testing.T, got, want *entities.Expense) {
+	t.Helper()
+	if got.ID != want.ID || got.UserID != want.UserID || got.Amount != want.Amount ||
+		got.Category != want.Category || got.Description != want.Description ||
+		!sameDay(got.Date, want.Date) {
+		t.Fatalf("expense = %+v, want %+v", got, want)
+	}
+}
+
+func assertIDs(t *testing.T, name string, expenses []*entities.Expense, ids ...string) {
+	t.Helper()
+	if len(expenses) != len(ids) {
+		t.Fatalf("%s: got %d expenses, want %d", name, len(expenses), len(ids))
+	}
+	for i, id := range ids {
+		if expenses[i].ID != id {
+			t.Fatalf("%s: expense %d is %s, want %s", name, i, expenses[i].ID, id)
+		}
+	}
+}
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.UTC().Format("2006-01-02") == b.UTC().Format("2006-01-02")
+}
+
+// sameInstant allows for PostgreSQL keeping microseconds only.
+func sameInstant(a, b time.Time) bool {
+	return a.Sub(b).Abs() < time.Microsecond
+}
@@ -0,0 +1,92 @@
+package repositorytest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/infrastructure/database"
+	"expense-tracker/migrations"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SQLite opens a migrated database in a temporary directory that is removed
+// when the test ends.
+func SQLite(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	db, err := database.Connect(config.DatabaseConfig{
+		Type:   "sqlite",
+		DBName: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("opening sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrate(t, db)
+	return db
+}
+
+// Postgres connects to the database described by the TEST_DB_* variables,
+// e.g. the one from docker-compose.yml, and skips the test when
+// TEST_DB_HOST is not set. Tables are emptied before and after the test.
+func Postgres(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		t.Skip("TEST_DB_HOST not set; skipping Postgres tests")
+	}
+
+	db, err := database.Connect(config.DatabaseConfig{
+		Type:     "postgres",
+		Host:     host,
+		Port:     envOr("TEST_DB_PORT", "5432"),
+		User:     envOr("TEST_DB_USER", "expense_user"),
+		Password: envOr("TEST_DB_PASSWORD", "expense_password"),
+		DBName:   envOr("TEST_DB_NAME", "expense_tracker"),
+		SSLMode:  envOr("TEST_DB_SSLMODE", "disable"),
+	})
+	if err != nil {
+		t.Fatalf("connecting to postgres: %v", err)
+	}
+
+	migrate(t, db)
+	truncate(t, db)
+	t.Cleanup(func() {
+		truncate(t, db)
+		db.Close()
+	})
+	return db
+}
+
+func migrate(t *testing.T, db *sqlx.DB) {
+	t.Helper()
+
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("running migrations: %v", err)
+	}
+}
+
+func truncate(t *testing.T, db *sqlx.DB) {
+	t.Helper()
+
+	if _, err := db.Exec(`TRUNCATE users, expenses, expense_revisions, login_attempts, email_verifications, data_exports, webhook_endpoints, webhook_deliveries, outbox_events, leases CASCADE`); err != nil {
+		t.Fatalf("truncating tables: %v", err)
+	}
+}
+
+func envOr(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
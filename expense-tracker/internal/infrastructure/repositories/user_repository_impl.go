@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/infrastructure/database"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,6 +19,10 @@ func NewUserRepository(db *sqlx.DB) *UserRepositoryImpl {
 	return &UserRepositoryImpl{db: db}
 }
 
+func (r *UserRepositoryImpl) conn(ctx context.Context) sqlx.ExtContext {
+	return database.Conn(ctx, r.db)
+}
+
 func (r *UserRepositoryImpl) Create(ctx context.Context, user *entities.User) error {
 	user.ID = uuid.New().String()
 	user.CreatedAt = time.Now()
@@ -28,7 +33,7 @@ func (r *UserRepositoryImpl) Create(ctx context.Context, user *entities.User) er
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := r.conn(ctx).ExecContext(ctx, query,
 		user.ID, user.Email, user.Password, user.Name,
 		user.CreatedAt, user.UpdatedAt)
 
@@ -37,12 +42,12 @@ func (r *UserRepositoryImpl) Create(ctx context.Context, user *entities.User) er
 
 func (r *UserRepositoryImpl) FindByEmail(ctx context.Context, email string) (*entities.User, error) {
 	query := `
-		SELECT id, email, password, name, created_at, updated_at
+		SELECT id, email, password, name, created_at, updated_at, disabled_at
 		FROM users WHERE email = $1
 	`
 
 	var user entities.User
-	err := r.db.GetContext(ctx, &user, query, email)
+	err := sqlx.GetContext(ctx, r.conn(ctx), &user, query, email)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -51,12 +56,12 @@ func (r *UserRepositoryImpl) FindByEmail(ctx context.Context, email string) (*en
 
 func (r *UserRepositoryImpl) FindByID(ctx context.Context, id string) (*entities.User, error) {
 	query := `
-		SELECT id, email, password, name, created_at, updated_at
+		SELECT id, email, password, name, created_at, updated_at, disabled_at
 		FROM users WHERE id = $1
 	`
 
 	var user entities.User
-	err := r.db.GetContext(ctx, &user, query, id)
+	err := sqlx.GetContext(ctx, r.conn(ctx), &user, query, id)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -66,6 +71,44 @@ func (r *UserRepositoryImpl) FindByID(ctx context.Context, id string) (*entities
 func (r *UserRepositoryImpl) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
 	var exists bool
-	err := r.db.GetContext(ctx, &exists, query, email)
+	err := sqlx.GetContext(ctx, r.conn(ctx), &exists, query, email)
 	return exists, err
-}
\ No newline at end of file
+}
+
+func (r *UserRepositoryImpl) Update(ctx context.Context, user *entities.User) error {
+	user.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE users
+		SET email = $1, password = $2, name = $3, updated_at = $4, disabled_at = $5
+		WHERE id = $6
+	`
+
+	_, err := r.conn(ctx).ExecContext(ctx, query,
+		user.Email, user.Password, user.Name, user.UpdatedAt, user.DisabledAt, user.ID)
+
+	return err
+}
+
+func (r *UserRepositoryImpl) Delete(ctx context.Context, id string) error {
+	// Most tables also cascade from users, but outbox events and login
+	// attempts have no foreign key, so every table is cleared explicitly.
+	queries := []string{
+		`DELETE FROM expense_revisions WHERE user_id = $1`,
+		`DELETE FROM expenses WHERE user_id = $1`,
+		`DELETE FROM email_verifications WHERE user_id = $1`,
+		`DELETE FROM data_exports WHERE user_id = $1`,
+		`DELETE FROM webhook_deliveries WHERE user_id = $1`,
+		`DELETE FROM webhook_endpoints WHERE user_id = $1`,
+		`DELETE FROM outbox_events WHERE user_id = $1`,
+		`DELETE FROM login_attempts WHERE email = (SELECT email FROM users WHERE id = $1)`,
+		`DELETE FROM users WHERE id = $1`,
+	}
+
+	for _, query := range queries {
+		if _, err := r.conn(ctx).ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/infrastructure/database"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type WebhookEndpointRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewWebhookEndpointRepository(db *sqlx.DB) *WebhookEndpointRepositoryImpl {
+	return &WebhookEndpointRepositoryImpl{db: db}
+}
+
+func (r *WebhookEndpointRepositoryImpl) conn(ctx context.Context) sqlx.ExtContext {
+	return database.Conn(ctx, r.db)
+}
+
+// endpointRow stores the subscribed events as a comma-separated list.
+type endpointRow struct {
+	entities.WebhookEndpoint
+	EventList string `db:"events"`
+}
+
+func (row *endpointRow) toEntity() *entities.WebhookEndpoint {
+	endpoint := row.WebhookEndpoint
+	endpoint.Events = []string{}
+	if row.EventList != "" {
+		endpoint.Events = strings.Split(row.EventList, ",")
+	}
+	return &endpoint
+}
+
+const endpointColumns = `id, user_id, url, secret, events, active, consecutive_failures, disabled_at, created_at, updated_at`
+
+func (r *WebhookEndpointRepositoryImpl) Create(ctx context.Context, endpoint *entities.WebhookEndpoint) error {
+	endpoint.ID = uuid.New().String()
+	endpoint.CreatedAt = time.Now()
+	endpoint.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO webhook_endpoints (` + endpointColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.conn(ctx).ExecContext(ctx, query,
+		endpoint.ID, endpoint.UserID, endpoint.URL, endpoint.Secret, strings.Join(endpoint.Events, ","),
+		endpoint.Active, endpoint.ConsecutiveFailures, endpoint.DisabledAt, endpoint.CreatedAt, endpoint.UpdatedAt)
+
+	return err
+}
+
+func (r *WebhookEndpointRepositoryImpl) Update(ctx context.Context, endpoint *entities.WebhookEndpoint) error {
+	endpoint.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE webhook_endpoints
+		SET url = $1, secret = $2, events = $3, active = $4, consecutive_failures = $5, disabled_at = $6, updated_at = $7
+		WHERE id = $8
+	`
+
+	_, err := r.conn(ctx).ExecContext(ctx, query,
+		endpoint.URL, endpoint.Secret, strings.Join(endpoint.Events, ","), endpoint.Active,
+		endpoint.ConsecutiveFailures, endpoint.DisabledAt, endpoint.UpdatedAt, endpoint.ID)
+
+	return err
+}
+
+func (r *WebhookEndpointRepositoryImpl) FindByID(ctx context.Context, id string) (*entities.WebhookEndpoint, error) {
+	query := `SELECT ` + endpointColumns + ` FROM webhook_endpoints WHERE id = $1`
+
+	var row endpointRow
+	err := sqlx.GetContext(ctx, r.conn(ctx), &row, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return row.toEntity(), nil
+}
+
+func (r *WebhookEndpointRepositoryImpl) FindByUserID(ctx context.Context, userID string) ([]*entities.WebhookEndpoint, error) {
+	query := `SELECT ` + endpointColumns + ` FROM webhook_endpoints WHERE user_id = $1 ORDER BY created_at`
+
+	var rows []endpointRow
+	if err := sqlx.SelectContext(ctx, r.conn(ctx), &rows, query, userID); err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]*entities.WebhookEndpoint, len(rows))
+	for i := range rows {
+		endpoints[i] = rows[i].toEntity()
+	}
+	return endpoints, nil
+}
+
+func (r *WebhookEndpointRepositoryImpl) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM webhook_endpoints WHERE id = $1`
+	_, err := r.conn(ctx).ExecContext(ctx, query, id)
+	return err
+}
+
+type WebhookDeliveryRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewWebhookDeliveryRepository(db *sqlx.DB) *WebhookDeliveryRepositoryImpl {
+	return &WebhookDeliveryRepositoryImpl{db: db}
+}
+
+func (r *WebhookDeliveryRepositoryImpl) conn(ctx context.Context) sqlx.ExtContext {
+	return database.Conn(ctx, r.db)
+}
+
+const deliveryColumns = `id, endpoint_id, user_id, event, payload, status, attempts, next_attempt_at,
+	response_code, response_body, error, created_at, last_attempt_at, locked_until`
+
+func (r *WebhookDeliveryRepositoryImpl) Create(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	delivery.ID = uuid.New().String()
+	delivery.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO webhook_deliveries (` + deliveryColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+
+	_, err := r.conn(ctx).ExecContext(ctx, query,
+		delivery.ID, delivery.EndpointID, delivery.UserID, delivery.Event, delivery.Payload,
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.ResponseCode,
+		delivery.ResponseBody, delivery.Error, delivery.CreatedAt, delivery.LastAttemptAt, delivery.LockedUntil)
+
+	return err
+}
+
+func (r *WebhookDeliveryRepositoryImpl) Update(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, response_code = $4,
+			response_body = $5, error = $6, last_attempt_at = $7, locked_until = NULL
+		WHERE id = $8
+	`
+
+	_, err := r.conn(ctx).ExecContext(ctx, query,
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.ResponseCode,
+		delivery.ResponseBody, delivery.Error, delivery.LastAttemptAt, delivery.ID)
+	if err != nil {
+		return err
+	}
+
+	delivery.LockedUntil = nil
+	return nil
+}
+
+func (r *WebhookDeliveryRepositoryImpl) FindByID(ctx context.Context, id string) (*entities.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+
+	var delivery entities.WebhookDelivery
+	err := sqlx.GetContext(ctx, r.conn(ctx), &delivery, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &delivery, err
+}
+
+func (r *WebhookDeliveryRepositoryImpl) FindByEndpointID(ctx context.Context, endpointID string, limit int) ([]*entities.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE endpoint_id = $1 ORDER BY created_at DESC`
+	args := []interface{}{endpointID}
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
+
+	deliveries := []*entities.WebhookDelivery{}
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &deliveries, query, args...)
+	return deliveries, err
+}
+
+// ClaimDue repeats the conditions outside the subquery: PostgreSQL checks
+// them again on rows another transaction claimed meanwhile, so each row goes
+// to one caller only.
+func (r *WebhookDeliveryRepositoryImpl) ClaimDue(ctx context.Context, now, lockedUntil time.Time, limit int) ([]*entities.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries SET locked_until = $1
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = $2 AND next_attempt_at <= $3 AND (locked_until IS NULL OR locked_until <= $3)
+			ORDER BY next_attempt_at
+			LIMIT $4
+		) AND status = $2 AND (locked_until IS NULL OR locked_until <= $3)
+		RETURNING ` + deliveryColumns
+
+	deliveries := []*entities.WebhookDelivery{}
+	err := sqlx.SelectContext(ctx, r.conn(ctx), &deliveries, query, lockedUntil, entities.WebhookDeliveryPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING keeps no order
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].NextAttemptAt.Before(*deliveries[j].NextAttemptAt)
+	})
+	return deliveries, nil
+}
+
+func (r *WebhookDeliveryRepositoryImpl) FailPending(ctx context.Context, endpointID, reason string) error {
+	query := `
+		UPDATE webhook_deliveries SET status = $1, next_attempt_at = NULL, error = $2
+		WHERE endpoint_id = $3 AND status = $4
+	`
+	_, err := r.conn(ctx).ExecContext(ctx, query, entities.WebhookDeliveryFailed, reason, endpointID, entities.WebhookDeliveryPending)
+	return err
+}
+
+func (r *WebhookDeliveryRepositoryImpl) DeleteByEndpointID(ctx context.Context, endpointID string) error {
+	query := `DELETE FROM webhook_deliveries WHERE endpoint_id = $1`
+	_, err := r.conn(ctx).ExecContext(ctx, query, endpointID)
+	return err
+}
@@ -0,0 +1,64 @@
+// Package tracing configures OpenTelemetry tracing for the server.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"expense-tracker/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// Setup installs the global tracer provider and the W3C trace-context and
+// baggage propagators. With the "none" exporter no spans are recorded. The
+// returned function flushes pending spans.
+func Setup(ctx context.Context, cfg config.TracingConfig) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var option sdktrace.TracerProviderOption
+	switch cfg.Exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		// Spans are written as they end, so they show up next to the logs
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, err
+		}
+		option = sdktrace.WithSyncer(exporter)
+	case "otlp":
+		var options []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+		}
+		exporter, err := otlptracehttp.New(ctx, options...)
+		if err != nil {
+			return nil, err
+		}
+		option = sdktrace.WithBatcher(exporter)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, want none, stdout or otlp", cfg.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		option,
+		sdktrace.WithResource(res),
+		// A sampled parent keeps its decision, so traces stay complete
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(float64(cfg.SamplePercent)/100))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,124 @@
+// Package webhook delivers webhook payloads over HTTP and signs them.
+//
+// Each request carries the headers
+//
+//	X-Webhook-Id:        delivery ID (a redelivery gets a new one)
+//	X-Webhook-Event:     event type, e.g. expense.created
+//	X-Webhook-Signature: t=<unix timestamp>,v1=<hex HMAC-SHA256>
+//
+// where the HMAC is computed with the endpoint secret over
+// "<timestamp>.<body>". Receivers should recompute it, compare in constant
+// time and reject old timestamps to prevent replays.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/pkg/netguard"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+type HTTPSender struct {
+	client *http.Client
+}
+
+// NewHTTPSender creates a sender whose requests time out after timeout.
+// Redirects are not followed. Unless allowPrivate is set, connections to
+// loopback, private and link-local addresses are refused, whatever the URL's
+// host resolves to at the time.
+func NewHTTPSender(timeout time.Duration, allowPrivate bool) *HTTPSender {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: netguard.Control}
+		transport.DialContext = dialer.DialContext
+		// A proxy would connect on our behalf, out of reach of the check
+		transport.Proxy = nil
+	}
+
+	return &HTTPSender{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (s *HTTPSender) Send(ctx context.Context, endpoint *entities.WebhookEndpoint, delivery *entities.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "expense-tracker-webhooks/1.0")
+	req.Header.Set("X-Webhook-Id", delivery.ID)
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Signature", SignatureHeader(endpoint.Secret, time.Now(), body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return resp.StatusCode, string(respBody), nil
+}
+
+// SignatureHeader builds the X-Webhook-Signature value for body.
+func SignatureHeader(secret string, at time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + sign(secret, timestamp, body)
+}
+
+// VerifySignature checks an X-Webhook-Signature header against body and
+// rejects signatures older than tolerance.
+func VerifySignature(secret, header string, body []byte, tolerance time.Duration) error {
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || signature == "" {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+	}
+	if !hmac.Equal([]byte(signature), []byte(sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
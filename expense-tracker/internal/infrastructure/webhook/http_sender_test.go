@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/domain/entities"
+	"expense-tracker/internal/pkg/netguard"
+)
+
+func TestVerifySignature(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"event":"expense.created"}`)
+	now := time.Now()
+	valid := SignatureHeader(secret, now, body)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		body    []byte
+		wantErr bool
+	}{
+		{"valid", secret, valid, body, false},
+		{"valid with spaces", secret, strings.ReplaceAll(valid, ",", ", "), body, false},
+		{"other secret", "whsec_other", valid, body, true},
+		{"modified body", secret, valid, []byte(`{"event":"expense.deleted"}`), true},
+		{"too old", secret, SignatureHeader(secret, now.Add(-10*time.Minute), body), body, true},
+		{"too far ahead", secret, SignatureHeader(secret, now.Add(10*time.Minute), body), body, true},
+		{"timestamp changed", secret, strings.Replace(valid, "t="+timestamp, "t="+strconv.FormatInt(now.Unix()-1, 10), 1), body, true},
+		{"no signature", secret, "t=" + timestamp, body, true},
+		{"no timestamp", secret, valid[strings.Index(valid, "v1="):], body, true},
+		{"empty", secret, "", body, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(tt.secret, tt.header, tt.body, 5*time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifySignature = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("VerifySignature = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func testDelivery() (*entities.WebhookEndpoint, *entities.WebhookDelivery) {
+	return &entities.WebhookEndpoint{Secret: "whsec_test"},
+		&entities.WebhookDelivery{ID: "delivery-1", Event: "expense.created", Payload: `{"event":"expense.created"}`}
+}
+
+func TestHTTPSenderSignsDeliveries(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, strings.Repeat("x", 5000))
+	}))
+	defer server.Close()
+
+	endpoint, delivery := testDelivery()
+	endpoint.URL = server.URL
+	status, body, err := NewHTTPSender(time.Second, true).Send(context.Background(), endpoint, delivery)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if status != http.StatusAccepted || len(body) != 4096 {
+		t.Fatalf("Send = %d with %d body bytes, want 202 with the first 4096", status, len(body))
+	}
+
+	if got := received.Header.Get("X-Webhook-Id"); got != delivery.ID {
+		t.Errorf("X-Webhook-Id = %q, want %q", got, delivery.ID)
+	}
+	if got := received.Header.Get("X-Webhook-Event"); got != delivery.Event {
+		t.Errorf("X-Webhook-Event = %q, want %q", got, delivery.Event)
+	}
+	if err := VerifySignature(endpoint.Secret, received.Header.Get("X-Webhook-Signature"), receivedBody, time.Minute); err != nil {
+		t.Errorf("signature of the delivered request: %v", err)
+	}
+}
+
+func TestHTTPSenderDoesNotFollowRedirects(t *testing.T) {
+	target := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/target" {
+			target = true
+			return
+		}
+		http.Redirect(w, r, "/target", http.StatusFound)
+	}))
+	defer server.Close()
+
+	endpoint, delivery := testDelivery()
+	endpoint.URL = server.URL
+	status, _, err := NewHTTPSender(time.Second, true).Send(context.Background(), endpoint, delivery)
+	if err != nil || status != http.StatusFound || target {
+		t.Fatalf("Send = %d, %v (redirect followed: %v); want 302 without following", status, err, target)
+	}
+}
+
+// The receiver listens on loopback, as an internal service would.
+func TestHTTPSenderRefusesPrivateAddresses(t *testing.T) {
+	reached := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer server.Close()
+
+	endpoint, delivery := testDelivery()
+	for _, url := range []string{server.URL, strings.Replace(server.URL, "127.0.0.1", "localhost", 1)} {
+		endpoint.URL = url
+		_, _, err := NewHTTPSender(time.Second, false).Send(context.Background(), endpoint, delivery)
+		if !errors.Is(err, netguard.ErrForbiddenAddress) {
+			t.Errorf("Send to %s = %v, want ErrForbiddenAddress", url, err)
+		}
+	}
+	if reached {
+		t.Fatal("the request reached the loopback receiver")
+	}
+}
@@ -1,47 +1,125 @@
+// Package logger provides structured, leveled logging on top of log/slog.
+// Setup installs the default logger; request handlers log through the
+// logger carried by their context, which adds the request's attributes.
 package logger
 
 import (
-	"log"
-	"os"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
 )
 
-type Logger struct {
-	infoLogger  *log.Logger
-	errorLogger *log.Logger
-	warnLogger  *log.Logger
+// level is shared by every handler Setup creates, so changing it takes
+// effect at once.
+var level = new(slog.LevelVar)
+
+// Setup makes a logger writing to w the default for slog and for the
+// standard log package, whose messages are logged at info level. format is
+// "text" or "json"; lvl is debug, info, warn or error.
+func Setup(w io.Writer, format, lvl string) error {
+	if err := SetLevel(lvl); err != nil {
+		return err
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch format {
+	case "", "text":
+		handler = slog.NewTextHandler(w, options)
+	case "json":
+		handler = slog.NewJSONHandler(w, options)
+	default:
+		return fmt.Errorf("unknown log format %q, want text or json", format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
 }
 
-func NewLogger() *Logger {
-	return &Logger{
-		infoLogger:  log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile),
-		errorLogger: log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile),
-		warnLogger:  log.New(os.Stdout, "WARN: ", log.Ldate|log.Ltime|log.Lshortfile),
+// SetLevel changes the minimum level of the loggers made by Setup.
+func SetLevel(lvl string) error {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(lvl)); err != nil {
+		return fmt.Errorf("unknown log level %q, want debug, info, warn or error", lvl)
 	}
+	level.Set(parsed)
+	return nil
 }
 
-func (l *Logger) Info(format string, v ...interface{}) {
-	l.infoLogger.Printf(format, v...)
+// Level returns the current minimum level in lower case.
+func Level() string {
+	return strings.ToLower(level.Level().String())
 }
 
-func (l *Logger) Error(format string, v ...interface{}) {
-	l.errorLogger.Printf(format, v...)
+type contextKey struct{}
+
+// WithLogger returns a copy of ctx carrying l.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
 }
 
-func (l *Logger) Warn(format string, v ...interface{}) {
-	l.warnLogger.Printf(format, v...)
+// FromContext returns the logger carried by ctx, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
 }
 
-// Global logger instance
-var defaultLogger = NewLogger()
+// With returns a copy of ctx whose logger adds args to every record.
+func With(ctx context.Context, args ...any) context.Context {
+	return WithLogger(ctx, FromContext(ctx).With(args...))
+}
 
-func Info(format string, v ...interface{}) {
-	defaultLogger.Info(format, v...)
+func Debug(ctx context.Context, msg string, args ...any) {
+	FromContext(ctx).DebugContext(ctx, msg, args...)
 }
 
-func Error(format string, v ...interface{}) {
-	defaultLogger.Error(format, v...)
+func Info(ctx context.Context, msg string, args ...any) {
+	FromContext(ctx).InfoContext(ctx, msg, args...)
 }
 
-func Warn(format string, v ...interface{}) {
-	defaultLogger.Warn(format, v...)
-}
\ No newline at end of file
+func Warn(ctx context.Context, msg string, args ...any) {
+	FromContext(ctx).WarnContext(ctx, msg, args...)
+}
+
+func Error(ctx context.Context, msg string, args ...any) {
+	FromContext(ctx).ErrorContext(ctx, msg, args...)
+}
+
+// LevelHandler reports the level on GET and changes it on PUT with a body
+// like {"level":"debug"}. Callers must send token as a bearer token.
+func LevelHandler(token string) http.Handler {
+	type levelBody struct {
+		Level string `json:"level"`
+	}
+
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			var body levelBody
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			if err := SetLevel(body.Level); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			Info(r.Context(), "log level changed", "level", Level())
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(levelBody{Level: Level()})
+	})
+}
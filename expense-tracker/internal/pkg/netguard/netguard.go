@@ -0,0 +1,87 @@
+// Package netguard keeps requests the server makes on behalf of users, such
+// as webhook deliveries, away from its own network: loopback, private,
+// link-local (including cloud metadata at 169.254.169.254) and other
+// non-public addresses are refused.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+var ErrForbiddenAddress = errors.New("address is not publicly routable")
+
+// Ranges that are neither private nor loopback by the standard library's
+// definition, but are not reachable on the public internet either
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+}
+
+// NAT64 addresses embed an IPv4 address in their last four bytes
+var nat64 = netip.MustParsePrefix("64:ff9b::/96")
+
+// Public reports whether ip is a publicly routable unicast address.
+func Public(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if nat64.Contains(ip) {
+		b := ip.As16()
+		return Public(netip.AddrFrom4([4]byte(b[12:])))
+	}
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reserved {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost resolves host, a name or an IP literal, and fails with
+// ErrForbiddenAddress when any of its addresses is not public. A name that
+// does not resolve passes; Control checks again when connecting.
+func CheckHost(ctx context.Context, host string) error {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return check(ip)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, ip := range addrs {
+		if err := check(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Control is a net.Dialer Control function refusing connections to addresses
+// that are not public. It runs after DNS resolution, for every address
+// tried, so a name rebound to an internal address after CheckHost is still
+// refused.
+func Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	return check(addrPort.Addr())
+}
+
+func check(ip netip.Addr) error {
+	if !Public(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+	}
+	return nil
+}
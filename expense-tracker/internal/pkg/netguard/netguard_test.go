@@ -0,0 +1,84 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false}, // cloud metadata
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"198.18.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:93.184.215.14", true},
+		{"64:ff9b::7f00:1", false}, // NAT64 of 127.0.0.1
+		{"64:ff9b::5db8:d70e", true},
+		{"64:ff9b:1::1", false},
+	}
+	for _, tt := range tests {
+		if got := Public(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("Public(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestControl(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{"93.184.215.14:443", false},
+		{"[2606:2800:21f:cb07:6820:80da:af6b:8b2c]:443", false},
+		{"127.0.0.1:8080", true},
+		{"[::1]:80", true},
+		{"169.254.169.254:80", true},
+		{"example.com:80", true}, // dialers pass resolved addresses only
+	}
+	for _, tt := range tests {
+		err := Control("tcp", tt.address, nil)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Control(%s) = %v, want error %v", tt.address, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("Control(%s) = %v, want ErrForbiddenAddress", tt.address, err)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		wantErr bool
+	}{
+		{"93.184.215.14", false},
+		{"10.0.0.1", true},
+		{"::1", true},
+		{"localhost", true},
+		{"unresolvable.invalid", false}, // refused when dialing instead
+	}
+	for _, tt := range tests {
+		err := CheckHost(context.Background(), tt.host)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckHost(%s) = %v, want error %v", tt.host, err, tt.wantErr)
+		}
+	}
+}
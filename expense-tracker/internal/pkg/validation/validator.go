@@ -63,6 +63,23 @@ func (v *Validator) Validate(data interface{}) error {
 func (v *Validator) validateField(fieldName string, fieldValue reflect.Value, tag string) *ValidationError {
 	rules := strings.Split(tag, ",")
 	
+	// Optional fields are pointers: a nil pointer only fails "required",
+	// otherwise the rules apply to the value it points to.
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			for _, rule := range rules {
+				if strings.TrimSpace(rule) == "required" {
+					return &ValidationError{
+						Field: fieldName,
+						Error: fmt.Sprintf("%s is required", fieldName),
+					}
+				}
+			}
+			return nil
+		}
+		fieldValue = fieldValue.Elem()
+	}
+	
 	for _, rule := range rules {
 		rule = strings.TrimSpace(rule)
 		
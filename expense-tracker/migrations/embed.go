@@ -0,0 +1,8 @@
+// Package migrations embeds the versioned schema migrations. Each dialect has
+// its own directory of NNNN_name.up.sql / NNNN_name.down.sql files.
+package migrations
+
+import "embed"
+
+//go:embed sqlite/*.sql postgres/*.sql
+var FS embed.FS
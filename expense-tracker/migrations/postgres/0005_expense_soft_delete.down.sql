@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_expenses_deleted_at;
+ALTER TABLE expenses DROP COLUMN deleted_at;
@@ -0,0 +1,5 @@
+-- Trashed expenses keep their row until purged
+ALTER TABLE expenses ADD COLUMN deleted_at TIMESTAMP;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_expenses_deleted_at ON expenses(deleted_at);
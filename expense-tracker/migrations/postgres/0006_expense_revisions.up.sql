@@ -0,0 +1,18 @@
+-- Create expense change history table
+CREATE TABLE IF NOT EXISTS expense_revisions (
+    id VARCHAR(36) PRIMARY KEY,
+    expense_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    actor_id VARCHAR(36) NOT NULL,
+    revision INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    changes TEXT NOT NULL,
+    snapshot TEXT NOT NULL,
+    reverted_to INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (expense_id, revision),
+    FOREIGN KEY (expense_id) REFERENCES expenses(id) ON DELETE CASCADE
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_expense_revisions_user_id ON expense_revisions(user_id);
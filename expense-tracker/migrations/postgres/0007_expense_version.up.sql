@@ -0,0 +1,2 @@
+-- Version is incremented on every write for optimistic concurrency control
+ALTER TABLE expenses ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
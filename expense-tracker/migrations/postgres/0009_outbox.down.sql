@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS outbox_events;
+DROP FUNCTION IF EXISTS notify_outbox_events();
@@ -0,0 +1,2 @@
+-- Disabled users cannot log in; their data is kept
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMP;
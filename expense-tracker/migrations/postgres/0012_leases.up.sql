@@ -0,0 +1,6 @@
+-- Named leases elect the one process that runs a job, such as the outbox relay
+CREATE TABLE IF NOT EXISTS leases (
+    name VARCHAR(100) PRIMARY KEY,
+    holder VARCHAR(100) NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
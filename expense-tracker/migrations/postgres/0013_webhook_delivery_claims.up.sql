@@ -0,0 +1,2 @@
+-- A claimed delivery is skipped by other instances until the claim expires
+ALTER TABLE webhook_deliveries ADD COLUMN locked_until TIMESTAMP;
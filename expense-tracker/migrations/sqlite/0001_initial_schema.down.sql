@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS expenses;
+DROP TABLE IF EXISTS users;
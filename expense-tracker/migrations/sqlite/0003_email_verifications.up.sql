@@ -0,0 +1,9 @@
+-- Create pending email changes table
+CREATE TABLE IF NOT EXISTS email_verifications (
+    user_id TEXT PRIMARY KEY,
+    email TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,15 @@
+-- Create personal data exports table
+CREATE TABLE IF NOT EXISTS data_exports (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    status TEXT NOT NULL,
+    file_name TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports(user_id);
@@ -0,0 +1,18 @@
+-- Create expense change history table
+CREATE TABLE IF NOT EXISTS expense_revisions (
+    id TEXT PRIMARY KEY,
+    expense_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    actor_id TEXT NOT NULL,
+    revision INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    changes TEXT NOT NULL,
+    snapshot TEXT NOT NULL,
+    reverted_to INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (expense_id, revision),
+    FOREIGN KEY (expense_id) REFERENCES expenses(id) ON DELETE CASCADE
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_expense_revisions_user_id ON expense_revisions(user_id);
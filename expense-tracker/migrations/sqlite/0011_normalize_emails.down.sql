@@ -0,0 +1 @@
+-- The original letter case of emails is not kept, so there is nothing to undo
@@ -0,0 +1,7 @@
+-- Emails are stored and looked up in lower case. An account whose lower-cased
+-- email is taken by another account keeps its own, to be resolved by hand.
+UPDATE users SET email = LOWER(email)
+WHERE email <> LOWER(email)
+  AND NOT EXISTS (SELECT 1 FROM users other WHERE other.id <> users.id AND LOWER(other.email) = LOWER(users.email));
+
+UPDATE email_verifications SET email = LOWER(email) WHERE email <> LOWER(email);
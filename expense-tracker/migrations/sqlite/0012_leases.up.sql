@@ -0,0 +1,6 @@
+-- Named leases elect the one process that runs a job, such as the outbox relay
+CREATE TABLE IF NOT EXISTS leases (
+    name TEXT PRIMARY KEY,
+    holder TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
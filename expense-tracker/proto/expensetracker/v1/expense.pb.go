@@ -0,0 +1,1228 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: expensetracker/v1/expense.proto
+
+package expensetrackerv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Expense struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Amount float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	// One of groceries, leisure, electronics, utilities, clothing, health
+	// or others.
+	Category    string `protobuf:"bytes,3,opt,name=category,proto3" json:"category,omitempty"`
+	Description string `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	// YYYY-MM-DD.
+	Date          string                 `protobuf:"bytes,5,opt,name=date,proto3" json:"date,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Version       int32                  `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Expense) Reset() {
+	*x = Expense{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Expense) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Expense) ProtoMessage() {}
+
+func (x *Expense) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Expense.ProtoReflect.Descriptor instead.
+func (*Expense) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Expense) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Expense) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Expense) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Expense) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Expense) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *Expense) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Expense) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Expense) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type FieldChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Old           *structpb.Value        `protobuf:"bytes,2,opt,name=old,proto3" json:"old,omitempty"`
+	New           *structpb.Value        `protobuf:"bytes,3,opt,name=new,proto3" json:"new,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FieldChange) Reset() {
+	*x = FieldChange{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FieldChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldChange) ProtoMessage() {}
+
+func (x *FieldChange) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldChange.ProtoReflect.Descriptor instead.
+func (*FieldChange) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FieldChange) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *FieldChange) GetOld() *structpb.Value {
+	if x != nil {
+		return x.Old
+	}
+	return nil
+}
+
+func (x *FieldChange) GetNew() *structpb.Value {
+	if x != nil {
+		return x.New
+	}
+	return nil
+}
+
+type ExpenseRevision struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Revision int32                  `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	// created, updated, deleted, restored or reverted.
+	Action        string                 `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`
+	ActorId       string                 `protobuf:"bytes,3,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
+	Changes       []*FieldChange         `protobuf:"bytes,4,rep,name=changes,proto3" json:"changes,omitempty"`
+	RevertedTo    *int32                 `protobuf:"varint,5,opt,name=reverted_to,json=revertedTo,proto3,oneof" json:"reverted_to,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExpenseRevision) Reset() {
+	*x = ExpenseRevision{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpenseRevision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpenseRevision) ProtoMessage() {}
+
+func (x *ExpenseRevision) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpenseRevision.ProtoReflect.Descriptor instead.
+func (*ExpenseRevision) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ExpenseRevision) GetRevision() int32 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *ExpenseRevision) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *ExpenseRevision) GetActorId() string {
+	if x != nil {
+		return x.ActorId
+	}
+	return ""
+}
+
+func (x *ExpenseRevision) GetChanges() []*FieldChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+func (x *ExpenseRevision) GetRevertedTo() int32 {
+	if x != nil && x.RevertedTo != nil {
+		return *x.RevertedTo
+	}
+	return 0
+}
+
+func (x *ExpenseRevision) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type CategoryTotal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Category      string                 `protobuf:"bytes,1,opt,name=category,proto3" json:"category,omitempty"`
+	Total         float64                `protobuf:"fixed64,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CategoryTotal) Reset() {
+	*x = CategoryTotal{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CategoryTotal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CategoryTotal) ProtoMessage() {}
+
+func (x *CategoryTotal) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CategoryTotal.ProtoReflect.Descriptor instead.
+func (*CategoryTotal) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CategoryTotal) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *CategoryTotal) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type CreateExpenseRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Amount      float64                `protobuf:"fixed64,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	Category    string                 `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
+	Description string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	// YYYY-MM-DD.
+	Date          string `protobuf:"bytes,4,opt,name=date,proto3" json:"date,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateExpenseRequest) Reset() {
+	*x = CreateExpenseRequest{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateExpenseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateExpenseRequest) ProtoMessage() {}
+
+func (x *CreateExpenseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateExpenseRequest.ProtoReflect.Descriptor instead.
+func (*CreateExpenseRequest) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateExpenseRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *CreateExpenseRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *CreateExpenseRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreateExpenseRequest) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+type CreateExpenseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Expense       *Expense               `protobuf:"bytes,1,opt,name=expense,proto3" json:"expense,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateExpenseResponse) Reset() {
+	*x = CreateExpenseResponse{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateExpenseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateExpenseResponse) ProtoMessage() {}
+
+func (x *CreateExpenseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateExpenseResponse.ProtoReflect.Descriptor instead.
+func (*CreateExpenseResponse) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CreateExpenseResponse) GetExpense() *Expense {
+	if x != nil {
+		return x.Expense
+	}
+	return nil
+}
+
+type GetExpenseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetExpenseRequest) Reset() {
+	*x = GetExpenseRequest{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetExpenseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetExpenseRequest) ProtoMessage() {}
+
+func (x *GetExpenseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetExpenseRequest.ProtoReflect.Descriptor instead.
+func (*GetExpenseRequest) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetExpenseRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetExpenseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Expense       *Expense               `protobuf:"bytes,1,opt,name=expense,proto3" json:"expense,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetExpenseResponse) Reset() {
+	*x = GetExpenseResponse{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetExpenseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetExpenseResponse) ProtoMessage() {}
+
+func (x *GetExpenseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetExpenseResponse.ProtoReflect.Descriptor instead.
+func (*GetExpenseResponse) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetExpenseResponse) GetExpense() *Expense {
+	if x != nil {
+		return x.Expense
+	}
+	return nil
+}
+
+// ListExpensesRequest takes the filters of GET /api/expenses.
+type ListExpensesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// week, month, 3months or custom; custom uses start_date and end_date.
+	Period        string `protobuf:"bytes,1,opt,name=period,proto3" json:"period,omitempty"`
+	StartDate     string `protobuf:"bytes,2,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate       string `protobuf:"bytes,3,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	Category      string `protobuf:"bytes,4,opt,name=category,proto3" json:"category,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListExpensesRequest) Reset() {
+	*x = ListExpensesRequest{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListExpensesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListExpensesRequest) ProtoMessage() {}
+
+func (x *ListExpensesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListExpensesRequest.ProtoReflect.Descriptor instead.
+func (*ListExpensesRequest) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListExpensesRequest) GetPeriod() string {
+	if x != nil {
+		return x.Period
+	}
+	return ""
+}
+
+func (x *ListExpensesRequest) GetStartDate() string {
+	if x != nil {
+		return x.StartDate
+	}
+	return ""
+}
+
+func (x *ListExpensesRequest) GetEndDate() string {
+	if x != nil {
+		return x.EndDate
+	}
+	return ""
+}
+
+func (x *ListExpensesRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+type ListExpensesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Expense       *Expense               `protobuf:"bytes,1,opt,name=expense,proto3" json:"expense,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListExpensesResponse) Reset() {
+	*x = ListExpensesResponse{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListExpensesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListExpensesResponse) ProtoMessage() {}
+
+func (x *ListExpensesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListExpensesResponse.ProtoReflect.Descriptor instead.
+func (*ListExpensesResponse) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListExpensesResponse) GetExpense() *Expense {
+	if x != nil {
+		return x.Expense
+	}
+	return nil
+}
+
+// UpdateExpenseRequest changes the fields that are set. A non-zero
+// expected_version makes the update fail with ABORTED unless the expense is
+// still at that version.
+type UpdateExpenseRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Amount          *float64               `protobuf:"fixed64,2,opt,name=amount,proto3,oneof" json:"amount,omitempty"`
+	Category        *string                `protobuf:"bytes,3,opt,name=category,proto3,oneof" json:"category,omitempty"`
+	Description     *string                `protobuf:"bytes,4,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	Date            *string                `protobuf:"bytes,5,opt,name=date,proto3,oneof" json:"date,omitempty"`
+	ExpectedVersion int32                  `protobuf:"varint,6,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UpdateExpenseRequest) Reset() {
+	*x = UpdateExpenseRequest{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateExpenseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateExpenseRequest) ProtoMessage() {}
+
+func (x *UpdateExpenseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateExpenseRequest.ProtoReflect.Descriptor instead.
+func (*UpdateExpenseRequest) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UpdateExpenseRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateExpenseRequest) GetAmount() float64 {
+	if x != nil && x.Amount != nil {
+		return *x.Amount
+	}
+	return 0
+}
+
+func (x *UpdateExpenseRequest) GetCategory() string {
+	if x != nil && x.Category != nil {
+		return *x.Category
+	}
+	return ""
+}
+
+func (x *UpdateExpenseRequest) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *UpdateExpenseRequest) GetDate() string {
+	if x != nil && x.Date != nil {
+		return *x.Date
+	}
+	return ""
+}
+
+func (x *UpdateExpenseRequest) GetExpectedVersion() int32 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+type UpdateExpenseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Expense       *Expense               `protobuf:"bytes,1,opt,name=expense,proto3" json:"expense,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateExpenseResponse) Reset() {
+	*x = UpdateExpenseResponse{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateExpenseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateExpenseResponse) ProtoMessage() {}
+
+func (x *UpdateExpenseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateExpenseResponse.ProtoReflect.Descriptor instead.
+func (*UpdateExpenseResponse) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UpdateExpenseResponse) GetExpense() *Expense {
+	if x != nil {
+		return x.Expense
+	}
+	return nil
+}
+
+type DeleteExpenseRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ExpectedVersion int32                  `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeleteExpenseRequest) Reset() {
+	*x = DeleteExpenseRequest{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteExpenseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteExpenseRequest) ProtoMessage() {}
+
+func (x *DeleteExpenseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteExpenseRequest.ProtoReflect.Descriptor instead.
+func (*DeleteExpenseRequest) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DeleteExpenseRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteExpenseRequest) GetExpectedVersion() int32 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+type DeleteExpenseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteExpenseResponse) Reset() {
+	*x = DeleteExpenseResponse{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteExpenseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteExpenseResponse) ProtoMessage() {}
+
+func (x *DeleteExpenseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteExpenseResponse.ProtoReflect.Descriptor instead.
+func (*DeleteExpenseResponse) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{13}
+}
+
+type ListHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExpenseId     string                 `protobuf:"bytes,1,opt,name=expense_id,json=expenseId,proto3" json:"expense_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListHistoryRequest) Reset() {
+	*x = ListHistoryRequest{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListHistoryRequest) ProtoMessage() {}
+
+func (x *ListHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListHistoryRequest.ProtoReflect.Descriptor instead.
+func (*ListHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListHistoryRequest) GetExpenseId() string {
+	if x != nil {
+		return x.ExpenseId
+	}
+	return ""
+}
+
+type ListHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Revision      *ExpenseRevision       `protobuf:"bytes,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListHistoryResponse) Reset() {
+	*x = ListHistoryResponse{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListHistoryResponse) ProtoMessage() {}
+
+func (x *ListHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListHistoryResponse.ProtoReflect.Descriptor instead.
+func (*ListHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListHistoryResponse) GetRevision() *ExpenseRevision {
+	if x != nil {
+		return x.Revision
+	}
+	return nil
+}
+
+type GetCategoryTotalsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// YYYY-MM-DD, inclusive.
+	StartDate     string `protobuf:"bytes,1,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate       string `protobuf:"bytes,2,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCategoryTotalsRequest) Reset() {
+	*x = GetCategoryTotalsRequest{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCategoryTotalsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCategoryTotalsRequest) ProtoMessage() {}
+
+func (x *GetCategoryTotalsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCategoryTotalsRequest.ProtoReflect.Descriptor instead.
+func (*GetCategoryTotalsRequest) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetCategoryTotalsRequest) GetStartDate() string {
+	if x != nil {
+		return x.StartDate
+	}
+	return ""
+}
+
+func (x *GetCategoryTotalsRequest) GetEndDate() string {
+	if x != nil {
+		return x.EndDate
+	}
+	return ""
+}
+
+type GetCategoryTotalsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Totals        []*CategoryTotal       `protobuf:"bytes,1,rep,name=totals,proto3" json:"totals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCategoryTotalsResponse) Reset() {
+	*x = GetCategoryTotalsResponse{}
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCategoryTotalsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCategoryTotalsResponse) ProtoMessage() {}
+
+func (x *GetCategoryTotalsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_expensetracker_v1_expense_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCategoryTotalsResponse.ProtoReflect.Descriptor instead.
+func (*GetCategoryTotalsResponse) Descriptor() ([]byte, []int) {
+	return file_expensetracker_v1_expense_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetCategoryTotalsResponse) GetTotals() []*CategoryTotal {
+	if x != nil {
+		return x.Totals
+	}
+	return nil
+}
+
+var File_expensetracker_v1_expense_proto protoreflect.FileDescriptor
+
+const file_expensetracker_v1_expense_proto_rawDesc = "" +
+	"\n" +
+	"\x1fexpensetracker/v1/expense.proto\x12\x11expensetracker.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x93\x02\n" +
+	"\aExpense\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcategory\x18\x03 \x01(\tR\bcategory\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x12\n" +
+	"\x04date\x18\x05 \x01(\tR\x04date\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x18\n" +
+	"\aversion\x18\b \x01(\x05R\aversion\"w\n" +
+	"\vFieldChange\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12(\n" +
+	"\x03old\x18\x02 \x01(\v2\x16.google.protobuf.ValueR\x03old\x12(\n" +
+	"\x03new\x18\x03 \x01(\v2\x16.google.protobuf.ValueR\x03new\"\x8b\x02\n" +
+	"\x0fExpenseRevision\x12\x1a\n" +
+	"\brevision\x18\x01 \x01(\x05R\brevision\x12\x16\n" +
+	"\x06action\x18\x02 \x01(\tR\x06action\x12\x19\n" +
+	"\bactor_id\x18\x03 \x01(\tR\aactorId\x128\n" +
+	"\achanges\x18\x04 \x03(\v2\x1e.expensetracker.v1.FieldChangeR\achanges\x12$\n" +
+	"\vreverted_to\x18\x05 \x01(\x05H\x00R\n" +
+	"revertedTo\x88\x01\x01\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAtB\x0e\n" +
+	"\f_reverted_to\"A\n" +
+	"\rCategoryTotal\x12\x1a\n" +
+	"\bcategory\x18\x01 \x01(\tR\bcategory\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x01R\x05total\"\x80\x01\n" +
+	"\x14CreateExpenseRequest\x12\x16\n" +
+	"\x06amount\x18\x01 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcategory\x18\x02 \x01(\tR\bcategory\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x12\n" +
+	"\x04date\x18\x04 \x01(\tR\x04date\"M\n" +
+	"\x15CreateExpenseResponse\x124\n" +
+	"\aexpense\x18\x01 \x01(\v2\x1a.expensetracker.v1.ExpenseR\aexpense\"#\n" +
+	"\x11GetExpenseRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"J\n" +
+	"\x12GetExpenseResponse\x124\n" +
+	"\aexpense\x18\x01 \x01(\v2\x1a.expensetracker.v1.ExpenseR\aexpense\"\x83\x01\n" +
+	"\x13ListExpensesRequest\x12\x16\n" +
+	"\x06period\x18\x01 \x01(\tR\x06period\x12\x1d\n" +
+	"\n" +
+	"start_date\x18\x02 \x01(\tR\tstartDate\x12\x19\n" +
+	"\bend_date\x18\x03 \x01(\tR\aendDate\x12\x1a\n" +
+	"\bcategory\x18\x04 \x01(\tR\bcategory\"L\n" +
+	"\x14ListExpensesResponse\x124\n" +
+	"\aexpense\x18\x01 \x01(\v2\x1a.expensetracker.v1.ExpenseR\aexpense\"\x80\x02\n" +
+	"\x14UpdateExpenseRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\x06amount\x18\x02 \x01(\x01H\x00R\x06amount\x88\x01\x01\x12\x1f\n" +
+	"\bcategory\x18\x03 \x01(\tH\x01R\bcategory\x88\x01\x01\x12%\n" +
+	"\vdescription\x18\x04 \x01(\tH\x02R\vdescription\x88\x01\x01\x12\x17\n" +
+	"\x04date\x18\x05 \x01(\tH\x03R\x04date\x88\x01\x01\x12)\n" +
+	"\x10expected_version\x18\x06 \x01(\x05R\x0fexpectedVersionB\t\n" +
+	"\a_amountB\v\n" +
+	"\t_categoryB\x0e\n" +
+	"\f_descriptionB\a\n" +
+	"\x05_date\"M\n" +
+	"\x15UpdateExpenseResponse\x124\n" +
+	"\aexpense\x18\x01 \x01(\v2\x1a.expensetracker.v1.ExpenseR\aexpense\"Q\n" +
+	"\x14DeleteExpenseRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12)\n" +
+	"\x10expected_version\x18\x02 \x01(\x05R\x0fexpectedVersion\"\x17\n" +
+	"\x15DeleteExpenseResponse\"3\n" +
+	"\x12ListHistoryRequest\x12\x1d\n" +
+	"\n" +
+	"expense_id\x18\x01 \x01(\tR\texpenseId\"U\n" +
+	"\x13ListHistoryResponse\x12>\n" +
+	"\brevision\x18\x01 \x01(\v2\".expensetracker.v1.ExpenseRevisionR\brevision\"T\n" +
+	"\x18GetCategoryTotalsRequest\x12\x1d\n" +
+	"\n" +
+	"start_date\x18\x01 \x01(\tR\tstartDate\x12\x19\n" +
+	"\bend_date\x18\x02 \x01(\tR\aendDate\"U\n" +
+	"\x19GetCategoryTotalsResponse\x128\n" +
+	"\x06totals\x18\x01 \x03(\v2 .expensetracker.v1.CategoryTotalR\x06totals2\xca\x05\n" +
+	"\x0eExpenseService\x12b\n" +
+	"\rCreateExpense\x12'.expensetracker.v1.CreateExpenseRequest\x1a(.expensetracker.v1.CreateExpenseResponse\x12Y\n" +
+	"\n" +
+	"GetExpense\x12$.expensetracker.v1.GetExpenseRequest\x1a%.expensetracker.v1.GetExpenseResponse\x12a\n" +
+	"\fListExpenses\x12&.expensetracker.v1.ListExpensesRequest\x1a'.expensetracker.v1.ListExpensesResponse0\x01\x12b\n" +
+	"\rUpdateExpense\x12'.expensetracker.v1.UpdateExpenseRequest\x1a(.expensetracker.v1.UpdateExpenseResponse\x12b\n" +
+	"\rDeleteExpense\x12'.expensetracker.v1.DeleteExpenseRequest\x1a(.expensetracker.v1.DeleteExpenseResponse\x12^\n" +
+	"\vListHistory\x12%.expensetracker.v1.ListHistoryRequest\x1a&.expensetracker.v1.ListHistoryResponse0\x01\x12n\n" +
+	"\x11GetCategoryTotals\x12+.expensetracker.v1.GetCategoryTotalsRequest\x1a,.expensetracker.v1.GetCategoryTotalsResponseB:Z8expense-tracker/proto/expensetracker/v1;expensetrackerv1b\x06proto3"
+
+var (
+	file_expensetracker_v1_expense_proto_rawDescOnce sync.Once
+	file_expensetracker_v1_expense_proto_rawDescData []byte
+)
+
+func file_expensetracker_v1_expense_proto_rawDescGZIP() []byte {
+	file_expensetracker_v1_expense_proto_rawDescOnce.Do(func() {
+		file_expensetracker_v1_expense_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_expensetracker_v1_expense_proto_rawDesc), len(file_expensetracker_v1_expense_proto_rawDesc)))
+	})
+	return file_expensetracker_v1_expense_proto_rawDescData
+}
+
+var file_expensetracker_v1_expense_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_expensetracker_v1_expense_proto_goTypes = []any{
+	(*Expense)(nil),                   // 0: expensetracker.v1.Expense
+	(*FieldChange)(nil),               // 1: expensetracker.v1.FieldChange
+	(*ExpenseRevision)(nil),           // 2: expensetracker.v1.ExpenseRevision
+	(*CategoryTotal)(nil),             // 3: expensetracker.v1.CategoryTotal
+	(*CreateExpenseRequest)(nil),      // 4: expensetracker.v1.CreateExpenseRequest
+	(*CreateExpenseResponse)(nil),     // 5: expensetracker.v1.CreateExpenseResponse
+	(*GetExpenseRequest)(nil),         // 6: expensetracker.v1.GetExpenseRequest
+	(*GetExpenseResponse)(nil),        // 7: expensetracker.v1.GetExpenseResponse
+	(*ListExpensesRequest)(nil),       // 8: expensetracker.v1.ListExpensesRequest
+	(*ListExpensesResponse)(nil),      // 9: expensetracker.v1.ListExpensesResponse
+	(*UpdateExpenseRequest)(nil),      // 10: expensetracker.v1.UpdateExpenseRequest
+	(*UpdateExpenseResponse)(nil),     // 11: expensetracker.v1.UpdateExpenseResponse
+	(*DeleteExpenseRequest)(nil),      // 12: expensetracker.v1.DeleteExpenseRequest
+	(*DeleteExpenseResponse)(nil),     // 13: expensetracker.v1.DeleteExpenseResponse
+	(*ListHistoryRequest)(nil),        // 14: expensetracker.v1.ListHistoryRequest
+	(*ListHistoryResponse)(nil),       // 15: expensetracker.v1.ListHistoryResponse
+	(*GetCategoryTotalsRequest)(nil),  // 16: expensetracker.v1.GetCategoryTotalsRequest
+	(*GetCategoryTotalsResponse)(nil), // 17: expensetracker.v1.GetCategoryTotalsResponse
+	(*timestamppb.Timestamp)(nil),     // 18: google.protobuf.Timestamp
+	(*structpb.Value)(nil),            // 19: google.protobuf.Value
+}
+var file_expensetracker_v1_expense_proto_depIdxs = []int32{
+	18, // 0: expensetracker.v1.Expense.created_at:type_name -> google.protobuf.Timestamp
+	18, // 1: expensetracker.v1.Expense.updated_at:type_name -> google.protobuf.Timestamp
+	19, // 2: expensetracker.v1.FieldChange.old:type_name -> google.protobuf.Value
+	19, // 3: expensetracker.v1.FieldChange.new:type_name -> google.protobuf.Value
+	1,  // 4: expensetracker.v1.ExpenseRevision.changes:type_name -> expensetracker.v1.FieldChange
+	18, // 5: expensetracker.v1.ExpenseRevision.created_at:type_name -> google.protobuf.Timestamp
+	0,  // 6: expensetracker.v1.CreateExpenseResponse.expense:type_name -> expensetracker.v1.Expense
+	0,  // 7: expensetracker.v1.GetExpenseResponse.expense:type_name -> expensetracker.v1.Expense
+	0,  // 8: expensetracker.v1.ListExpensesResponse.expense:type_name -> expensetracker.v1.Expense
+	0,  // 9: expensetracker.v1.UpdateExpenseResponse.expense:type_name -> expensetracker.v1.Expense
+	2,  // 10: expensetracker.v1.ListHistoryResponse.revision:type_name -> expensetracker.v1.ExpenseRevision
+	3,  // 11: expensetracker.v1.GetCategoryTotalsResponse.totals:type_name -> expensetracker.v1.CategoryTotal
+	4,  // 12: expensetracker.v1.ExpenseService.CreateExpense:input_type -> expensetracker.v1.CreateExpenseRequest
+	6,  // 13: expensetracker.v1.ExpenseService.GetExpense:input_type -> expensetracker.v1.GetExpenseRequest
+	8,  // 14: expensetracker.v1.ExpenseService.ListExpenses:input_type -> expensetracker.v1.ListExpensesRequest
+	10, // 15: expensetracker.v1.ExpenseService.UpdateExpense:input_type -> expensetracker.v1.UpdateExpenseRequest
+	12, // 16: expensetracker.v1.ExpenseService.DeleteExpense:input_type -> expensetracker.v1.DeleteExpenseRequest
+	14, // 17: expensetracker.v1.ExpenseService.ListHistory:input_type -> expensetracker.v1.ListHistoryRequest
+	16, // 18: expensetracker.v1.ExpenseService.GetCategoryTotals:input_type -> expensetracker.v1.GetCategoryTotalsRequest
+	5,  // 19: expensetracker.v1.ExpenseService.CreateExpense:output_type -> expensetracker.v1.CreateExpenseResponse
+	7,  // 20: expensetracker.v1.ExpenseService.GetExpense:output_type -> expensetracker.v1.GetExpenseResponse
+	9,  // 21: expensetracker.v1.ExpenseService.ListExpenses:output_type -> expensetracker.v1.ListExpensesResponse
+	11, // 22: expensetracker.v1.ExpenseService.UpdateExpense:output_type -> expensetracker.v1.UpdateExpenseResponse
+	13, // 23: expensetracker.v1.ExpenseService.DeleteExpense:output_type -> expensetracker.v1.DeleteExpenseResponse
+	15, // 24: expensetracker.v1.ExpenseService.ListHistory:output_type -> expensetracker.v1.ListHistoryResponse
+	17, // 25: expensetracker.v1.ExpenseService.GetCategoryTotals:output_type -> expensetracker.v1.GetCategoryTotalsResponse
+	19, // [19:26] is the sub-list for method output_type
+	12, // [12:19] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_expensetracker_v1_expense_proto_init() }
+func file_expensetracker_v1_expense_proto_init() {
+	if File_expensetracker_v1_expense_proto != nil {
+		return
+	}
+	file_expensetracker_v1_expense_proto_msgTypes[2].OneofWrappers = []any{}
+	file_expensetracker_v1_expense_proto_msgTypes[10].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_expensetracker_v1_expense_proto_rawDesc), len(file_expensetracker_v1_expense_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   18,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_expensetracker_v1_expense_proto_goTypes,
+		DependencyIndexes: file_expensetracker_v1_expense_proto_depIdxs,
+		MessageInfos:      file_expensetracker_v1_expense_proto_msgTypes,
+	}.Build()
+	File_expensetracker_v1_expense_proto = out.File
+	file_expensetracker_v1_expense_proto_goTypes = nil
+	file_expensetracker_v1_expense_proto_depIdxs = nil
+}
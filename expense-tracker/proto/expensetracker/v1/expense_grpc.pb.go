@@ -0,0 +1,368 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: expensetracker/v1/expense.proto
+
+package expensetrackerv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ExpenseService_CreateExpense_FullMethodName     = "/expensetracker.v1.ExpenseService/CreateExpense"
+	ExpenseService_GetExpense_FullMethodName        = "/expensetracker.v1.ExpenseService/GetExpense"
+	ExpenseService_ListExpenses_FullMethodName      = "/expensetracker.v1.ExpenseService/ListExpenses"
+	ExpenseService_UpdateExpense_FullMethodName     = "/expensetracker.v1.ExpenseService/UpdateExpense"
+	ExpenseService_DeleteExpense_FullMethodName     = "/expensetracker.v1.ExpenseService/DeleteExpense"
+	ExpenseService_ListHistory_FullMethodName       = "/expensetracker.v1.ExpenseService/ListHistory"
+	ExpenseService_GetCategoryTotals_FullMethodName = "/expensetracker.v1.ExpenseService/GetCategoryTotals"
+)
+
+// ExpenseServiceClient is the client API for ExpenseService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ExpenseService manages the expenses of the authenticated user. Every call
+// needs a token from AuthService.
+type ExpenseServiceClient interface {
+	CreateExpense(ctx context.Context, in *CreateExpenseRequest, opts ...grpc.CallOption) (*CreateExpenseResponse, error)
+	GetExpense(ctx context.Context, in *GetExpenseRequest, opts ...grpc.CallOption) (*GetExpenseResponse, error)
+	// ListExpenses streams the matching expenses, newest first.
+	ListExpenses(ctx context.Context, in *ListExpensesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListExpensesResponse], error)
+	UpdateExpense(ctx context.Context, in *UpdateExpenseRequest, opts ...grpc.CallOption) (*UpdateExpenseResponse, error)
+	// DeleteExpense moves the expense to the trash.
+	DeleteExpense(ctx context.Context, in *DeleteExpenseRequest, opts ...grpc.CallOption) (*DeleteExpenseResponse, error)
+	// ListHistory streams the revisions of an expense, oldest first.
+	ListHistory(ctx context.Context, in *ListHistoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListHistoryResponse], error)
+	GetCategoryTotals(ctx context.Context, in *GetCategoryTotalsRequest, opts ...grpc.CallOption) (*GetCategoryTotalsResponse, error)
+}
+
+type expenseServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewExpenseServiceClient(cc grpc.ClientConnInterface) ExpenseServiceClient {
+	return &expenseServiceClient{cc}
+}
+
+func (c *expenseServiceClient) CreateExpense(ctx context.Context, in *CreateExpenseRequest, opts ...grpc.CallOption) (*CreateExpenseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateExpenseResponse)
+	err := c.cc.Invoke(ctx, ExpenseService_CreateExpense_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *expenseServiceClient) GetExpense(ctx context.Context, in *GetExpenseRequest, opts ...grpc.CallOption) (*GetExpenseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetExpenseResponse)
+	err := c.cc.Invoke(ctx, ExpenseService_GetExpense_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *expenseServiceClient) ListExpenses(ctx context.Context, in *ListExpensesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListExpensesResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ExpenseService_ServiceDesc.Streams[0], ExpenseService_ListExpenses_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListExpensesRequest, ListExpensesResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ExpenseService_ListExpensesClient = grpc.ServerStreamingClient[ListExpensesResponse]
+
+func (c *expenseServiceClient) UpdateExpense(ctx context.Context, in *UpdateExpenseRequest, opts ...grpc.CallOption) (*UpdateExpenseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateExpenseResponse)
+	err := c.cc.Invoke(ctx, ExpenseService_UpdateExpense_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *expenseServiceClient) DeleteExpense(ctx context.Context, in *DeleteExpenseRequest, opts ...grpc.CallOption) (*DeleteExpenseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteExpenseResponse)
+	err := c.cc.Invoke(ctx, ExpenseService_DeleteExpense_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *expenseServiceClient) ListHistory(ctx context.Context, in *ListHistoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListHistoryResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ExpenseService_ServiceDesc.Streams[1], ExpenseService_ListHistory_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListHistoryRequest, ListHistoryResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ExpenseService_ListHistoryClient = grpc.ServerStreamingClient[ListHistoryResponse]
+
+func (c *expenseServiceClient) GetCategoryTotals(ctx context.Context, in *GetCategoryTotalsRequest, opts ...grpc.CallOption) (*GetCategoryTotalsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCategoryTotalsResponse)
+	err := c.cc.Invoke(ctx, ExpenseService_GetCategoryTotals_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExpenseServiceServer is the server API for ExpenseService service.
+// All implementations must embed UnimplementedExpenseServiceServer
+// for forward compatibility.
+//
+// ExpenseService manages the expenses of the authenticated user. Every call
+// needs a token from AuthService.
+type ExpenseServiceServer interface {
+	CreateExpense(context.Context, *CreateExpenseRequest) (*CreateExpenseResponse, error)
+	GetExpense(context.Context, *GetExpenseRequest) (*GetExpenseResponse, error)
+	// ListExpenses streams the matching expenses, newest first.
+	ListExpenses(*ListExpensesRequest, grpc.ServerStreamingServer[ListExpensesResponse]) error
+	UpdateExpense(context.Context, *UpdateExpenseRequest) (*UpdateExpenseResponse, error)
+	// DeleteExpense moves the expense to the trash.
+	DeleteExpense(context.Context, *DeleteExpenseRequest) (*DeleteExpenseResponse, error)
+	// ListHistory streams the revisions of an expense, oldest first.
+	ListHistory(*ListHistoryRequest, grpc.ServerStreamingServer[ListHistoryResponse]) error
+	GetCategoryTotals(context.Context, *GetCategoryTotalsRequest) (*GetCategoryTotalsResponse, error)
+	mustEmbedUnimplementedExpenseServiceServer()
+}
+
+// UnimplementedExpenseServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedExpenseServiceServer struct{}
+
+func (UnimplementedExpenseServiceServer) CreateExpense(context.Context, *CreateExpenseRequest) (*CreateExpenseResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateExpense not implemented")
+}
+func (UnimplementedExpenseServiceServer) GetExpense(context.Context, *GetExpenseRequest) (*GetExpenseResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetExpense not implemented")
+}
+func (UnimplementedExpenseServiceServer) ListExpenses(*ListExpensesRequest, grpc.ServerStreamingServer[ListExpensesResponse]) error {
+	return status.Error(codes.Unimplemented, "method ListExpenses not implemented")
+}
+func (UnimplementedExpenseServiceServer) UpdateExpense(context.Context, *UpdateExpenseRequest) (*UpdateExpenseResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateExpense not implemented")
+}
+func (UnimplementedExpenseServiceServer) DeleteExpense(context.Context, *DeleteExpenseRequest) (*DeleteExpenseResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteExpense not implemented")
+}
+func (UnimplementedExpenseServiceServer) ListHistory(*ListHistoryRequest, grpc.ServerStreamingServer[ListHistoryResponse]) error {
+	return status.Error(codes.Unimplemented, "method ListHistory not implemented")
+}
+func (UnimplementedExpenseServiceServer) GetCategoryTotals(context.Context, *GetCategoryTotalsRequest) (*GetCategoryTotalsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetCategoryTotals not implemented")
+}
+func (UnimplementedExpenseServiceServer) mustEmbedUnimplementedExpenseServiceServer() {}
+func (UnimplementedExpenseServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeExpenseServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ExpenseServiceServer will
+// result in compilation errors.
+type UnsafeExpenseServiceServer interface {
+	mustEmbedUnimplementedExpenseServiceServer()
+}
+
+func RegisterExpenseServiceServer(s grpc.ServiceRegistrar, srv ExpenseServiceServer) {
+	// If the following call panics, it indicates UnimplementedExpenseServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ExpenseService_ServiceDesc, srv)
+}
+
+func _ExpenseService_CreateExpense_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateExpenseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExpenseServiceServer).CreateExpense(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExpenseService_CreateExpense_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExpenseServiceServer).CreateExpense(ctx, req.(*CreateExpenseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExpenseService_GetExpense_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetExpenseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExpenseServiceServer).GetExpense(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExpenseService_GetExpense_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExpenseServiceServer).GetExpense(ctx, req.(*GetExpenseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExpenseService_ListExpenses_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListExpensesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ExpenseServiceServer).ListExpenses(m, &grpc.GenericServerStream[ListExpensesRequest, ListExpensesResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ExpenseService_ListExpensesServer = grpc.ServerStreamingServer[ListExpensesResponse]
+
+func _ExpenseService_UpdateExpense_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateExpenseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExpenseServiceServer).UpdateExpense(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExpenseService_UpdateExpense_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExpenseServiceServer).UpdateExpense(ctx, req.(*UpdateExpenseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExpenseService_DeleteExpense_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteExpenseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExpenseServiceServer).DeleteExpense(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExpenseService_DeleteExpense_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExpenseServiceServer).DeleteExpense(ctx, req.(*DeleteExpenseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExpenseService_ListHistory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListHistoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ExpenseServiceServer).ListHistory(m, &grpc.GenericServerStream[ListHistoryRequest, ListHistoryResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ExpenseService_ListHistoryServer = grpc.ServerStreamingServer[ListHistoryResponse]
+
+func _ExpenseService_GetCategoryTotals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCategoryTotalsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExpenseServiceServer).GetCategoryTotals(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExpenseService_GetCategoryTotals_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExpenseServiceServer).GetCategoryTotals(ctx, req.(*GetCategoryTotalsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ExpenseService_ServiceDesc is the grpc.ServiceDesc for ExpenseService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ExpenseService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "expensetracker.v1.ExpenseService",
+	HandlerType: (*ExpenseServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateExpense",
+			Handler:    _ExpenseService_CreateExpense_Handler,
+		},
+		{
+			MethodName: "GetExpense",
+			Handler:    _ExpenseService_GetExpense_Handler,
+		},
+		{
+			MethodName: "UpdateExpense",
+			Handler:    _ExpenseService_UpdateExpense_Handler,
+		},
+		{
+			MethodName: "DeleteExpense",
+			Handler:    _ExpenseService_DeleteExpense_Handler,
+		},
+		{
+			MethodName: "GetCategoryTotals",
+			Handler:    _ExpenseService_GetCategoryTotals_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListExpenses",
+			Handler:       _ExpenseService_ListExpenses_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListHistory",
+			Handler:       _ExpenseService_ListHistory_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "expensetracker/v1/expense.proto",
+}